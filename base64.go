@@ -0,0 +1,18 @@
+package gorun
+
+import (
+	"bytes"
+	"encoding/base64"
+)
+
+// decodeBase64 returns the decoding of b from standard base64 encoding,
+// ignoring any white space in b.
+func decodeBase64(b []byte) ([]byte, error) {
+	b = bytes.Join(bytes.Fields(b), nil)
+	decoded := make([]byte, base64.StdEncoding.DecodedLen(len(b)))
+	n, err := base64.StdEncoding.Decode(decoded, b)
+	if err != nil {
+		return nil, err
+	}
+	return decoded[:n], nil
+}
@@ -0,0 +1,111 @@
+package gorun
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sort"
+	"time"
+)
+
+// BenchResult represents the wall clock durations of the measured runs
+// of a child process spawned by Benchmark.
+type BenchResult struct {
+	// Durations are the elapsed wall clock times of the measured runs,
+	// in the order they were run.
+	Durations []time.Duration
+
+	// Min is the shortest of Durations.
+	Min time.Duration
+
+	// Max is the longest of Durations.
+	Max time.Duration
+
+	// Mean is the arithmetic mean of Durations.
+	Mean time.Duration
+
+	// Median is the middle of Durations when sorted, or the mean of the
+	// middle two when there is an even number of them.
+	Median time.Duration
+
+	// StdDev is the population standard deviation of Durations.
+	StdDev time.Duration
+}
+
+// Benchmark executes a system command warmup times, discarding the
+// results, then runs more times, and returns statistics about the
+// durations of the latter runs. Each run is executed by Measure, so
+// the output of the child process is discarded. Because the command is
+// executed many times, req ought not to specify standard input that can
+// only be read once.
+//
+// It returns the error from Measure of the first run that fails, an
+// ErrExit error for the first run whose child process exits with a
+// non-zero exit code when SuccessCodes is empty, and an ErrCanceled
+// error when the context is done between runs. When warmup is negative
+// or runs is not positive, it returns an ErrInvalidRequest error.
+func (req *Request) Benchmark(ctx context.Context, warmup, runs int) (BenchResult, error) {
+	return req.benchmark(ctx, warmup, runs, req.Measure)
+}
+
+// benchmark runs req like Benchmark does, executing each run with
+// measure.
+func (req *Request) benchmark(ctx context.Context, warmup, runs int, measure func(context.Context) (Metrics, error)) (BenchResult, error) {
+	if runs <= 0 {
+		return BenchResult{}, ErrInvalidRequest{Err: errors.New("cannot benchmark fewer than one run")}
+	}
+	if warmup < 0 {
+		return BenchResult{}, ErrInvalidRequest{Err: errors.New("cannot warm up fewer than zero runs")}
+	}
+	durations := make([]time.Duration, 0, runs)
+	for i := 0; i < warmup+runs; i++ {
+		if err := ctx.Err(); err != nil {
+			return BenchResult{}, ErrCanceled{Err: err}
+		}
+		m, err := measure(ctx)
+		if err != nil {
+			return BenchResult{}, err
+		}
+		if m.Code != 0 && len(req.SuccessCodes) == 0 {
+			// Measure reports a code not listed in SuccessCodes as
+			// an error.
+			return BenchResult{}, ErrExit{Code: m.Code}
+		}
+		if i >= warmup {
+			durations = append(durations, m.Duration)
+		}
+	}
+	return newBenchResult(durations), nil
+}
+
+// newBenchResult returns the statistics of durations, which must not be
+// empty.
+func newBenchResult(durations []time.Duration) BenchResult {
+	sorted := append([]time.Duration(nil), durations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	var sum float64
+	for _, d := range sorted {
+		sum += float64(d)
+	}
+	mean := sum / float64(len(sorted))
+
+	var squares float64
+	for _, d := range sorted {
+		squares += (float64(d) - mean) * (float64(d) - mean)
+	}
+
+	median := sorted[len(sorted)/2]
+	if len(sorted)%2 == 0 {
+		median = (sorted[len(sorted)/2-1] + median) / 2
+	}
+
+	return BenchResult{
+		Durations: durations,
+		Min:       sorted[0],
+		Max:       sorted[len(sorted)-1],
+		Mean:      time.Duration(mean),
+		Median:    median,
+		StdDev:    time.Duration(math.Sqrt(squares / float64(len(sorted)))),
+	}
+}
@@ -0,0 +1,75 @@
+//go:build !windows
+// +build !windows
+
+package gorun
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestBenchmark(t *testing.T) {
+	t.Run("populated", func(t *testing.T) {
+		got, err := (&Request{Path: "/usr/bin/true"}).Benchmark(context.Background(), 2, 5)
+		ensureError(t, err, nil)
+		if g, w := len(got.Durations), 5; g != w {
+			t.Fatalf("GOT: %v; WANT: %v", g, w)
+		}
+		if got.Min <= 0 {
+			t.Errorf("GOT: %v; WANT: > 0", got.Min)
+		}
+		if !(got.Min <= got.Median && got.Median <= got.Max) {
+			t.Errorf("GOT: %v <= %v <= %v; WANT: ordered", got.Min, got.Median, got.Max)
+		}
+		if !(got.Min <= got.Mean && got.Mean <= got.Max) {
+			t.Errorf("GOT: %v <= %v <= %v; WANT: ordered", got.Min, got.Mean, got.Max)
+		}
+		if got.StdDev < 0 || got.StdDev > got.Max-got.Min {
+			t.Errorf("GOT: %v; WANT: between 0 and %v", got.StdDev, got.Max-got.Min)
+		}
+	})
+
+	t.Run("statistics", func(t *testing.T) {
+		got := newBenchResult([]time.Duration{4, 1, 3, 2})
+		want := BenchResult{
+			Durations: []time.Duration{4, 1, 3, 2},
+			Min:       1,
+			Max:       4,
+			Mean:      2,
+			Median:    2,
+			StdDev:    1,
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("GOT: %+v; WANT: %+v", got, want)
+		}
+	})
+
+	t.Run("exit", func(t *testing.T) {
+		_, err := (&Request{Path: "/usr/bin/false"}).Benchmark(context.Background(), 0, 3)
+		ensureError(t, err, ErrExit{Code: 1})
+	})
+
+	t.Run("hard timeout", func(t *testing.T) {
+		_, err := (&Request{
+			Path:        "/bin/sleep",
+			Args:        []string{"5"},
+			HardTimeout: 100 * time.Millisecond,
+		}).Benchmark(context.Background(), 0, 3)
+		ensureError(t, err, ErrHardTimeout{Err: ErrSignal{Err: errors.New("signal: killed")}})
+	})
+
+	t.Run("no runs", func(t *testing.T) {
+		_, err := (&Request{Path: "/usr/bin/true"}).Benchmark(context.Background(), 1, 0)
+		ensureError(t, err, ErrInvalidRequest{Err: errors.New("cannot benchmark fewer than one run")})
+	})
+
+	t.Run("canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err := (&Request{Path: "/usr/bin/true"}).Benchmark(ctx, 0, 3)
+		ensureError(t, err, ErrCanceled{Err: context.Canceled})
+	})
+}
@@ -0,0 +1,409 @@
+// Package cache provides a gorun.Executor that caches the responses of
+// the requests it runs, for idempotent commands that are expensive to
+// run.
+package cache
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"hash"
+	"io"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/karrick/gorun"
+)
+
+// Entry is a cached response along with when it expires.
+type Entry struct {
+	// Response is the cached response.
+	Response *gorun.Response
+
+	// Stdout and Stderr are what the child process wrote to its
+	// standard output and standard error, as received by StdoutTee
+	// and StderrTee, before any of the filtering or decoding reflected
+	// in Response. They are written to the tees and writers of the
+	// requests served from the entry.
+	Stdout, Stderr []byte
+
+	// Expires is when the entry expires, or the zero Time when it
+	// never expires.
+	Expires time.Time
+}
+
+// expired returns true when e has expired as of now.
+func (e Entry) expired(now time.Time) bool {
+	return !e.Expires.IsZero() && !now.Before(e.Expires)
+}
+
+// Store is the interface implemented by values that store cache
+// entries. Implementations must be safe for concurrent use.
+type Store interface {
+	// Get returns the entry stored for key, and false when there is
+	// none.
+	Get(key string) (Entry, bool)
+
+	// Set stores entry for key, replacing any entry already stored
+	// for it.
+	Set(key string, entry Entry)
+}
+
+// MemoryStore is a Store that keeps entries in memory. Expired entries
+// are removed when they are next looked up. The zero value is ready to
+// use.
+type MemoryStore struct {
+	lock    sync.Mutex
+	entries map[string]Entry
+}
+
+// Get returns the entry stored for key, and false when there is none.
+func (ms *MemoryStore) Get(key string) (Entry, bool) {
+	ms.lock.Lock()
+	defer ms.lock.Unlock()
+	entry, ok := ms.entries[key]
+	if ok && entry.expired(time.Now()) {
+		delete(ms.entries, key)
+		return Entry{}, false
+	}
+	return entry, ok
+}
+
+// Set stores entry for key, replacing any entry already stored for it.
+func (ms *MemoryStore) Set(key string, entry Entry) {
+	ms.lock.Lock()
+	defer ms.lock.Unlock()
+	if ms.entries == nil {
+		ms.entries = make(map[string]Entry)
+	}
+	ms.entries[key] = entry
+}
+
+// CachingExecutor is a gorun.Executor that returns the stored Response
+// of an earlier identical request when there is one that has not
+// expired, and otherwise runs the request and stores its Response.
+//
+// Requests are identical when they have the same Path, Args,
+// environment assignments from Env and EnvMap, Dir, and standard input,
+// and either both or neither set InheritEnv, and when every other field
+// that changes their Response, such as StdoutGrep, CombineOutput, the
+// output limits, and Timeout, is the same. The environment inherited by
+// a request that assigns none, or whose InheritEnv is true, is not part
+// of its key. To key on its standard input, all of it is read into
+// memory before the request is run.
+//
+// When a Response is served from the cache, what the child process
+// wrote is written to StdinTee, StdoutTee, StderrTee, StdoutWriter, and
+// StderrWriter, as though it ran again.
+//
+// Requests that set fields whose effect cannot be identified by a key
+// nor repeated from a cached Response are always run rather than
+// cached. These are Secrets, so the output one caller derives from its
+// secrets is never served to another caller, and the secrets are never
+// written to a Store, not even hashed into a key, along with
+// StdoutBuffer, StderrBuffer, StdoutWriters, Events, HashStdout,
+// StdoutTransform, OnStart, OnPercent, Terminator, SpillStdoutAfter,
+// whose file belongs to the caller, and Retry with a RetryOn function.
+//
+// Cached responses are shared, so callers must not modify them.
+type CachingExecutor struct {
+	// Executor runs the requests that are not cached. When nil, they
+	// are run on the local host by gorun.Run.
+	Executor gorun.Executor
+
+	// Store holds the cached responses. When nil, they are held in a
+	// MemoryStore owned by the CachingExecutor.
+	Store Store
+
+	// TTL is how long a response remains cached. When zero, cached
+	// responses never expire.
+	TTL time.Duration
+
+	// Cacheable, when not nil, returns true for each response that
+	// ought to be cached. When nil, only responses of commands that
+	// exited with a zero exit code and without an error are cached.
+	// Requests that return an error are never cached.
+	Cacheable func(*gorun.Response) bool
+
+	once  sync.Once
+	store Store
+}
+
+// Run returns the cached Response for req when there is one, and
+// otherwise runs req and caches its Response.
+func (ce *CachingExecutor) Run(ctx context.Context, req *gorun.Request) (*gorun.Response, error) {
+	ce.once.Do(func() {
+		ce.store = ce.Store
+		if ce.store == nil {
+			ce.store = new(MemoryStore)
+		}
+	})
+
+	executor := ce.Executor
+	if executor == nil {
+		executor = gorun.ExecutorFunc(gorun.Run)
+	}
+	if uncacheable(req) {
+		return executor.Run(ctx, req)
+	}
+
+	stdin, err := readStdin(req)
+	if err != nil {
+		return nil, gorun.ErrSpawn{Err: err}
+	}
+	key := Key(req, stdin)
+
+	if entry, ok := ce.store.Get(key); ok && !entry.expired(time.Now()) {
+		return entry.replay(req, stdin)
+	}
+
+	r := *req
+	r.Stdin, r.StdinReaders, r.StdinReaderAt = nil, nil, nil
+	r.StdinBytes, r.StdinString = nil, ""
+	if stdin != nil {
+		r.Stdin = bytes.NewReader(stdin)
+	}
+	// Record the output to replay it to the tees and writers of later
+	// requests. Tees cannot be set when the streams are combined, in
+	// which case there is nothing to replay it to.
+	var stderr, stdout bytes.Buffer
+	if !req.CombineOutput {
+		r.StderrTee = tee(&stderr, req.StderrTee)
+		r.StdoutTee = tee(&stdout, req.StdoutTee)
+	}
+
+	resp, err := executor.Run(ctx, &r)
+	if err != nil {
+		return resp, err
+	}
+
+	cacheable := ce.Cacheable
+	if cacheable == nil {
+		cacheable = (*gorun.Response).Success
+	}
+	if cacheable(resp) {
+		entry := Entry{Response: resp, Stderr: stderr.Bytes(), Stdout: stdout.Bytes()}
+		if req.StderrWriter != nil || req.StdoutWriter != nil {
+			// Later requests without writers expect the output in
+			// the Response.
+			cached := *resp
+			if req.StderrWriter != nil {
+				cached.Stderr = entry.Stderr
+			}
+			if req.StdoutWriter != nil {
+				cached.Stdout = entry.Stdout
+			}
+			entry.Response = &cached
+		}
+		if ce.TTL > 0 {
+			entry.Expires = time.Now().Add(ce.TTL)
+		}
+		ce.store.Set(key, entry)
+	}
+	return resp, nil
+}
+
+// replay writes the output recorded in e to the tees and writers of
+// req, and returns the Response for req.
+func (e Entry) replay(req *gorun.Request, stdin []byte) (*gorun.Response, error) {
+	for _, w := range []struct {
+		w io.Writer
+		b []byte
+	}{
+		{req.StdinTee, stdin},
+		{req.StderrTee, e.Stderr},
+		{req.StderrWriter, e.Stderr},
+		{req.StdoutTee, e.Stdout},
+		{req.StdoutWriter, e.Stdout},
+	} {
+		if w.w == nil || len(w.b) == 0 {
+			continue
+		}
+		if _, err := w.w.Write(w.b); err != nil {
+			return nil, gorun.ErrWait{Err: err}
+		}
+	}
+	resp := e.Response
+	if req.StderrWriter != nil || req.StdoutWriter != nil {
+		// The output of a request with writers is not in its Response.
+		c := *resp
+		if req.StderrWriter != nil {
+			c.Stderr = nil
+		}
+		if req.StdoutWriter != nil {
+			c.Stdout = nil
+		}
+		resp = &c
+	}
+	return resp, nil
+}
+
+// uncacheable returns true when req must be run rather than served from
+// or stored in the cache, because it sets a field whose effect can be
+// neither identified by a key nor repeated from a cached Response.
+func uncacheable(req *gorun.Request) bool {
+	return len(req.Secrets) > 0 ||
+		req.StderrBuffer != nil || req.StdoutBuffer != nil || len(req.StdoutWriters) > 0 ||
+		req.Events != nil || req.HashStdout != nil || req.StdoutTransform != nil ||
+		req.OnStart != nil || req.OnPercent != nil || req.Terminator != nil ||
+		req.SpillStdoutAfter > 0 || (req.Retry != nil && req.Retry.RetryOn != nil)
+}
+
+// tee returns an io.Writer that writes to w and to t, when t is not nil.
+func tee(w, t io.Writer) io.Writer {
+	if t == nil {
+		return w
+	}
+	return io.MultiWriter(w, t)
+}
+
+// readStdin returns everything available on the standard input of req,
+// or nil when req has no standard input.
+func readStdin(req *gorun.Request) ([]byte, error) {
+	r := req.StdinReader()
+	if r == nil {
+		return nil, nil
+	}
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if b == nil {
+		b = []byte{}
+	}
+	return b, nil
+}
+
+// Key returns the hexadecimal SHA-256 hash that identifies req, when
+// its standard input is stdin, for use as a Store key. A nil stdin
+// means req has no standard input, which is distinct from an empty
+// one.
+func Key(req *gorun.Request, stdin []byte) string {
+	h := sha256.New()
+	writeString(h, req.Path)
+	writeStrings(h, req.Args)
+	switch env := req.EnvList(); {
+	case env == nil:
+		h.Write([]byte{0})
+	case req.InheritEnv:
+		// Env is applied to the environment of this process.
+		h.Write([]byte{2})
+		writeStrings(h, env)
+	default:
+		h.Write([]byte{1})
+		writeStrings(h, env)
+	}
+	writeString(h, req.Dir)
+	if stdin == nil {
+		h.Write([]byte{0})
+	} else {
+		h.Write([]byte{1})
+		writeBytes(h, stdin)
+	}
+
+	// The remaining fields that change the Response, in the order
+	// they are declared.
+	writeInt(h, int64(req.AlarmAfter))
+	writeInt(h, int64(req.StdinIdleTimeout))
+	signals := make([]int, len(req.BlockSignals))
+	for i, sig := range req.BlockSignals {
+		signals[i] = int(sig)
+	}
+	writeInts(h, signals)
+	writeBool(h, req.CLIFriendlyCancel)
+	writeBool(h, req.CombineOutput)
+	writeBool(h, req.DecodeBase64Stdout)
+	writeBool(h, req.DecompressStdout)
+	writeBool(h, req.DetectOOMKill)
+	writeBool(h, req.DumpOnTimeout)
+	writeString(h, req.ExitCodeFile)
+	writeStrings(h, req.ExpectFiles)
+	writeBool(h, req.ExpandEnv)
+	writeStrings(h, req.Fallbacks)
+	writeInt(h, int64(req.GracePeriod))
+	writeInt(h, int64(req.HardTimeout))
+	writeInt(h, int64(req.LatencyBudget))
+	writeInt(h, int64(req.MaxChildProcesses))
+	writeInt(h, req.MaxOutputBytes)
+	writeInt(h, int64(req.MaxStderrLines))
+	writeInt(h, req.MaxStdoutBytes)
+	writeInt(h, int64(req.MaxStdoutLines))
+	writeBool(h, req.NewPIDNamespace)
+	writeBool(h, req.PTY)
+	writeBool(h, req.ProcessGroup)
+	writeBool(h, req.RecordKernelStartTime)
+	writeBool(h, req.RequireOutput)
+	writeInt(h, int64(req.RequireOutputStream))
+	writeBool(h, req.ResolvePath)
+	if req.Retry == nil {
+		h.Write([]byte{0})
+	} else {
+		h.Write([]byte{1})
+		writeInt(h, int64(req.Retry.MaxAttempts))
+		writeInt(h, int64(req.Retry.Backoff))
+		writeInt(h, int64(math.Float64bits(req.Retry.Multiplier)))
+	}
+	writeInt(h, int64(req.RetryOnEmptyOutput))
+	writeInt(h, int64(req.RetryOnSignal))
+	writeBool(h, req.SampleFDs)
+	writeBool(h, req.ShareProcessGroup)
+	writeInt(h, int64(req.StderrHeadBytes))
+	writeInts(h, req.SuccessCodes)
+	writeBool(h, req.SwapStdoutStderr)
+	writeBool(h, req.TimeStdoutLines)
+	writeBool(h, req.TimedOutput)
+	writeInt(h, int64(req.Timeout))
+	writeBool(h, req.UseTimeoutCommand)
+	if req.StdoutGrep == nil {
+		h.Write([]byte{0})
+	} else {
+		h.Write([]byte{1})
+		writeString(h, req.StdoutGrep.String())
+	}
+	writeInt(h, int64(req.StdoutHeadBytes))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func writeBool(h hash.Hash, b bool) {
+	if b {
+		h.Write([]byte{1})
+	} else {
+		h.Write([]byte{0})
+	}
+}
+
+func writeInt(h hash.Hash, i int64) {
+	var n [binary.MaxVarintLen64]byte
+	h.Write(n[:binary.PutVarint(n[:], i)])
+}
+
+func writeInts(h hash.Hash, is []int) {
+	var n [binary.MaxVarintLen64]byte
+	h.Write(n[:binary.PutUvarint(n[:], uint64(len(is)))])
+	for _, i := range is {
+		writeInt(h, int64(i))
+	}
+}
+
+// writeBytes writes b to h prefixed by its length, so the boundaries
+// between adjacent fields are unambiguous.
+func writeBytes(h hash.Hash, b []byte) {
+	var n [binary.MaxVarintLen64]byte
+	h.Write(n[:binary.PutUvarint(n[:], uint64(len(b)))])
+	h.Write(b)
+}
+
+func writeString(h hash.Hash, s string) {
+	writeBytes(h, []byte(s))
+}
+
+func writeStrings(h hash.Hash, ss []string) {
+	var n [binary.MaxVarintLen64]byte
+	h.Write(n[:binary.PutUvarint(n[:], uint64(len(ss)))])
+	for _, s := range ss {
+		writeString(h, s)
+	}
+}
@@ -0,0 +1,373 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/karrick/gorun"
+)
+
+// countingExecutor returns an executor that echoes the standard input
+// of each request it runs to its standard output, exiting with code,
+// along with a pointer to the number of requests it has run.
+func countingExecutor(code int) (gorun.Executor, *int) {
+	var calls int
+	return gorun.ExecutorFunc(func(_ context.Context, req *gorun.Request) (*gorun.Response, error) {
+		calls++
+		var stdout []byte
+		if req.Stdin != nil {
+			var err error
+			if stdout, err = io.ReadAll(req.Stdin); err != nil {
+				return nil, err
+			}
+		}
+		return &gorun.Response{Code: code, Stdout: stdout}, nil
+	}), &calls
+}
+
+func TestCachingExecutor(t *testing.T) {
+	t.Run("second run served from cache", func(t *testing.T) {
+		executor, calls := countingExecutor(0)
+		ce := &CachingExecutor{Executor: executor}
+
+		for i := 0; i < 2; i++ {
+			resp, err := ce.Run(context.Background(), &gorun.Request{
+				Path:  "/bin/cat",
+				Stdin: strings.NewReader("input"),
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, want := string(resp.Stdout), "input"; got != want {
+				t.Errorf("GOT: %q; WANT: %q", got, want)
+			}
+		}
+		if got, want := *calls, 1; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+
+	t.Run("different requests", func(t *testing.T) {
+		executor, calls := countingExecutor(0)
+		ce := &CachingExecutor{Executor: executor}
+
+		for _, req := range []*gorun.Request{
+			{Path: "/bin/cat"},
+			{Path: "/bin/cat", Stdin: strings.NewReader("")},
+			{Path: "/bin/cat", Stdin: strings.NewReader("input")},
+			{Path: "/bin/cat", Args: []string{"-n"}},
+			{Path: "/bin/cat", Env: []string{}},
+			{Path: "/bin/cat", Env: []string{}, InheritEnv: true},
+			{Path: "/bin/cat", Dir: "/tmp"},
+		} {
+			if _, err := ce.Run(context.Background(), req); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if got, want := *calls, 7; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+
+	t.Run("failures not cached", func(t *testing.T) {
+		executor, calls := countingExecutor(1)
+		ce := &CachingExecutor{Executor: executor}
+
+		for i := 0; i < 2; i++ {
+			if _, err := ce.Run(context.Background(), &gorun.Request{Path: "/bin/false"}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if got, want := *calls, 2; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+
+	t.Run("cacheable", func(t *testing.T) {
+		executor, calls := countingExecutor(1)
+		ce := &CachingExecutor{
+			Executor:  executor,
+			Cacheable: func(*gorun.Response) bool { return true },
+		}
+
+		for i := 0; i < 2; i++ {
+			if _, err := ce.Run(context.Background(), &gorun.Request{Path: "/bin/false"}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if got, want := *calls, 1; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+
+	t.Run("errors not cached", func(t *testing.T) {
+		var calls int
+		ce := &CachingExecutor{
+			Executor: gorun.ExecutorFunc(func(context.Context, *gorun.Request) (*gorun.Response, error) {
+				calls++
+				return nil, gorun.ErrSpawn{Err: errors.New("cannot spawn")}
+			}),
+			Cacheable: func(*gorun.Response) bool { return true },
+		}
+
+		for i := 0; i < 2; i++ {
+			if _, err := ce.Run(context.Background(), &gorun.Request{Path: "/no-such-path"}); err == nil {
+				t.Fatalf("GOT: %v; WANT: %v", err, gorun.ErrSpawn{})
+			}
+		}
+		if got, want := calls, 2; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+
+	t.Run("ttl", func(t *testing.T) {
+		executor, calls := countingExecutor(0)
+		ce := &CachingExecutor{Executor: executor, TTL: 50 * time.Millisecond}
+
+		run := func() {
+			if _, err := ce.Run(context.Background(), &gorun.Request{Path: "/bin/true"}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		run()
+		run()
+		if got, want := *calls, 1; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		time.Sleep(100 * time.Millisecond)
+		run()
+		if got, want := *calls, 2; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+
+	t.Run("secrets not cached", func(t *testing.T) {
+		executor, calls := countingExecutor(0)
+		store := new(MemoryStore)
+		ce := &CachingExecutor{Executor: executor, Store: store}
+
+		for _, token := range []string{"a", "b"} {
+			req := &gorun.Request{
+				Path:    "/bin/sh",
+				Args:    []string{"-c", `cat "$TOKEN_FILE"`},
+				Secrets: map[string][]byte{"TOKEN": []byte(token)},
+			}
+			if _, err := ce.Run(context.Background(), req); err != nil {
+				t.Fatal(err)
+			}
+			if _, ok := store.Get(Key(req, nil)); ok {
+				t.Errorf("GOT: %v; WANT: %v", ok, false)
+			}
+		}
+		if got, want := *calls, 2; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+
+	t.Run("swap stdout and stderr", func(t *testing.T) {
+		var calls int
+		ce := &CachingExecutor{
+			Executor: gorun.ExecutorFunc(func(ctx context.Context, req *gorun.Request) (*gorun.Response, error) {
+				calls++
+				return gorun.Run(ctx, req)
+			}),
+		}
+
+		for _, swap := range []bool{false, true} {
+			resp, err := ce.Run(context.Background(), &gorun.Request{
+				Path:             "/bin/sh",
+				Args:             []string{"-c", "echo out; echo err >&2"},
+				SwapStdoutStderr: swap,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			stdout, stderr := "out\n", "err\n"
+			if swap {
+				stdout, stderr = stderr, stdout
+			}
+			if got, want := string(resp.Stdout), stdout; got != want {
+				t.Errorf("GOT: %q; WANT: %q", got, want)
+			}
+			if got, want := string(resp.Stderr), stderr; got != want {
+				t.Errorf("GOT: %q; WANT: %q", got, want)
+			}
+		}
+		if got, want := calls, 2; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+
+	t.Run("stdout grep", func(t *testing.T) {
+		ce := new(CachingExecutor)
+		for _, tc := range []struct {
+			grep *regexp.Regexp
+			want string
+		}{
+			{grep: nil, want: "out\n"},
+			{grep: regexp.MustCompile("nomatch"), want: ""},
+		} {
+			resp, err := ce.Run(context.Background(), &gorun.Request{
+				Path:       "/bin/echo",
+				Args:       []string{"out"},
+				StdoutGrep: tc.grep,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := string(resp.Stdout); got != tc.want {
+				t.Errorf("GOT: %q; WANT: %q", got, tc.want)
+			}
+		}
+	})
+
+	t.Run("replay to tees and writers", func(t *testing.T) {
+		var calls int
+		ce := &CachingExecutor{
+			Executor: gorun.ExecutorFunc(func(ctx context.Context, req *gorun.Request) (*gorun.Response, error) {
+				calls++
+				return gorun.Run(ctx, req)
+			}),
+		}
+		run := func(req *gorun.Request) *gorun.Response {
+			t.Helper()
+			req.Path = "/bin/sh"
+			req.Args = []string{"-c", "cat; echo err >&2"}
+			req.StdinString = "out\n"
+			resp, err := ce.Run(context.Background(), req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return resp
+		}
+
+		var stdoutWriter, stderrWriter bytes.Buffer
+		resp := run(&gorun.Request{StdoutWriter: &stdoutWriter, StderrWriter: &stderrWriter})
+		if resp.Stdout != nil || resp.Stderr != nil {
+			t.Errorf("GOT: %q and %q; WANT: nil", resp.Stdout, resp.Stderr)
+		}
+
+		// Served from the entry of the request with writers.
+		resp = run(new(gorun.Request))
+		if got, want := string(resp.Stdout), "out\n"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+		if got, want := string(resp.Stderr), "err\n"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+
+		var stdinTee, stdoutTee, stderrTee bytes.Buffer
+		stdoutWriter.Reset()
+		stderrWriter.Reset()
+		resp = run(&gorun.Request{
+			StdinTee:     &stdinTee,
+			StdoutTee:    &stdoutTee,
+			StderrTee:    &stderrTee,
+			StdoutWriter: &stdoutWriter,
+			StderrWriter: &stderrWriter,
+		})
+		if resp.Stdout != nil || resp.Stderr != nil {
+			t.Errorf("GOT: %q and %q; WANT: nil", resp.Stdout, resp.Stderr)
+		}
+		for _, tc := range []struct {
+			got  *bytes.Buffer
+			want string
+		}{
+			{&stdinTee, "out\n"},
+			{&stdoutTee, "out\n"},
+			{&stdoutWriter, "out\n"},
+			{&stderrTee, "err\n"},
+			{&stderrWriter, "err\n"},
+		} {
+			if got := tc.got.String(); got != tc.want {
+				t.Errorf("GOT: %q; WANT: %q", got, tc.want)
+			}
+		}
+		if got, want := calls, 1; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+
+	t.Run("store", func(t *testing.T) {
+		executor, _ := countingExecutor(0)
+		store := new(MemoryStore)
+		ce := &CachingExecutor{Executor: executor, Store: store}
+
+		req := &gorun.Request{Path: "/bin/cat", Stdin: strings.NewReader("input")}
+		if _, err := ce.Run(context.Background(), req); err != nil {
+			t.Fatal(err)
+		}
+		entry, ok := store.Get(Key(req, []byte("input")))
+		if !ok {
+			t.Fatalf("GOT: %v; WANT: %v", ok, true)
+		}
+		if got, want := string(entry.Response.Stdout), "input"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+	})
+}
+
+// TestKeyCoversRequest ensures that setting any field of a Request
+// either changes its key or causes it to be run without the cache, so
+// fields added to Request are not silently ignored.
+func TestKeyCoversRequest(t *testing.T) {
+	// Fields that identify the request separately from the loop below,
+	// or that do not change its Response.
+	ignored := map[string]bool{
+		"Path": true, "Args": true, "Env": true, "EnvMap": true, "InheritEnv": true, "Dir": true,
+		"Stdin": true, "StdinBytes": true, "StdinString": true, "StdinReaders": true,
+		"StdinReaderAt": true, "StdinOffset": true, "StdinLength": true,
+		"StdinTee": true, "StderrTee": true, "StdoutTee": true, "StderrWriter": true, "StdoutWriter": true,
+		"Name": true, "NoDefaultArgs": true, "StartDelay": true, "ReadBufferSize": true,
+		"ExitCodeErrors": true, "PercentPattern": true,
+	}
+
+	base := Key(&gorun.Request{}, nil)
+	typ := reflect.TypeOf(gorun.Request{})
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if !field.IsExported() || ignored[field.Name] {
+			continue
+		}
+		req := new(gorun.Request)
+		v := reflect.ValueOf(req).Elem().Field(i)
+		switch field.Type.Kind() {
+		case reflect.Bool:
+			v.SetBool(true)
+		case reflect.Int, reflect.Int64:
+			v.SetInt(1)
+		case reflect.String:
+			v.SetString("x")
+		case reflect.Slice:
+			v.Set(reflect.MakeSlice(field.Type, 1, 1))
+		case reflect.Map:
+			v.Set(reflect.MakeMap(field.Type))
+			v.SetMapIndex(reflect.Zero(field.Type.Key()), reflect.Zero(field.Type.Elem()))
+		case reflect.Pointer:
+			v.Set(reflect.New(field.Type.Elem()))
+		case reflect.Func:
+			v.Set(reflect.MakeFunc(field.Type, func([]reflect.Value) []reflect.Value { return nil }))
+		case reflect.Interface:
+			for _, impl := range []any{io.Discard, gorun.TerminatorFunc(func(context.Context, *os.Process) error { return nil })} {
+				if reflect.TypeOf(impl).AssignableTo(field.Type) {
+					v.Set(reflect.ValueOf(impl))
+				}
+			}
+		}
+		if v.IsZero() {
+			t.Errorf("%s: cannot set field of type %v", field.Name, field.Type)
+			continue
+		}
+		if !uncacheable(req) && Key(req, nil) == base {
+			t.Errorf("%s: neither changes the key nor bypasses the cache", field.Name)
+		}
+	}
+}
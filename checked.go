@@ -0,0 +1,138 @@
+package gorun
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/karrick/gorun/internal/diff"
+)
+
+// previewLimit is the maximum number of bytes of each output stream
+// included in the message of an ErrExit error.
+const previewLimit = 256
+
+// RunVerboseChecked executes a system command like Run does, but also
+// returns an ErrExit error when the child process does not exit with a
+// zero exit code, or one of req.SuccessCodes when it is not empty, or
+// when it exits due to receiving a signal. The
+// message of the ErrExit error includes the exit code and a bounded
+// preview of both the standard output and standard error of the child
+// process, and it wraps Response.Err so the signal error remains
+// reachable with errors.As.
+//
+// When the exit code of the child process is listed in
+// req.ExitCodeErrors, the ErrExit error wraps the corresponding error,
+// so callers may test for it with errors.Is.
+//
+// The returned Response is the same one Run would have returned, and
+// remains available even when the returned error is an ErrExit.
+func (req *Request) RunVerboseChecked(ctx context.Context) (*Response, error) {
+	resp, err := req.Run(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return resp, req.exitError(resp)
+}
+
+// exitError returns nil when resp describes a child process that exited
+// successfully, and otherwise an ErrExit error describing it, wrapping
+// the error from req.ExitCodeErrors for its exit code when there is one.
+func (req *Request) exitError(resp *Response) error {
+	if resp.Err == nil && (resp.Code == 0 || len(req.SuccessCodes) > 0) {
+		return nil
+	}
+	err := resp.Err
+	if mapped, ok := req.ExitCodeErrors[resp.Code]; ok && (err == nil || errors.Is(err, ErrUnexpectedExit{})) {
+		err = mapped
+	}
+	return ErrExit{
+		Code:   resp.Code,
+		Err:    err,
+		Stderr: resp.Stderr,
+		Stdout: resp.Stdout,
+	}
+}
+
+// RunExpect executes a system command like Run does, but also returns
+// an ErrUnexpectedOutput error when the standard output of the child
+// process, with leading and trailing white space removed, differs from
+// wantStdout, likewise trimmed. The message of the ErrUnexpectedOutput
+// error includes a line oriented diff of the two.
+//
+// The returned Response is the same one Run would have returned, and
+// remains available even when the returned error is an
+// ErrUnexpectedOutput.
+func (req *Request) RunExpect(ctx context.Context, wantStdout string) (*Response, error) {
+	resp, err := req.Run(ctx)
+	if err != nil {
+		return nil, err
+	}
+	got := strings.TrimSpace(string(resp.Stdout))
+	want := strings.TrimSpace(wantStdout)
+	if got != want {
+		return resp, ErrUnexpectedOutput{Got: got, Want: want}
+	}
+	return resp, nil
+}
+
+// ErrExit is returned when a child process did not exit successfully.
+type ErrExit struct {
+	// Err is the potentially nil error that describes why the child
+	// process terminated, such as an ErrSignal, or the error listed for
+	// its exit code in Request.ExitCodeErrors.
+	Err error
+
+	// Stderr is what the child process wrote to its standard error.
+	Stderr []byte
+
+	// Stdout is what the child process wrote to its standard output.
+	Stdout []byte
+
+	// Code is the exit code of the child process.
+	Code int
+}
+
+func (e ErrExit) Error() string {
+	msg := "exit code " + strconv.Itoa(e.Code)
+	if e.Err != nil {
+		msg += " (" + e.Err.Error() + ")"
+	}
+	return msg + "; stdout: " + preview(e.Stdout) + "; stderr: " + preview(e.Stderr)
+}
+
+func (e ErrExit) Is(err error) bool {
+	_, ok := err.(ErrExit)
+	return ok
+}
+
+func (e ErrExit) Unwrap() error { return e.Err }
+
+// ErrUnexpectedOutput is returned when a child process did not produce
+// the expected output.
+type ErrUnexpectedOutput struct {
+	// Got is the output the child process produced.
+	Got string
+
+	// Want is the output the child process was expected to produce.
+	Want string
+}
+
+func (e ErrUnexpectedOutput) Error() string {
+	return "unexpected output (-want +got):\n" + diff.Lines(e.Want, e.Got)
+}
+
+func (e ErrUnexpectedOutput) Is(err error) bool {
+	_, ok := err.(ErrUnexpectedOutput)
+	return ok
+}
+
+// preview returns a quoted representation of at most previewLimit bytes
+// of buf, followed by an ellipsis when buf was longer than that.
+func preview(buf []byte) string {
+	if len(buf) <= previewLimit {
+		return strconv.Quote(string(buf))
+	}
+	return strconv.Quote(string(buf[:previewLimit])) + "..."
+}
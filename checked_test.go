@@ -0,0 +1,140 @@
+//go:build !windows
+// +build !windows
+
+package gorun
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunVerboseChecked(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		resp, err := (&Request{
+			Path: "/bin/echo",
+			Args: []string{"hello"},
+		}).RunVerboseChecked(context.Background())
+		ensureError(t, err, nil)
+		ensureResponsesMatch(t, resp, &Response{
+			Stderr: []byte{},
+			Stdout: []byte("hello\n"),
+		})
+	})
+
+	t.Run("non-zero exit", func(t *testing.T) {
+		resp, err := (&Request{
+			Path: "/bin/sh",
+			Args: []string{"-c", "echo to-stdout; echo to-stderr >&2; exit 7"},
+		}).RunVerboseChecked(context.Background())
+		ensureError(t, err, ErrExit{
+			Code:   7,
+			Stderr: []byte("to-stderr\n"),
+			Stdout: []byte("to-stdout\n"),
+		})
+
+		msg := err.Error()
+		for _, want := range []string{"exit code 7", "to-stdout", "to-stderr"} {
+			if !strings.Contains(msg, want) {
+				t.Errorf("GOT: %q; WANT: contains %q", msg, want)
+			}
+		}
+
+		var ee ErrExit
+		if !errors.As(err, &ee) {
+			t.Fatalf("GOT: %T; WANT: %T", err, ee)
+		}
+		if got, want := ee.Code, 7; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		if got, want := resp.Code, 7; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+
+	t.Run("bounded preview", func(t *testing.T) {
+		_, err := (&Request{
+			Path: "/bin/sh",
+			Args: []string{"-c", "head -c 10000 /dev/zero | tr '\\0' x; exit 1"},
+		}).RunVerboseChecked(context.Background())
+		if !errors.Is(err, ErrExit{}) {
+			t.Fatalf("GOT: %T(%v); WANT: %T", err, err, ErrExit{})
+		}
+		if got, limit := len(err.Error()), 2*previewLimit+100; got > limit {
+			t.Errorf("GOT: %v; WANT: <= %v", got, limit)
+		}
+	})
+
+	t.Run("signal", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+		_, err := (&Request{
+			Path: "/bin/sleep",
+			Args: []string{"1"},
+		}).RunVerboseChecked(ctx)
+
+		var se ErrSignal
+		if !errors.As(err, &se) {
+			t.Fatalf("GOT: %T(%v); WANT: %T", err, err, se)
+		}
+	})
+	t.Run("exit code errors", func(t *testing.T) {
+		errUsage := errors.New("usage error")
+		errNotFound := errors.New("not found")
+		codes := map[int]error{2: errUsage, 3: errNotFound}
+
+		_, err := (&Request{
+			Path:           "/bin/sh",
+			Args:           []string{"-c", "exit 2"},
+			ExitCodeErrors: codes,
+		}).RunVerboseChecked(context.Background())
+		if !errors.Is(err, errUsage) || errors.Is(err, errNotFound) {
+			t.Errorf("GOT: %v; WANT: %v", err, errUsage)
+		}
+		var ee ErrExit
+		if !errors.As(err, &ee) || ee.Code != 2 {
+			t.Errorf("GOT: %v; WANT: %v", err, ErrExit{Code: 2, Err: errUsage})
+		}
+		if !strings.Contains(err.Error(), "exit code 2 (usage error)") {
+			t.Errorf("GOT: %v; WANT: %v", err, "exit code 2 (usage error)")
+		}
+
+		_, err = (&Request{
+			Path:           "/bin/sh",
+			Args:           []string{"-c", "exit 4"},
+			ExitCodeErrors: codes,
+		}).RunVerboseChecked(context.Background())
+		if !errors.As(err, &ee) || ee.Code != 4 || ee.Err != nil {
+			t.Errorf("GOT: %v; WANT: %v", err, ErrExit{Code: 4})
+		}
+	})
+}
+
+func TestRunExpect(t *testing.T) {
+	t.Run("match", func(t *testing.T) {
+		resp, err := (&Request{
+			Path: "/bin/echo",
+			Args: []string{"one"},
+		}).RunExpect(context.Background(), "one")
+		ensureError(t, err, nil)
+		if got, want := string(resp.Stdout), "one\n"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+	})
+
+	t.Run("mismatch", func(t *testing.T) {
+		resp, err := (&Request{
+			Path: "/usr/bin/printf",
+			Args: []string{"one\\ntwo\\nthree\\n"},
+		}).RunExpect(context.Background(), "one\n2\nthree")
+		ensureError(t, err, ErrUnexpectedOutput{Got: "one\ntwo\nthree", Want: "one\n2\nthree"})
+		if got, want := err.Error(), "unexpected output (-want +got):\n one\n-2\n+two\n three\n"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+		if resp == nil {
+			t.Fatalf("GOT: %v; WANT: non-nil", resp)
+		}
+	})
+}
@@ -0,0 +1,41 @@
+package gorun
+
+import (
+	"maps"
+	"slices"
+)
+
+// Clone returns a copy of req that may be modified without affecting
+// req, so a Request may serve as a template for many variations. The
+// slices and maps of req, including Args, Env, EnvMap, StdinBytes,
+// and Secrets, are copied, as is Retry. Everything else is shared,
+// notably Stdin and the readers in StdinReaders, which are consumed by
+// running the child process, so a clone that shares them with another
+// Request reads only what that Request has not. Likewise StdoutBuffer,
+// StderrBuffer, and the various writers are shared, so set them anew
+// on each clone that runs at the same time as another.
+func (req *Request) Clone() *Request {
+	c := *req
+	c.Args = slices.Clone(req.Args)
+	c.Env = slices.Clone(req.Env)
+	c.EnvMap = maps.Clone(req.EnvMap)
+	c.StdinBytes = slices.Clone(req.StdinBytes)
+	c.StdinReaders = slices.Clone(req.StdinReaders)
+	c.BlockSignals = slices.Clone(req.BlockSignals)
+	c.ExitCodeErrors = maps.Clone(req.ExitCodeErrors)
+	c.ExpectFiles = slices.Clone(req.ExpectFiles)
+	c.Fallbacks = slices.Clone(req.Fallbacks)
+	c.SuccessCodes = slices.Clone(req.SuccessCodes)
+	c.StdoutWriters = slices.Clone(req.StdoutWriters)
+	if req.Secrets != nil {
+		c.Secrets = make(map[string][]byte, len(req.Secrets))
+		for name, secret := range req.Secrets {
+			c.Secrets[name] = slices.Clone(secret)
+		}
+	}
+	if req.Retry != nil {
+		retry := *req.Retry
+		c.Retry = &retry
+	}
+	return &c
+}
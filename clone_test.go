@@ -0,0 +1,131 @@
+//go:build !windows
+// +build !windows
+
+package gorun
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestClone(t *testing.T) {
+	t.Run("equal", func(t *testing.T) {
+		req := &Request{
+			Path:         "/bin/echo",
+			Args:         []string{"one"},
+			Env:          []string{"A=1"},
+			EnvMap:       map[string]string{"B": "2"},
+			Secrets:      map[string][]byte{"TOKEN": []byte("secret")},
+			SuccessCodes: []int{0, 1},
+			Retry:        &Retry{MaxAttempts: 3},
+		}
+		if got := req.Clone(); !reflect.DeepEqual(got, req) {
+			t.Errorf("GOT: %+v; WANT: %+v", got, req)
+		}
+	})
+
+	t.Run("independent", func(t *testing.T) {
+		args := make([]string, 1, 4) // spare capacity exposes shared backing arrays
+		args[0] = "base"
+		template := &Request{
+			Path:    "/bin/echo",
+			Args:    args,
+			Env:     []string{"A=1"},
+			EnvMap:  map[string]string{"B": "2"},
+			Secrets: map[string][]byte{"TOKEN": []byte("secret")},
+			Retry:   &Retry{MaxAttempts: 3},
+		}
+
+		clone := template.Clone()
+		clone.Args[0] = "changed"
+		clone.Args = append(clone.Args, "extra")
+		clone.Env[0] = "A=changed"
+		clone.EnvMap["B"] = "changed"
+		clone.Secrets["TOKEN"][0] = 'X'
+		clone.Retry.MaxAttempts = 1
+
+		if got, want := template.Args, []string{"base"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+		if got, want := args[:2], []string{"base", ""}; !reflect.DeepEqual(got, want) {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+		if got, want := template.Env[0], "A=1"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+		if got, want := template.EnvMap["B"], "2"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+		if got, want := string(template.Secrets["TOKEN"]), "secret"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+		if got, want := template.Retry.MaxAttempts, 3; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+
+		got, err := Run(context.Background(), template)
+		ensureError(t, err, nil)
+		if got, want := string(got.Stdout), "base\n"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+		got, err = Run(context.Background(), clone)
+		ensureError(t, err, nil)
+		if got, want := string(got.Stdout), "changed extra\n"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+	})
+
+	t.Run("every slice and map copied", func(t *testing.T) {
+		// Populating each slice and map field by reflection ensures
+		// Clone copies fields added to Request after it was written.
+		req := new(Request)
+		rv := reflect.ValueOf(req).Elem()
+		for i := 0; i < rv.NumField(); i++ {
+			f := rv.Field(i)
+			switch f.Kind() {
+			case reflect.Slice:
+				f.Set(reflect.MakeSlice(f.Type(), 1, 1))
+			case reflect.Map:
+				f.Set(reflect.MakeMap(f.Type()))
+				f.SetMapIndex(reflect.Zero(f.Type().Key()), reflect.Zero(f.Type().Elem()))
+			}
+		}
+
+		cv := reflect.ValueOf(req.Clone()).Elem()
+		for i := 0; i < rv.NumField(); i++ {
+			switch rv.Field(i).Kind() {
+			case reflect.Slice, reflect.Map:
+				if cv.Field(i).Pointer() == rv.Field(i).Pointer() {
+					t.Errorf("GOT: %s shared; WANT: copied", rv.Type().Field(i).Name)
+				}
+			}
+		}
+	})
+
+	t.Run("stdin shared", func(t *testing.T) {
+		template := &Request{Path: "/bin/cat", Stdin: strings.NewReader("once")}
+		clone := template.Clone()
+		if clone.Stdin != template.Stdin {
+			t.Fatalf("GOT: %v; WANT: %v", clone.Stdin, template.Stdin)
+		}
+		got, err := Run(context.Background(), template)
+		ensureError(t, err, nil)
+		if got, want := string(got.Stdout), "once"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+		got, err = Run(context.Background(), clone)
+		ensureError(t, err, nil)
+		if got, want := string(got.Stdout), ""; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+	})
+
+	t.Run("nil fields stay nil", func(t *testing.T) {
+		got := (&Request{Path: "/bin/true"}).Clone()
+		if got.Args != nil || got.Env != nil || got.EnvMap != nil || got.Secrets != nil || got.Retry != nil {
+			t.Errorf("GOT: %+v; WANT: nil slices, maps, and Retry", got)
+		}
+	})
+}
@@ -0,0 +1,66 @@
+//go:build linux && (amd64 || arm64)
+// +build linux
+// +build amd64 arm64
+
+// Command gorun-blocksignals executes a program with a set of signals
+// blocked. It is the helper that github.com/karrick/gorun runs child
+// programs through when Request.BlockSignals is not empty, because the
+// Go standard library cannot change the signal mask of a child process
+// between fork and exec. gorun locates it in PATH, so install it with:
+//
+//	go install github.com/karrick/gorun/cmd/gorun-blocksignals@latest
+//
+// Usage:
+//
+//	gorun-blocksignals STATUS-FD MASK PROGRAM ARGV0 [ARG...]
+//
+// It sets its signal mask to MASK, a hexadecimal signal set in which
+// bit n-1 represents signal n, and replaces itself with PROGRAM, with
+// ARGV0 and the remaining arguments as its argument list. The file
+// descriptor STATUS-FD is closed when PROGRAM is executed. When it
+// cannot execute PROGRAM, it writes the decimal errno describing why to
+// STATUS-FD and exits with status 127.
+package main
+
+import (
+	"os"
+	"runtime"
+	"strconv"
+	"syscall"
+	"unsafe"
+)
+
+// sigSetMask is SIG_SETMASK, which the syscall package does not define
+// for Linux.
+const sigSetMask = 2
+
+func main() {
+	// The mask is a property of the thread, so the one that sets it
+	// must be the one that executes the program.
+	runtime.LockOSThread()
+
+	if len(os.Args) < 5 {
+		os.Stderr.WriteString("usage: gorun-blocksignals STATUS-FD MASK PROGRAM ARGV0 [ARG...]\n")
+		os.Exit(127)
+	}
+	fd, err := strconv.Atoi(os.Args[1])
+	if err != nil {
+		os.Stderr.WriteString("gorun-blocksignals: invalid status file descriptor: " + os.Args[1] + "\n")
+		os.Exit(127)
+	}
+	syscall.CloseOnExec(fd)
+
+	errno := syscall.EINVAL
+	if mask, err := strconv.ParseUint(os.Args[2], 16, 64); err == nil {
+		_, _, errno = syscall.RawSyscall6(syscall.SYS_RT_SIGPROCMASK, sigSetMask, uintptr(unsafe.Pointer(&mask)), 0, unsafe.Sizeof(mask), 0, 0)
+		if errno == 0 {
+			// Exec only returns when it fails.
+			errno = syscall.EINVAL
+			if e, ok := syscall.Exec(os.Args[3], os.Args[4:], os.Environ()).(syscall.Errno); ok {
+				errno = e
+			}
+		}
+	}
+	_, _ = syscall.Write(fd, []byte(strconv.Itoa(int(errno))))
+	os.Exit(127)
+}
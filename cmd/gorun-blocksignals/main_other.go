@@ -0,0 +1,11 @@
+//go:build !linux || !(amd64 || arm64)
+// +build !linux !amd64,!arm64
+
+package main
+
+import "os"
+
+func main() {
+	os.Stderr.WriteString("gorun-blocksignals: cannot block signals on this platform\n")
+	os.Exit(127)
+}
@@ -0,0 +1,65 @@
+//go:build !windows
+// +build !windows
+
+package gorun
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCommand(t *testing.T) {
+	t.Run("matches request", func(t *testing.T) {
+		dir := t.TempDir()
+		req := &Request{
+			Path:  "/bin/sh",
+			Args:  []string{"-c", `echo "$GORUN $(pwd)"; cat; echo oops >&2`},
+			Dir:   dir,
+			Env:   []string{"GORUN=asdf"},
+			Stdin: strings.NewReader("input\n"),
+		}
+		cmd := req.Command(context.Background())
+		if got, want := cmd.Path, req.Path; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+		if got, want := cmd.Args, append([]string{req.Path}, req.Args...); !reflect.DeepEqual(got, want) {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+		if got, want := cmd.Dir, req.Dir; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+		if got, want := cmd.Env, req.Env; !reflect.DeepEqual(got, want) {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+		if cmd.Stdin != req.Stdin {
+			t.Errorf("GOT: %v; WANT: %v", cmd.Stdin, req.Stdin)
+		}
+
+		ensureError(t, cmd.Run(), nil)
+		if got, want := cmd.Stdout.(*bytes.Buffer).String(), "asdf "+dir+"\ninput\n"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+		if got, want := cmd.Stderr.(*bytes.Buffer).String(), "oops\n"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+	})
+
+	t.Run("output destinations", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		cmd := (&Request{Path: "/usr/bin/true", StdoutWriter: &stdout, StderrBuffer: &stderr}).Command(context.Background())
+		if cmd.Stdout != &stdout {
+			t.Errorf("GOT: %v; WANT: %v", cmd.Stdout, &stdout)
+		}
+		if cmd.Stderr != &stderr {
+			t.Errorf("GOT: %v; WANT: %v", cmd.Stderr, &stderr)
+		}
+
+		cmd = (&Request{Path: "/usr/bin/true", CombineOutput: true}).Command(context.Background())
+		if cmd.Stdout == nil || cmd.Stdout != cmd.Stderr {
+			t.Errorf("GOT: %v and %v; WANT: the same *bytes.Buffer", cmd.Stdout, cmd.Stderr)
+		}
+	})
+}
@@ -0,0 +1,105 @@
+package gorun
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strconv"
+	"sync"
+)
+
+// RunConcurrent runs each of reqs concurrently, and writes every line
+// their child processes write to their standard output and standard
+// error to w, prefixed by a tag identifying the request: its Name in
+// brackets, or its index in reqs in brackets when its Name is empty.
+// Lines are only written to w once complete, each call to w holds whole
+// lines, and calls are serialized, so lines from different child
+// processes are never mixed within a line, even when they write
+// partial lines. A final line
+// lacking a newline terminator is written with one appended. Output is
+// also captured in each Response and passed to StdoutTee and StderrTee
+// as Run does.
+//
+// It returns the Responses in the order of reqs once every child
+// process has exited. A Response is nil when its child process could
+// not be spawned, and the returned error joins the errors of all such
+// requests. Unlike Group, a request failing does not terminate the
+// others.
+func RunConcurrent(ctx context.Context, w io.Writer, reqs ...*Request) ([]*Response, error) {
+	lw := &lockedWriter{w: w}
+	responses := make([]*Response, len(reqs))
+	errs := make([]error, len(reqs))
+
+	var wg sync.WaitGroup
+	for i, req := range reqs {
+		tag := req.Name
+		if tag == "" {
+			tag = strconv.Itoa(i)
+		}
+		prefix := []byte("[" + tag + "] ")
+
+		wg.Add(1)
+		go func(i int, req *Request) {
+			defer wg.Done()
+
+			stdout := &prefixWriter{prefix: prefix, w: lw}
+			stderr := &prefixWriter{prefix: prefix, w: lw}
+			r := *req
+			r.StdoutTee = tee(stdout, req.StdoutTee)
+			r.StderrTee = tee(stderr, req.StderrTee)
+
+			responses[i], errs[i] = r.Run(ctx)
+			_ = stdout.flush()
+			_ = stderr.flush()
+		}(i, req)
+	}
+	wg.Wait()
+
+	return responses, errors.Join(errs...)
+}
+
+// prefixWriter writes each complete line written to it to w, prefixed
+// by prefix, in a single call to w, holding partial lines until they
+// are completed.
+type prefixWriter struct {
+	prefix  []byte
+	w       io.Writer
+	partial []byte // the most recent line, when it lacks a newline
+	buf     []byte
+}
+
+func (pw *prefixWriter) Write(p []byte) (int, error) {
+	n := len(p)
+	pw.buf = pw.buf[:0]
+	for {
+		i := bytes.IndexByte(p, '\n')
+		if i == -1 {
+			pw.partial = append(pw.partial, p...)
+			break
+		}
+		pw.buf = append(pw.buf, pw.prefix...)
+		pw.buf = append(pw.buf, pw.partial...)
+		pw.buf = append(pw.buf, p[:i+1]...)
+		pw.partial = pw.partial[:0]
+		p = p[i+1:]
+	}
+	if len(pw.buf) > 0 {
+		if _, err := pw.w.Write(pw.buf); err != nil {
+			return 0, err
+		}
+	}
+	return n, nil
+}
+
+// flush writes the final line, with a newline appended, when it lacks
+// one.
+func (pw *prefixWriter) flush() error {
+	if len(pw.partial) == 0 {
+		return nil
+	}
+	line := append(append(append([]byte{}, pw.prefix...), pw.partial...), '\n')
+	pw.partial = nil
+	_, err := pw.w.Write(line)
+	return err
+}
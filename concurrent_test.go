@@ -0,0 +1,85 @@
+//go:build !windows
+// +build !windows
+
+package gorun
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestRunConcurrent(t *testing.T) {
+	t.Run("tagged lines", func(t *testing.T) {
+		// Each child writes every line in pieces, to give lines from
+		// other children the opportunity to land in between.
+		script := `for i in 1 2 3 4 5 6 7 8 9 10; do printf "%s " "$0"; sleep 0.001; printf "line $i\n"; printf "%s err\n" "$0" >&2; done; printf "%s end" "$0"`
+		var reqs []*Request
+		for i := 0; i < 4; i++ {
+			req := &Request{
+				Path: "/bin/sh",
+				Args: []string{"-c", script, "cmd" + strconv.Itoa(i)},
+			}
+			if i%2 == 0 {
+				req.Name = "cmd" + strconv.Itoa(i)
+			}
+			reqs = append(reqs, req)
+		}
+
+		var buf bytes.Buffer
+		responses, err := RunConcurrent(context.Background(), &buf, reqs...)
+		ensureError(t, err, nil)
+
+		counts := make(map[string]int)
+		for _, line := range strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n") {
+			tag, rest, ok := strings.Cut(line, " ")
+			if !ok {
+				t.Fatalf("GOT: %q; WANT: tagged line", line)
+			}
+			var name string
+			switch tag {
+			case "[cmd0]", "[cmd2]":
+				name = strings.Trim(tag, "[]")
+			case "[1]", "[3]":
+				name = "cmd" + strings.Trim(tag, "[]")
+			default:
+				t.Fatalf("GOT: %q; WANT: known tag", line)
+			}
+			if !strings.HasPrefix(rest, name+" ") {
+				t.Fatalf("GOT: %q; WANT: line from %s", line, name)
+			}
+			counts[tag]++
+		}
+		for _, tag := range []string{"[cmd0]", "[1]", "[cmd2]", "[3]"} {
+			if got, want := counts[tag], 21; got != want {
+				t.Errorf("%s: GOT: %v; WANT: %v", tag, got, want)
+			}
+		}
+
+		for i, resp := range responses {
+			if got, want := resp.StdoutLineCount(), 11; got != want {
+				t.Errorf("%d: GOT: %v; WANT: %v", i, got, want)
+			}
+		}
+	})
+
+	t.Run("cannot spawn", func(t *testing.T) {
+		var buf bytes.Buffer
+		responses, err := RunConcurrent(context.Background(), &buf,
+			&Request{Path: "/bin/echo", Args: []string{"hello"}},
+			&Request{Path: "/no-such-path"},
+		)
+		if !errors.Is(err, ErrSpawn{}) {
+			t.Errorf("GOT: %v; WANT: %v", err, ErrSpawn{})
+		}
+		if responses[0] == nil || responses[1] != nil {
+			t.Errorf("GOT: %v; WANT: [response nil]", responses)
+		}
+		if got, want := buf.String(), "[0] hello\n"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+	})
+}
@@ -0,0 +1,20 @@
+package gorun
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+)
+
+// DetectContentType returns the MIME type of what the child process
+// wrote to its standard output, as determined by the algorithm of
+// http.DetectContentType, except that output which is a valid JSON
+// object or array is reported as "application/json". It returns
+// "application/octet-stream" when no more specific type applies.
+func (r *Response) DetectContentType() string {
+	trimmed := bytes.TrimSpace(r.Stdout)
+	if len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[') && json.Valid(trimmed) {
+		return "application/json"
+	}
+	return http.DetectContentType(r.Stdout)
+}
@@ -0,0 +1,35 @@
+//go:build !windows
+// +build !windows
+
+package gorun
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestDetectContentType(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		script string
+		want   string
+	}{
+		{name: "json", script: `echo '{"name": "gorun", "tags": [1, 2]}'`, want: "application/json"},
+		{name: "text", script: "echo hello world", want: "text/plain"},
+		{name: "html", script: "echo '<!DOCTYPE html><html></html>'", want: "text/html"},
+		{name: "gzip", script: "echo hello | gzip", want: "application/x-gzip"},
+		{name: "binary", script: `printf '\001\002\003\000\377'`, want: "application/octet-stream"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			resp, err := Run(context.Background(), &Request{
+				Path: "/bin/sh",
+				Args: []string{"-c", tc.script},
+			})
+			ensureError(t, err, nil)
+			if got := resp.DetectContentType(); !strings.HasPrefix(got, tc.want) {
+				t.Errorf("GOT: %q; WANT: %q", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package gorun
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// CSVOptions control how RunCSV decodes the standard output of a child
+// process.
+type CSVOptions struct {
+	// Delimiter separates the fields of each record. When zero, fields
+	// are separated by commas.
+	Delimiter rune
+
+	// TrimLeadingSpace, when true, ignores leading white space in each
+	// field.
+	TrimLeadingSpace bool
+}
+
+// RunCSV executes a system command like RunVerboseChecked does, and
+// when the child process exits successfully, decodes its standard
+// output as CSV records, as specified by opts. Records need not have
+// the same number of fields as each other. It returns an ErrDecodeCSV
+// error when the standard output of the child process cannot be decoded
+// as CSV.
+//
+// The returned Response is the same one Run would have returned, and
+// remains available even when the returned error is an ErrExit or an
+// ErrDecodeCSV.
+func (req *Request) RunCSV(ctx context.Context, opts CSVOptions) ([][]string, *Response, error) {
+	resp, err := req.RunVerboseChecked(ctx)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	r := csv.NewReader(bytes.NewReader(resp.Stdout))
+	if opts.Delimiter != 0 {
+		r.Comma = opts.Delimiter
+	}
+	r.TrimLeadingSpace = opts.TrimLeadingSpace
+	r.FieldsPerRecord = -1
+
+	var records [][]string
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			return records, resp, nil
+		}
+		if err != nil {
+			e := ErrDecodeCSV{Err: err}
+			var perr *csv.ParseError
+			if errors.As(err, &perr) {
+				e.Line = perr.StartLine
+				e.Record = recordText(resp.Stdout, perr.StartLine, perr.Line)
+			}
+			return nil, resp, e
+		}
+		records = append(records, record)
+	}
+}
+
+// recordText returns the text of lines first through last of buf,
+// numbered from 1, without the final newline.
+func recordText(buf []byte, first, last int) string {
+	lines := splitLines(buf)
+	if first < 1 || first > len(lines) {
+		return ""
+	}
+	if last > len(lines) {
+		last = len(lines)
+	}
+	return strings.Join(lines[first-1:last], "\n")
+}
+
+// ErrDecodeCSV is returned when the standard output of a child process
+// cannot be decoded as CSV.
+type ErrDecodeCSV struct {
+	Err error
+
+	// Line is the line number, counting from 1, on which the offending
+	// record starts, or 0 when it is not known.
+	Line int
+
+	// Record is the text of the offending record.
+	Record string
+}
+
+func (e ErrDecodeCSV) Error() string {
+	msg := "cannot decode standard output as CSV: " + e.Err.Error()
+	if e.Line > 0 {
+		msg += ": record on line " + strconv.Itoa(e.Line) + ": " + preview([]byte(e.Record))
+	}
+	return msg
+}
+
+func (e ErrDecodeCSV) Is(err error) bool {
+	_, ok := err.(ErrDecodeCSV)
+	return ok
+}
+
+func (e ErrDecodeCSV) Unwrap() error { return e.Err }
@@ -0,0 +1,86 @@
+//go:build !windows
+// +build !windows
+
+package gorun
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRunCSV(t *testing.T) {
+	t.Run("decodes", func(t *testing.T) {
+		got, resp, err := (&Request{
+			Path: "/usr/bin/printf",
+			Args: []string{"name,count\n\"go, run\",3\nempty,\n"},
+		}).RunCSV(context.Background(), CSVOptions{})
+		ensureError(t, err, nil)
+		want := [][]string{{"name", "count"}, {"go, run", "3"}, {"empty", ""}}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+		if resp == nil {
+			t.Errorf("GOT: %v; WANT: response", resp)
+		}
+	})
+
+	t.Run("options", func(t *testing.T) {
+		got, _, err := (&Request{
+			Path: "/usr/bin/printf",
+			Args: []string{"a; b;  c\nd;e\n"},
+		}).RunCSV(context.Background(), CSVOptions{Delimiter: ';', TrimLeadingSpace: true})
+		ensureError(t, err, nil)
+		want := [][]string{{"a", "b", "c"}, {"d", "e"}}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		got, _, err := (&Request{Path: "/usr/bin/true"}).RunCSV(context.Background(), CSVOptions{})
+		ensureError(t, err, nil)
+		if got != nil {
+			t.Errorf("GOT: %q; WANT: %v", got, nil)
+		}
+	})
+
+	t.Run("malformed", func(t *testing.T) {
+		got, resp, err := (&Request{
+			Path: "/usr/bin/printf",
+			Args: []string{"a,b\nc,\"d\"e\nf,g\n"},
+		}).RunCSV(context.Background(), CSVOptions{})
+		var e ErrDecodeCSV
+		if !errors.As(err, &e) {
+			t.Fatalf("GOT: %v; WANT: %v", err, ErrDecodeCSV{})
+		}
+		if e.Line != 2 || e.Record != `c,"d"e` {
+			t.Errorf("GOT: %d %q; WANT: %d %q", e.Line, e.Record, 2, `c,"d"e`)
+		}
+		if got, want := err.Error(), `record on line 2: "c,\"d\"e"`; !strings.Contains(got, want) {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		if got != nil {
+			t.Errorf("GOT: %q; WANT: %v", got, nil)
+		}
+		if resp == nil || len(resp.Stdout) == 0 {
+			t.Errorf("GOT: %v; WANT: response with standard output", resp)
+		}
+	})
+
+	t.Run("exit", func(t *testing.T) {
+		got, resp, err := (&Request{
+			Path: "/bin/sh",
+			Args: []string{"-c", "echo 'not,\"csv'; exit 3"},
+		}).RunCSV(context.Background(), CSVOptions{})
+		ensureError(t, err, ErrExit{Code: 3, Stdout: []byte("not,\"csv\n"), Stderr: []byte{}})
+		if got != nil {
+			t.Errorf("GOT: %q; WANT: %v", got, nil)
+		}
+		if resp == nil || resp.Code != 3 {
+			t.Errorf("GOT: %v; WANT: response with code 3", resp)
+		}
+	})
+}
@@ -0,0 +1,97 @@
+// Package docker provides a gorun.Executor that runs requests inside a
+// Docker container by invoking the docker command line program.
+package docker
+
+import (
+	"context"
+	"os/exec"
+
+	"github.com/karrick/gorun"
+)
+
+// Executor is a gorun.Executor that runs each Request inside a Docker
+// container, either by executing it in the running container named by
+// Container, or by running it in a new container created from Image and
+// removed after the command exits.
+//
+// Request.Path and Request.Args name the program to run inside the
+// container, Request.Dir sets its working directory inside the
+// container, and the standard input returned by Request.StdinReader is
+// connected to its standard input.
+// Unlike Run on the local host, the entries of Request.Env and
+// Request.EnvMap are added to the environment of the container rather
+// than replacing it.
+//
+// The exit code of the command inside the container is reported in
+// Response.Code. The docker program reports its own failures using exit
+// codes 125 and above, which are indistinguishable from the same codes
+// returned by the command itself.
+//
+// NOTE: When the context is done, or Request.Timeout elapses when it
+// is positive, the docker program is killed, which does not necessarily
+// terminate the command inside the container.
+type Executor struct {
+	// Container is the name or ID of a running container in which to
+	// execute commands. When empty, Image is used instead.
+	Container string
+
+	// Image is the name of the image from which to create a new
+	// container for each command, when Container is empty.
+	Image string
+
+	// Docker is the path to the docker program. When empty, it is
+	// found by searching the directories named by the PATH
+	// environment variable.
+	Docker string
+}
+
+// Run executes req inside a Docker container.
+func (e *Executor) Run(ctx context.Context, req *gorun.Request) (*gorun.Response, error) {
+	path := e.Docker
+	if path == "" {
+		var err error
+		if path, err = exec.LookPath("docker"); err != nil {
+			return nil, gorun.ErrSpawn{Err: err}
+		}
+	}
+	if req.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, req.Timeout)
+		defer cancel()
+	}
+	return (&gorun.Request{
+		Path:      path,
+		Args:      e.args(req),
+		Stdin:     req.StdinReader(),
+		StdinTee:  req.StdinTee,
+		StderrTee: req.StderrTee,
+		StdoutTee: req.StdoutTee,
+	}).Run(ctx)
+}
+
+// args returns the command line arguments to send to the docker
+// program to run req.
+func (e *Executor) args(req *gorun.Request) []string {
+	var args []string
+	if e.Container != "" {
+		args = append(args, "exec")
+	} else {
+		args = append(args, "run", "--rm")
+	}
+	if req.StdinReader() != nil {
+		args = append(args, "--interactive")
+	}
+	if req.Dir != "" {
+		args = append(args, "--workdir", req.Dir)
+	}
+	for _, kv := range req.EnvList() {
+		args = append(args, "--env", kv)
+	}
+	if e.Container != "" {
+		args = append(args, e.Container)
+	} else {
+		args = append(args, e.Image)
+	}
+	args = append(args, req.Path)
+	return append(args, req.Args...)
+}
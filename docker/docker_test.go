@@ -0,0 +1,46 @@
+package docker
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/karrick/gorun"
+)
+
+func TestArgs(t *testing.T) {
+	t.Run("container", func(t *testing.T) {
+		got := (&Executor{Container: "web"}).args(&gorun.Request{
+			Path:  "/bin/cat",
+			Args:  []string{"-n"},
+			Dir:   "/srv",
+			Env:   []string{"A=1", "B=2"},
+			Stdin: strings.NewReader("input"),
+		})
+		want := "exec --interactive --workdir /srv --env A=1 --env B=2 web /bin/cat -n"
+		if g := strings.Join(got, " "); g != want {
+			t.Errorf("GOT: %q; WANT: %q", g, want)
+		}
+	})
+
+	t.Run("image", func(t *testing.T) {
+		got := (&Executor{Image: "alpine:3"}).args(&gorun.Request{
+			Path: "/bin/echo",
+			Args: []string{"hello"},
+		})
+		want := "run --rm alpine:3 /bin/echo hello"
+		if g := strings.Join(got, " "); g != want {
+			t.Errorf("GOT: %q; WANT: %q", g, want)
+		}
+	})
+
+	t.Run("stdin string", func(t *testing.T) {
+		got := (&Executor{Image: "alpine:3"}).args(&gorun.Request{
+			Path:        "/bin/cat",
+			StdinString: "input",
+		})
+		want := "run --rm --interactive alpine:3 /bin/cat"
+		if g := strings.Join(got, " "); g != want {
+			t.Errorf("GOT: %q; WANT: %q", g, want)
+		}
+	})
+}
@@ -0,0 +1,61 @@
+//go:build !windows
+// +build !windows
+
+package docker
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/karrick/gorun"
+)
+
+// fakeDocker writes a shell script that stands in for the docker
+// program, and returns its path.
+func fakeDocker(tb testing.TB, script string) string {
+	tb.Helper()
+	path := filepath.Join(tb.TempDir(), "docker")
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+script+"\n"), 0o755); err != nil {
+		tb.Fatal(err)
+	}
+	return path
+}
+
+func TestExecutor(t *testing.T) {
+	t.Run("arguments", func(t *testing.T) {
+		executor := &Executor{Image: "alpine:3", Docker: fakeDocker(t, `echo "$@"`)}
+		resp, err := executor.Run(context.Background(), &gorun.Request{
+			Path: "/bin/echo",
+			Args: []string{"hello"},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := string(resp.Stdout), "run --rm alpine:3 /bin/echo hello\n"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+	})
+
+	t.Run("request timeout", func(t *testing.T) {
+		executor := &Executor{Image: "alpine:3", Docker: fakeDocker(t, "exec sleep 5")}
+		started := time.Now()
+		resp, err := executor.Run(context.Background(), &gorun.Request{
+			Path:    "/bin/sleep",
+			Args:    []string{"5"},
+			Timeout: 100 * time.Millisecond,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if elapsed := time.Since(started); elapsed > 2*time.Second {
+			t.Errorf("GOT: %v; WANT: < %v", elapsed, 2*time.Second)
+		}
+		if !errors.Is(resp.Err, gorun.ErrTimeout{}) {
+			t.Errorf("GOT: %v; WANT: %v", resp.Err, gorun.ErrTimeout{})
+		}
+	})
+}
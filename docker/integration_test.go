@@ -0,0 +1,37 @@
+//go:build docker
+// +build docker
+
+package docker
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/karrick/gorun"
+)
+
+// Run these tests with: go test -tags docker ./docker
+func TestExecutorIntegration(t *testing.T) {
+	var executor gorun.Executor = &Executor{Image: "alpine:3"}
+
+	resp, err := executor.Run(context.Background(), &gorun.Request{
+		Path:  "/bin/sh",
+		Args:  []string{"-c", `echo "$GORUN $(pwd)" >&2; cat -; exit 13`},
+		Dir:   "/tmp",
+		Env:   []string{"GORUN=asdf"},
+		Stdin: strings.NewReader("from stdin"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := resp.Code, 13; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+	if got, want := string(resp.Stderr), "asdf /tmp\n"; got != want {
+		t.Errorf("GOT: %q; WANT: %q", got, want)
+	}
+	if got, want := string(resp.Stdout), "from stdin"; got != want {
+		t.Errorf("GOT: %q; WANT: %q", got, want)
+	}
+}
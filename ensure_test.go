@@ -17,8 +17,7 @@ func ensureError(tb testing.TB, got, want error) {
 	} else if want == nil {
 		tb.Fatalf("GOT: %T(%q); WANT: %v", got, got.Error(), want)
 	} else {
-		var target error
-		if ok := errors.As(got, &target); !ok {
+		if !errors.Is(got, want) {
 			tb.Fatalf("GOT: %T(%q); WANT: %T(%q)", got, got.Error(), want, want.Error())
 		}
 		if g, w := got.Error(), want.Error(); !strings.Contains(g, w) {
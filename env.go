@@ -0,0 +1,123 @@
+package gorun
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// expandEnv returns a copy of env with the references to other
+// variables in each value expanded, resolving each referenced variable
+// assigned in env before the variables that reference it. A reference
+// to a variable not assigned in env, or a variable's reference to
+// itself, expands to the value of that variable in the environment of
+// the current process. It returns an error when variables reference
+// each other in a cycle.
+func expandEnv(env []string) ([]string, error) {
+	if env == nil {
+		return nil, nil
+	}
+
+	values := make(map[string]string, len(env))
+	for _, kv := range env {
+		if k, v, ok := strings.Cut(kv, "="); ok {
+			values[k] = v // later assignments override earlier ones
+		}
+	}
+
+	expanded := make(map[string]string, len(values))
+	var path []string // names being expanded, to detect cycles
+
+	var expand func(name string) error
+	expand = func(name string) error {
+		if _, ok := expanded[name]; ok {
+			return nil
+		}
+		for i, n := range path {
+			if n == name {
+				return fmt.Errorf("environment variable reference cycle: %s", strings.Join(append(path[i:], name), " -> "))
+			}
+		}
+		path = append(path, name)
+		defer func() { path = path[:len(path)-1] }()
+
+		var err error
+		value := os.Expand(values[name], func(ref string) string {
+			if err != nil {
+				return ""
+			}
+			if _, ok := values[ref]; !ok || ref == name {
+				return os.Getenv(ref)
+			}
+			if err = expand(ref); err != nil {
+				return ""
+			}
+			return expanded[ref]
+		})
+		if err != nil {
+			return err
+		}
+		expanded[name] = value
+		return nil
+	}
+
+	result := make([]string, 0, len(env))
+	for _, kv := range env {
+		k, _, ok := strings.Cut(kv, "=")
+		if !ok {
+			result = append(result, kv)
+			continue
+		}
+		if err := expand(k); err != nil {
+			return nil, err
+		}
+		result = append(result, k+"="+expanded[k])
+	}
+	return result, nil
+}
+
+// mergeEnv returns base with the assignments of env applied to it, in
+// the order of base followed by any variables base does not assign, in
+// the order env first assigns them. When a variable is assigned more
+// than once in either, the last assignment wins, and it appears only
+// once in the result.
+func mergeEnv(base, env []string) []string {
+	merged := make([]string, 0, len(base)+len(env))
+	index := make(map[string]int, len(base)+len(env))
+	for _, list := range [][]string{base, env} {
+		for _, kv := range list {
+			k, _, _ := strings.Cut(kv, "=")
+			if i, ok := index[k]; ok {
+				merged[i] = kv
+				continue
+			}
+			index[k] = len(merged)
+			merged = append(merged, kv)
+		}
+	}
+	return merged
+}
+
+// EnvList returns the environment variable assignments specified by
+// EnvMap and Env, in the form of Env: those of EnvMap sorted by name,
+// followed by those of Env, with each variable assigned only once, and
+// Env taking precedence over EnvMap. It returns nil when both are nil,
+// in which case the child process inherits the environment of this
+// process. It neither applies the assignments to that environment when
+// InheritEnv is true, nor expands references when ExpandEnv is true.
+func (req *Request) EnvList() []string {
+	if req.EnvMap == nil {
+		return req.Env
+	}
+	names := make([]string, 0, len(req.EnvMap))
+	for name := range req.EnvMap {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	base := make([]string, len(names))
+	for i, name := range names {
+		base[i] = name + "=" + req.EnvMap[name]
+	}
+	return mergeEnv(base, req.Env)
+}
@@ -0,0 +1,151 @@
+package gorun
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+// Event types written to Request.Events.
+const (
+	// EventStart is written after the child process is spawned.
+	EventStart = "start"
+
+	// EventOutput is written for each chunk of output read from the
+	// child process.
+	EventOutput = "output"
+
+	// EventBudgetExceeded is written after the child process exits,
+	// before EventExit, when it ran longer than Request.LatencyBudget.
+	EventBudgetExceeded = "budget exceeded"
+
+	// EventExit is written after the child process exits.
+	EventExit = "exit"
+)
+
+// Event represents a lifecycle event of a child process, written as a
+// single line of JSON to Request.Events.
+type Event struct {
+	// Time is when the event occurred.
+	Time time.Time `json:"time"`
+
+	// Type is one of EventStart, EventOutput, EventBudgetExceeded, or
+	// EventExit.
+	Type string `json:"type"`
+
+	// PID is the process ID of the child process, set for EventStart.
+	PID int `json:"pid,omitempty"`
+
+	// Stream is either "stdout" or "stderr", set for EventOutput.
+	Stream string `json:"stream,omitempty"`
+
+	// Data is the chunk of output, set for EventOutput. Invalid UTF-8
+	// is replaced with the Unicode replacement character.
+	Data string `json:"data,omitempty"`
+
+	// Duration is how long the child process ran, set for
+	// EventBudgetExceeded.
+	Duration time.Duration `json:"duration,omitempty"`
+
+	// Code is the exit code of the child process, set for EventExit.
+	Code *int `json:"code,omitempty"`
+
+	// Error is the message of the error describing why the child
+	// process terminated, when it did not exit on its own, and is
+	// potentially set for EventExit.
+	Error string `json:"error,omitempty"`
+}
+
+// eventLog writes Events as newline delimited JSON. All its methods
+// are no-ops when invoked on a nil eventLog.
+type eventLog struct {
+	lock sync.Mutex
+	enc  *json.Encoder
+}
+
+func newEventLog(w io.Writer) *eventLog {
+	if w == nil {
+		return nil
+	}
+	return &eventLog{enc: json.NewEncoder(w)}
+}
+
+// emit writes e, ignoring any error, because failing to log an event
+// ought not affect the child process.
+func (el *eventLog) emit(e Event) {
+	if el == nil {
+		return
+	}
+	el.lock.Lock()
+	el.encode(e)
+	el.lock.Unlock()
+}
+
+// encode writes e while the caller holds the lock.
+func (el *eventLog) encode(e Event) {
+	e.Time = time.Now()
+	_ = el.enc.Encode(e)
+}
+
+// spawning blocks any output events from being written until either
+// start or abort is invoked, so the start event is always written
+// before any output events, even though the goroutines that copy the
+// output of the child process may run before spawn returns.
+func (el *eventLog) spawning() {
+	if el != nil {
+		el.lock.Lock()
+	}
+}
+
+// abort unblocks output events after the child process failed to
+// spawn.
+func (el *eventLog) abort() {
+	if el != nil {
+		el.lock.Unlock()
+	}
+}
+
+// start writes the start event and unblocks output events.
+func (el *eventLog) start(pid int) {
+	if el == nil {
+		return
+	}
+	el.encode(Event{Type: EventStart, PID: pid})
+	el.lock.Unlock()
+}
+
+func (el *eventLog) budgetExceeded(duration time.Duration) {
+	el.emit(Event{Type: EventBudgetExceeded, Duration: duration})
+}
+
+func (el *eventLog) exit(code int, err error) {
+	e := Event{Type: EventExit, Code: &code}
+	if err != nil {
+		e.Error = err.Error()
+	}
+	el.emit(e)
+}
+
+// stream returns an io.Writer that writes an EventOutput for each chunk
+// written to it, or nil when el is nil.
+func (el *eventLog) stream(stream int) io.Writer {
+	if el == nil {
+		return nil
+	}
+	name := "stdout"
+	if stream == StreamStderr {
+		name = "stderr"
+	}
+	return eventWriter{el: el, stream: name}
+}
+
+type eventWriter struct {
+	el     *eventLog
+	stream string
+}
+
+func (ew eventWriter) Write(p []byte) (int, error) {
+	ew.el.emit(Event{Type: EventOutput, Stream: ew.stream, Data: string(p)})
+	return len(p), nil
+}
@@ -0,0 +1,66 @@
+//go:build !windows
+// +build !windows
+
+package gorun
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+func TestEvents(t *testing.T) {
+	var bb bytes.Buffer
+
+	_, err := Run(context.Background(), &Request{
+		Path:   "/bin/sh",
+		Args:   []string{"-c", "echo one; sleep 0.05; echo two >&2; exit 3"},
+		Events: &bb,
+	})
+	ensureError(t, err, nil)
+
+	var events []Event
+	scanner := bufio.NewScanner(&bb)
+	for scanner.Scan() {
+		var e Event
+		ensureError(t, json.Unmarshal(scanner.Bytes(), &e), nil)
+		events = append(events, e)
+	}
+	ensureError(t, scanner.Err(), nil)
+
+	if got, want := len(events), 4; got != want {
+		t.Fatalf("GOT: %v; WANT: %v", got, want)
+	}
+
+	if got, want := events[0].Type, EventStart; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+	if events[0].PID <= 0 {
+		t.Errorf("GOT: %v; WANT: > 0", events[0].PID)
+	}
+
+	for i, want := range []Event{
+		{Type: EventOutput, Stream: "stdout", Data: "one\n"},
+		{Type: EventOutput, Stream: "stderr", Data: "two\n"},
+	} {
+		got := events[i+1]
+		if got.Type != want.Type || got.Stream != want.Stream || got.Data != want.Data {
+			t.Errorf("GOT: %+v; WANT: %+v", got, want)
+		}
+	}
+
+	if got, want := events[3].Type, EventExit; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+	if events[3].Code == nil || *events[3].Code != 3 {
+		t.Errorf("GOT: %v; WANT: %v", events[3].Code, 3)
+	}
+
+	for i := 1; i < len(events); i++ {
+		if events[i].Time.Before(events[i-1].Time) {
+			t.Errorf("GOT: %v; WANT: not before %v", events[i].Time, events[i-1].Time)
+		}
+	}
+}
@@ -0,0 +1,25 @@
+package gorun
+
+import "context"
+
+// Executor is the interface implemented by values that execute a
+// Request and return its Response, whether by spawning a child process
+// on the local host or by some other transport.
+//
+// Implementations should follow the same conventions as Run: they
+// return a nil Response and an ErrSpawn error when they cannot start
+// the command, and return a Response whose Err is an ErrSignal when the
+// command terminated due to receiving a signal.
+type Executor interface {
+	Run(ctx context.Context, req *Request) (*Response, error)
+}
+
+// ExecutorFunc is an adapter to allow the use of an ordinary function
+// as an Executor. For instance, ExecutorFunc(Run) is an Executor that
+// spawns child processes on the local host.
+type ExecutorFunc func(ctx context.Context, req *Request) (*Response, error)
+
+// Run calls f(ctx, req).
+func (f ExecutorFunc) Run(ctx context.Context, req *Request) (*Response, error) {
+	return f(ctx, req)
+}
@@ -0,0 +1,39 @@
+package gorun
+
+import (
+	"errors"
+	"io/fs"
+	"os/exec"
+	"path/filepath"
+)
+
+// path returns the path of the program the child process runs, which is
+// Path, unless it cannot be found, in which case it is the first of
+// Fallbacks that can be found. When none of them can be found, it
+// returns Path, so the spawn error describes it. The environment of the
+// child process is env.
+func (req *Request) path(env []string) string {
+	if len(req.Fallbacks) == 0 || req.found(req.Path, env) {
+		return req.Path
+	}
+	for _, name := range req.Fallbacks {
+		if req.found(name, env) {
+			return name
+		}
+	}
+	return req.Path
+}
+
+// found returns false when the named program does not exist, either as
+// a file, or when it is a bare name, in any of the directories named by
+// the PATH environment variable. Other errors, such as the program not
+// being executable, are reported when spawning it instead.
+func (req *Request) found(name string, env []string) bool {
+	if req.Dir != "" && filepath.Base(name) != name && !filepath.IsAbs(name) {
+		// Like exec.Cmd, resolve relative paths from the working
+		// directory of the child process.
+		name = filepath.Join(req.Dir, name)
+	}
+	_, err := req.lookPath(name, env)
+	return !errors.Is(err, exec.ErrNotFound) && !errors.Is(err, fs.ErrNotExist)
+}
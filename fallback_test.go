@@ -0,0 +1,55 @@
+//go:build !windows
+// +build !windows
+
+package gorun
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFallbacks(t *testing.T) {
+	t.Run("fallback found", func(t *testing.T) {
+		got, err := Run(context.Background(), &Request{
+			Path:      "/no-such-path",
+			Args:      []string{"hello"},
+			Fallbacks: []string{"no-such-program", "/bin/echo"},
+		})
+		ensureError(t, err, nil)
+		want := &Response{
+			Stderr: []byte{},
+			Stdout: []byte("hello\n"),
+		}
+		ensureResponsesMatch(t, got, want)
+	})
+	t.Run("primary found", func(t *testing.T) {
+		got, err := Run(context.Background(), &Request{
+			Path:      "/bin/echo",
+			Args:      []string{"hello"},
+			Fallbacks: []string{"/no-such-path"},
+		})
+		ensureError(t, err, nil)
+		if got, want := string(got.Stdout), "hello\n"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+	})
+	t.Run("none found", func(t *testing.T) {
+		_, err := Run(context.Background(), &Request{
+			Path:      "/no-such-path",
+			Fallbacks: []string{"/no-such-either"},
+		})
+		ensureError(t, err, ErrSpawn{Err: errors.New("fork/exec /no-such-path: no such file or directory")})
+	})
+	t.Run("not executable", func(t *testing.T) {
+		name := filepath.Join(t.TempDir(), "program")
+		ensureError(t, os.WriteFile(name, []byte("\x7fELF"), 0o644), nil)
+		_, err := Run(context.Background(), &Request{
+			Path:      name,
+			Fallbacks: []string{"/bin/echo"},
+		})
+		ensureError(t, err, ErrSpawn{Err: errors.New("fork/exec " + name + ": permission denied")})
+	})
+}
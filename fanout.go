@@ -0,0 +1,82 @@
+package gorun
+
+import (
+	"bytes"
+	"io"
+)
+
+// FilteredWriter is a destination for the lines of standard output of a
+// child process, along with an optional filter selecting which lines it
+// receives.
+type FilteredWriter struct {
+	// W is the io.Writer to which selected lines are written, each in
+	// a single call, including its newline terminator.
+	W io.Writer
+
+	// Filter is the potentially nil function that returns true for the
+	// lines W should receive. It is invoked with each line without its
+	// newline terminator. When nil, W receives every line.
+	Filter func(line []byte) bool
+}
+
+// fanoutWriter writes each complete line written to it to every one of
+// writers whose filter selects it.
+type fanoutWriter struct {
+	writers []FilteredWriter
+	partial []byte // the most recent line, when it lacks a newline
+}
+
+// writer returns fw as an io.Writer, or nil when fw is nil.
+func (fw *fanoutWriter) writer() io.Writer {
+	if fw == nil {
+		return nil
+	}
+	return fw
+}
+
+func (fw *fanoutWriter) Write(p []byte) (int, error) {
+	n := len(p)
+	for len(p) > 0 {
+		i := bytes.IndexByte(p, '\n')
+		if i == -1 {
+			fw.partial = append(fw.partial, p...)
+			break
+		}
+		line := p[:i+1]
+		if len(fw.partial) > 0 {
+			line = append(fw.partial, line...)
+			fw.partial = fw.partial[:0]
+		}
+		if err := fw.line(line); err != nil {
+			return 0, err
+		}
+		p = p[i+1:]
+	}
+	return n, nil
+}
+
+// flush handles the final line written when it lacks a newline. It
+// returns nil when invoked on a nil fanoutWriter.
+func (fw *fanoutWriter) flush() error {
+	if fw == nil || len(fw.partial) == 0 {
+		return nil
+	}
+	line := fw.partial
+	fw.partial = nil
+	return fw.line(line)
+}
+
+// line writes line to each of writers whose filter selects it, ignoring
+// its newline.
+func (fw *fanoutWriter) line(line []byte) error {
+	trimmed := bytes.TrimSuffix(line, []byte{'\n'})
+	for _, w := range fw.writers {
+		if w.Filter != nil && !w.Filter(trimmed) {
+			continue
+		}
+		if _, err := w.W.Write(line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
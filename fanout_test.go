@@ -0,0 +1,34 @@
+//go:build !windows
+// +build !windows
+
+package gorun
+
+import (
+	"bytes"
+	"context"
+	"testing"
+)
+
+func TestStdoutWriters(t *testing.T) {
+	var all, errs bytes.Buffer
+	got, err := Run(context.Background(), &Request{
+		Path: "/usr/bin/printf",
+		Args: []string{"INFO: one\nERROR: two\nINFO: three\nERROR: four"},
+		StdoutWriters: []FilteredWriter{
+			{W: &all},
+			{W: &errs, Filter: func(line []byte) bool { return bytes.HasPrefix(line, []byte("ERROR: ")) }},
+		},
+	})
+	ensureError(t, err, nil)
+	want := &Response{
+		Stderr: []byte{},
+		Stdout: []byte("INFO: one\nERROR: two\nINFO: three\nERROR: four"),
+	}
+	ensureResponsesMatch(t, got, want)
+	if got, want := all.String(), "INFO: one\nERROR: two\nINFO: three\nERROR: four"; got != want {
+		t.Errorf("GOT: %q; WANT: %q", got, want)
+	}
+	if got, want := errs.String(), "ERROR: two\nERROR: four"; got != want {
+		t.Errorf("GOT: %q; WANT: %q", got, want)
+	}
+}
@@ -0,0 +1,48 @@
+package gorun
+
+import "time"
+
+// fdSampleInterval is how often the open file descriptors of a child
+// process are counted when Request.SampleFDs is true.
+const fdSampleInterval = 10 * time.Millisecond
+
+// fdSampler periodically counts the open file descriptors of a child
+// process, retaining the most recent count taken while it was running.
+type fdSampler struct {
+	done     chan struct{}
+	finished chan struct{}
+	count    int
+}
+
+// sampleFDs starts counting the open file descriptors of the process
+// pid until stop is invoked.
+func sampleFDs(pid int) *fdSampler {
+	fs := &fdSampler{done: make(chan struct{}), finished: make(chan struct{})}
+	go func() {
+		defer close(fs.finished)
+		ticker := time.NewTicker(fdSampleInterval)
+		defer ticker.Stop()
+		for {
+			if n, err := countFDs(pid); err == nil {
+				fs.count = n
+			}
+			select {
+			case <-fs.done:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return fs
+}
+
+// stop stops sampling and returns the most recent count. It returns zero
+// when invoked on a nil fdSampler.
+func (fs *fdSampler) stop() int {
+	if fs == nil {
+		return 0
+	}
+	close(fs.done)
+	<-fs.finished
+	return fs.count
+}
@@ -0,0 +1,36 @@
+package gorun
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"strconv"
+)
+
+// canSampleFDs is true when this platform supports Request.SampleFDs.
+const canSampleFDs = true
+
+// countFDs returns the number of file descriptors the process pid has
+// open. It returns an error when the process has already exited, because
+// the file descriptors of a zombie process are already closed.
+func countFDs(pid int) (int, error) {
+	dir := "/proc/" + strconv.Itoa(pid)
+	entries, err := os.ReadDir(dir + "/fd")
+	if err != nil {
+		return 0, err
+	}
+	// The state follows the parenthesized command name, which may
+	// itself contain parentheses.
+	stat, err := os.ReadFile(dir + "/stat")
+	if err != nil {
+		return 0, err
+	}
+	i := bytes.LastIndexByte(stat, ')')
+	if i == -1 || i+2 >= len(stat) {
+		return 0, errors.New("cannot parse process status")
+	}
+	if state := stat[i+2]; state == 'Z' || state == 'X' {
+		return 0, errors.New("process exited")
+	}
+	return len(entries), nil
+}
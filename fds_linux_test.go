@@ -0,0 +1,19 @@
+package gorun
+
+import (
+	"context"
+	"testing"
+)
+
+func TestSampleFDs(t *testing.T) {
+	got, err := Run(context.Background(), &Request{
+		Path:      "/bin/sh",
+		Args:      []string{"-c", "exec 3</dev/null 4</dev/null 5</dev/null 6</dev/null; sleep 0.2"},
+		SampleFDs: true,
+	})
+	ensureError(t, err, nil)
+	// Standard input, output, and error, and the four opened above.
+	if got.OpenFDs < 7 || got.OpenFDs > 64 {
+		t.Errorf("GOT: %v; WANT: between %v and %v", got.OpenFDs, 7, 64)
+	}
+}
@@ -0,0 +1,13 @@
+//go:build !linux
+// +build !linux
+
+package gorun
+
+import "errors"
+
+// canSampleFDs is true when this platform supports Request.SampleFDs.
+const canSampleFDs = false
+
+func countFDs(_ int) (int, error) {
+	return 0, errors.New("cannot count file descriptors on this platform")
+}
@@ -0,0 +1,67 @@
+// Package goruntest provides helpers for testing the output of commands
+// run by gorun.
+package goruntest
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/karrick/gorun"
+	"github.com/karrick/gorun/internal/diff"
+)
+
+// update is set by passing -update to go test, and causes RunGolden to
+// write golden files rather than compare against them.
+var update = flag.Bool("update", false, "update golden files with the output of the commands")
+
+// RunGolden runs req, and compares its standard output to the contents
+// of the golden file at goldenPath, ignoring leading and trailing white
+// space of both. When they differ, it reports an error that includes a
+// unified diff from the golden file to the standard output. When go
+// test is invoked with the -update flag, it instead writes the standard
+// output, followed by a newline, to the golden file, creating it and
+// its parent directories as required.
+//
+// It fails tb immediately when req cannot be run, or when the golden
+// file cannot be read or written. It returns the Response of req.
+func RunGolden(tb testing.TB, ctx context.Context, req *gorun.Request, goldenPath string) *gorun.Response {
+	tb.Helper()
+
+	resp, err := req.Run(ctx)
+	if err != nil {
+		tb.Fatalf("cannot run %s: %v", req, err)
+		return nil
+	}
+	got := strings.TrimSpace(string(resp.Stdout))
+
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(goldenPath), 0o755); err != nil {
+			tb.Fatalf("cannot update golden file: %v", err)
+			return resp
+		}
+		if err := os.WriteFile(goldenPath, []byte(got+"\n"), 0o644); err != nil {
+			tb.Fatalf("cannot update golden file: %v", err)
+		}
+		return resp
+	}
+
+	buf, err := os.ReadFile(goldenPath)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			tb.Fatalf("cannot read golden file: %v; run go test with -update to create it", err)
+		} else {
+			tb.Fatalf("cannot read golden file: %v", err)
+		}
+		return resp
+	}
+	if want := strings.TrimSpace(string(buf)); got != want {
+		tb.Errorf("standard output of %s differs from golden file:\n%s", req, diff.Unified(goldenPath, "stdout", want, got))
+	}
+	return resp
+}
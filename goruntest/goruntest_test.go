@@ -0,0 +1,81 @@
+//go:build !windows
+// +build !windows
+
+package goruntest
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/karrick/gorun"
+)
+
+// recorder is a testing.TB that records the errors reported to it.
+type recorder struct {
+	testing.TB
+	errors []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...any) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func TestRunGolden(t *testing.T) {
+	req := &gorun.Request{Path: "/usr/bin/printf", Args: []string{"a\nb\nc\n"}}
+
+	t.Run("match", func(t *testing.T) {
+		golden := filepath.Join(t.TempDir(), "match.golden")
+		if err := os.WriteFile(golden, []byte("a\nb\nc\n\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		rec := &recorder{TB: t}
+		resp := RunGolden(rec, context.Background(), req, golden)
+		if len(rec.errors) > 0 {
+			t.Errorf("GOT: %q; WANT: %v", rec.errors, nil)
+		}
+		if got, want := string(resp.Stdout), "a\nb\nc\n"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+	})
+
+	t.Run("mismatch", func(t *testing.T) {
+		golden := filepath.Join(t.TempDir(), "mismatch.golden")
+		if err := os.WriteFile(golden, []byte("a\nB\nc\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		rec := &recorder{TB: t}
+		RunGolden(rec, context.Background(), req, golden)
+		if got, want := len(rec.errors), 1; got != want {
+			t.Fatalf("GOT: %v; WANT: %v", got, want)
+		}
+		want := "--- " + golden + "\n+++ stdout\n@@ -1,3 +1,3 @@\n a\n-B\n+b\n c\n"
+		if got := rec.errors[0]; !strings.HasSuffix(got, want) {
+			t.Errorf("GOT: %q; WANT: suffix %q", got, want)
+		}
+	})
+
+	t.Run("update", func(t *testing.T) {
+		defer func(saved bool) { *update = saved }(*update)
+		*update = true
+
+		golden := filepath.Join(t.TempDir(), "testdata", "update.golden")
+		rec := &recorder{TB: t}
+		RunGolden(rec, context.Background(), req, golden)
+		if len(rec.errors) > 0 {
+			t.Errorf("GOT: %q; WANT: %v", rec.errors, nil)
+		}
+		buf, err := os.ReadFile(golden)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := string(buf), "a\nb\nc\n"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+	})
+}
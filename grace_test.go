@@ -0,0 +1,126 @@
+//go:build !windows
+// +build !windows
+
+package gorun
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestGracePeriod(t *testing.T) {
+	t.Run("exits when terminated", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+		got, err := Run(ctx, &Request{
+			Path:        "/bin/sh",
+			Args:        []string{"-c", "trap 'echo cleanup; exit 3' TERM; while :; do sleep 0.01; done"},
+			GracePeriod: 5 * time.Second,
+		})
+		ensureError(t, err, nil)
+		want := &Response{
+			Code:   3,
+			Stderr: []byte{},
+			Stdout: []byte("cleanup\n"),
+		}
+		ensureResponsesMatch(t, got, want)
+	})
+	t.Run("killed after grace period", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+		started := time.Now()
+		got, err := Run(ctx, &Request{
+			Path:        "/bin/sh",
+			Args:        []string{"-c", "trap 'echo ignored' TERM; while :; do sleep 0.01; done"},
+			GracePeriod: 200 * time.Millisecond,
+		})
+		ensureError(t, err, nil)
+		if elapsed := time.Since(started); elapsed < 300*time.Millisecond || elapsed > 2*time.Second {
+			t.Errorf("GOT: %v; WANT: between %v and %v", elapsed, 300*time.Millisecond, 2*time.Second)
+		}
+		want := &Response{
+			Code:   -1,
+			Err:    ErrTimeout{Err: ErrSignal{Err: errors.New("signal: killed")}},
+			Stderr: []byte{},
+			Stdout: []byte("ignored\n"),
+		}
+		ensureResponsesMatch(t, got, want)
+	})
+	t.Run("terminated by signal", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+		got, err := Run(ctx, &Request{
+			Path:        "/bin/sleep",
+			Args:        []string{"5"},
+			GracePeriod: 5 * time.Second,
+		})
+		ensureError(t, err, nil)
+		want := &Response{
+			Code:   -1,
+			Err:    ErrTimeout{Err: ErrSignal{Err: errors.New("signal: terminated")}},
+			Stderr: []byte{},
+			Stdout: []byte{},
+		}
+		ensureResponsesMatch(t, got, want)
+	})
+}
+
+func TestCLIFriendlyCancel(t *testing.T) {
+	t.Run("exits when interrupted", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+		started := time.Now()
+		got, err := Run(ctx, &Request{
+			Path:              "/bin/sh",
+			Args:              []string{"-c", "trap 'echo cleanup; exit 130' INT; while :; do sleep 0.01; done"},
+			CLIFriendlyCancel: true,
+		})
+		ensureError(t, err, nil)
+		if elapsed := time.Since(started); elapsed > 900*time.Millisecond {
+			t.Errorf("GOT: %v; WANT: < %v", elapsed, 900*time.Millisecond)
+		}
+		want := &Response{
+			Code:   130,
+			Stderr: []byte{},
+			Stdout: []byte("cleanup\n"),
+		}
+		ensureResponsesMatch(t, got, want)
+	})
+	t.Run("escalates to terminate", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+		got, err := Run(ctx, &Request{
+			Path:              "/bin/sh",
+			Args:              []string{"-c", "trap 'echo interrupted' INT; trap 'echo terminated; exit 143' TERM; while :; do sleep 0.01; done"},
+			CLIFriendlyCancel: true,
+			GracePeriod:       100 * time.Millisecond,
+		})
+		ensureError(t, err, nil)
+		want := &Response{
+			Code:   143,
+			Stderr: []byte{},
+			Stdout: []byte("interrupted\nterminated\n"),
+		}
+		ensureResponsesMatch(t, got, want)
+	})
+	t.Run("escalates to kill", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+		got, err := Run(ctx, &Request{
+			Path:              "/bin/sh",
+			Args:              []string{"-c", "trap 'echo interrupted' INT; trap 'echo terminated' TERM; while :; do sleep 0.01; done"},
+			CLIFriendlyCancel: true,
+			GracePeriod:       100 * time.Millisecond,
+		})
+		ensureError(t, err, nil)
+		want := &Response{
+			Code:   -1,
+			Err:    ErrTimeout{Err: ErrSignal{Err: errors.New("signal: killed")}},
+			Stderr: []byte{},
+			Stdout: []byte("interrupted\nterminated\n"),
+		}
+		ensureResponsesMatch(t, got, want)
+	})
+}
@@ -0,0 +1,208 @@
+package gorun
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Graph runs a collection of named Requests, each after the Requests it
+// depends on have finished. A Request fails when its child process
+// cannot be spawned, exits with a non-zero exit code, or one not in its
+// SuccessCodes when they are not empty, or terminates due to receiving
+// a signal. The zero value is an empty Graph ready to use.
+type Graph struct {
+	// RunAfterFailure, when true, runs each Request once its
+	// dependencies have finished, even when some of them failed or were
+	// skipped. When false, a Request whose dependency failed or was
+	// skipped is itself skipped.
+	RunAfterFailure bool
+
+	nodes map[string]*graphNode
+	names []string // in the order they were added
+}
+
+type graphNode struct {
+	req  *Request
+	deps []string
+}
+
+// Add adds req to g as the node called name, to be run after the nodes
+// called deps, which need not have been added yet. It returns an
+// ErrInvalidRequest error when g already has a node called name, and an
+// ErrCycle error when a node would depend, directly or indirectly, on
+// itself.
+func (g *Graph) Add(name string, req *Request, deps ...string) error {
+	if _, ok := g.nodes[name]; ok {
+		return ErrInvalidRequest{Err: errors.New("duplicate graph node: " + strconv.Quote(name))}
+	}
+	for _, dep := range deps {
+		if path := g.pathTo(dep, name, make(map[string]bool)); path != nil {
+			return ErrCycle{Nodes: append([]string{name}, path...)}
+		}
+	}
+	if g.nodes == nil {
+		g.nodes = make(map[string]*graphNode)
+	}
+	g.nodes[name] = &graphNode{req: req, deps: deps}
+	g.names = append(g.names, name)
+	return nil
+}
+
+// pathTo returns the names of the nodes along a path of dependencies
+// from the node called from to the node called to, including both, or
+// nil when there is none, skipping the nodes already visited.
+func (g *Graph) pathTo(from, to string, visited map[string]bool) []string {
+	if from == to {
+		return []string{to}
+	}
+	if visited[from] {
+		return nil
+	}
+	visited[from] = true
+	if node, ok := g.nodes[from]; ok {
+		for _, dep := range node.deps {
+			if path := g.pathTo(dep, to, visited); path != nil {
+				return append([]string{from}, path...)
+			}
+		}
+	}
+	return nil
+}
+
+// Run runs every node of g, with at most limit child processes running
+// at a time, or with no limit when limit is not positive, and returns
+// their Responses by node name once every node has finished. A
+// Response is nil when its node was skipped or canceled, or its child
+// process could not be spawned. The returned error joins an
+// ErrNodeFailed error for each node that failed and an ErrNodeSkipped
+// error for each node that was skipped. When the context is done,
+// nodes that have not yet been spawned fail with an ErrCanceled error.
+// When a node depends on a node that was never added, Run returns an
+// ErrInvalidRequest error without running any of them.
+func (g *Graph) Run(ctx context.Context, limit int) (map[string]*Response, error) {
+	for _, name := range g.names {
+		for _, dep := range g.nodes[name].deps {
+			if _, ok := g.nodes[dep]; !ok {
+				return nil, ErrInvalidRequest{Err: errors.New("graph node " + strconv.Quote(name) + " depends on unknown node " + strconv.Quote(dep))}
+			}
+		}
+	}
+
+	var sem chan struct{}
+	if limit > 0 {
+		sem = make(chan struct{}, limit)
+	}
+
+	// Each node closes its channel in done once it has finished, after
+	// recording whether it succeeded in ok.
+	done := make(map[string]chan struct{}, len(g.names))
+	for _, name := range g.names {
+		done[name] = make(chan struct{})
+	}
+	var lock sync.Mutex
+	ok := make(map[string]bool, len(g.names))
+	responses := make(map[string]*Response, len(g.names))
+	errs := make([]error, len(g.names))
+
+	var wg sync.WaitGroup
+	for i, name := range g.names {
+		wg.Add(1)
+		go func(i int, name string, node *graphNode) {
+			defer wg.Done()
+			defer close(done[name])
+
+			for _, dep := range node.deps {
+				<-done[dep]
+				lock.Lock()
+				depOK := ok[dep]
+				lock.Unlock()
+				if !depOK && !g.RunAfterFailure {
+					errs[i] = ErrNodeSkipped{Node: name, Dependency: dep}
+					return
+				}
+			}
+
+			if sem != nil {
+				select {
+				case <-ctx.Done():
+				case sem <- struct{}{}:
+					defer func() { <-sem }()
+				}
+			}
+			if err := ctx.Err(); err != nil {
+				errs[i] = ErrNodeFailed{Node: name, Err: ErrCanceled{Err: err}}
+				return
+			}
+
+			resp, err := node.req.Run(ctx)
+			if err == nil {
+				err = node.req.exitError(resp)
+			}
+			lock.Lock()
+			responses[name] = resp
+			ok[name] = err == nil
+			lock.Unlock()
+			if err != nil {
+				errs[i] = ErrNodeFailed{Node: name, Err: err}
+			}
+		}(i, name, g.nodes[name])
+	}
+	wg.Wait()
+
+	return responses, errors.Join(errs...)
+}
+
+// ErrCycle is returned when adding a node to a Graph would make it
+// depend on itself.
+type ErrCycle struct {
+	// Nodes are the names of the nodes along the cycle, starting and
+	// ending with the same node, each depending on the next.
+	Nodes []string
+}
+
+func (e ErrCycle) Error() string {
+	return "dependency cycle: " + strings.Join(e.Nodes, " -> ")
+}
+
+func (e ErrCycle) Is(err error) bool {
+	_, ok := err.(ErrCycle)
+	return ok
+}
+
+// ErrNodeFailed is returned for each node of a Graph that failed.
+type ErrNodeFailed struct {
+	Node string
+	Err  error
+}
+
+func (e ErrNodeFailed) Error() string {
+	return "graph node " + strconv.Quote(e.Node) + " failed: " + e.Err.Error()
+}
+
+func (e ErrNodeFailed) Is(err error) bool {
+	_, ok := err.(ErrNodeFailed)
+	return ok
+}
+
+func (e ErrNodeFailed) Unwrap() error { return e.Err }
+
+// ErrNodeSkipped is returned for each node of a Graph that was not run
+// because one of its dependencies failed or was skipped.
+type ErrNodeSkipped struct {
+	Node string
+
+	// Dependency is the name of the node that failed or was skipped.
+	Dependency string
+}
+
+func (e ErrNodeSkipped) Error() string {
+	return "graph node " + strconv.Quote(e.Node) + " skipped: dependency " + strconv.Quote(e.Dependency) + " did not succeed"
+}
+
+func (e ErrNodeSkipped) Is(err error) bool {
+	_, ok := err.(ErrNodeSkipped)
+	return ok
+}
@@ -0,0 +1,127 @@
+//go:build !windows
+// +build !windows
+
+package gorun
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestGraph(t *testing.T) {
+	// diamond returns a Graph where b and c depend on a, and d depends
+	// on both b and c. Each node appends its name to a log in dir, and
+	// b exits with bCode.
+	diamond := func(t *testing.T, dir, bCode string) *Graph {
+		t.Helper()
+		node := func(name, code string) *Request {
+			return &Request{
+				Path: "/bin/sh",
+				Args: []string{"-c", "echo " + name + " >> log; exit " + code},
+				Dir:  dir,
+			}
+		}
+		g := new(Graph)
+		// Added out of order, so d refers to nodes not yet added.
+		ensureError(t, g.Add("d", node("d", "0"), "b", "c"), nil)
+		ensureError(t, g.Add("b", node("b", bCode), "a"), nil)
+		ensureError(t, g.Add("c", node("c", "0"), "a"), nil)
+		ensureError(t, g.Add("a", node("a", "0")), nil)
+		return g
+	}
+	readLog := func(t *testing.T, dir string) []string {
+		t.Helper()
+		buf, err := os.ReadFile(filepath.Join(dir, "log"))
+		ensureError(t, err, nil)
+		return strings.Fields(string(buf))
+	}
+
+	t.Run("order", func(t *testing.T) {
+		dir := t.TempDir()
+		responses, err := diamond(t, dir, "0").Run(context.Background(), 0)
+		ensureError(t, err, nil)
+		if got, want := len(responses), 4; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		log := readLog(t, dir)
+		if len(log) != 4 || log[0] != "a" || log[3] != "d" {
+			t.Errorf("GOT: %q; WANT: a first and d last", log)
+		}
+	})
+
+	t.Run("limit", func(t *testing.T) {
+		dir := t.TempDir()
+		_, err := diamond(t, dir, "0").Run(context.Background(), 1)
+		ensureError(t, err, nil)
+		log := readLog(t, dir)
+		if len(log) != 4 || log[0] != "a" || log[3] != "d" {
+			t.Errorf("GOT: %q; WANT: a first and d last", log)
+		}
+	})
+
+	t.Run("skip on failure", func(t *testing.T) {
+		dir := t.TempDir()
+		responses, err := diamond(t, dir, "1").Run(context.Background(), 0)
+		ensureError(t, err, ErrNodeFailed{Node: "b", Err: errors.New("exit code 1")})
+		ensureError(t, err, ErrNodeSkipped{Node: "d", Dependency: "b"})
+		if responses["b"] == nil || responses["b"].Code != 1 {
+			t.Errorf("GOT: %v; WANT: response with code 1", responses["b"])
+		}
+		if responses["d"] != nil {
+			t.Errorf("GOT: %v; WANT: %v", responses["d"], nil)
+		}
+		log := readLog(t, dir)
+		if got, want := len(log), 3; got != want {
+			t.Errorf("GOT: %q; WANT: %v lines", log, want)
+		}
+	})
+
+	t.Run("run after failure", func(t *testing.T) {
+		dir := t.TempDir()
+		g := diamond(t, dir, "1")
+		g.RunAfterFailure = true
+		responses, err := g.Run(context.Background(), 0)
+		ensureError(t, err, ErrNodeFailed{Node: "b", Err: errors.New("exit code 1")})
+		if errors.Is(err, ErrNodeSkipped{}) {
+			t.Errorf("GOT: %v; WANT: no skipped nodes", err)
+		}
+		if responses["d"] == nil {
+			t.Errorf("GOT: %v; WANT: response", responses["d"])
+		}
+		log := readLog(t, dir)
+		if len(log) != 4 || log[3] != "d" {
+			t.Errorf("GOT: %q; WANT: d last", log)
+		}
+	})
+
+	t.Run("cycle", func(t *testing.T) {
+		g := new(Graph)
+		ensureError(t, g.Add("a", &Request{Path: "/usr/bin/true"}, "c"), nil)
+		ensureError(t, g.Add("b", &Request{Path: "/usr/bin/true"}, "a"), nil)
+		err := g.Add("c", &Request{Path: "/usr/bin/true"}, "b")
+		ensureError(t, err, ErrCycle{Nodes: []string{"c", "b", "a", "c"}})
+		var ec ErrCycle
+		if errors.As(err, &ec); !reflect.DeepEqual(ec.Nodes, []string{"c", "b", "a", "c"}) {
+			t.Errorf("GOT: %q; WANT: %q", ec.Nodes, []string{"c", "b", "a", "c"})
+		}
+		ensureError(t, g.Add("d", &Request{Path: "/usr/bin/true"}, "d"), ErrCycle{Nodes: []string{"d", "d"}})
+	})
+
+	t.Run("duplicate", func(t *testing.T) {
+		g := new(Graph)
+		ensureError(t, g.Add("a", &Request{Path: "/usr/bin/true"}), nil)
+		ensureError(t, g.Add("a", &Request{Path: "/usr/bin/true"}), ErrInvalidRequest{Err: errors.New(`duplicate graph node: "a"`)})
+	})
+
+	t.Run("unknown dependency", func(t *testing.T) {
+		g := new(Graph)
+		ensureError(t, g.Add("a", &Request{Path: "/usr/bin/true"}, "missing"), nil)
+		_, err := g.Run(context.Background(), 0)
+		ensureError(t, err, ErrInvalidRequest{Err: errors.New(`graph node "a" depends on unknown node "missing"`)})
+	})
+}
@@ -0,0 +1,57 @@
+package gorun
+
+import (
+	"bytes"
+	"io"
+	"regexp"
+)
+
+// grepWriter writes to w only the lines written to it that match re,
+// counting every line written to it.
+type grepWriter struct {
+	re      *regexp.Regexp
+	w       io.Writer
+	partial []byte // the most recent line, when it lacks a newline
+	lines   int
+}
+
+func (gw *grepWriter) Write(p []byte) (int, error) {
+	n := len(p)
+	for len(p) > 0 {
+		i := bytes.IndexByte(p, '\n')
+		if i == -1 {
+			gw.partial = append(gw.partial, p...)
+			break
+		}
+		line := p[:i+1]
+		if len(gw.partial) > 0 {
+			line = append(gw.partial, line...)
+			gw.partial = gw.partial[:0]
+		}
+		if err := gw.line(line); err != nil {
+			return 0, err
+		}
+		p = p[i+1:]
+	}
+	return n, nil
+}
+
+// flush handles the final line written when it lacks a newline.
+func (gw *grepWriter) flush() error {
+	if len(gw.partial) == 0 {
+		return nil
+	}
+	line := gw.partial
+	gw.partial = nil
+	return gw.line(line)
+}
+
+// line writes line to w when it matches re, ignoring its newline.
+func (gw *grepWriter) line(line []byte) error {
+	gw.lines++
+	if !gw.re.Match(bytes.TrimSuffix(line, []byte{'\n'})) {
+		return nil
+	}
+	_, err := gw.w.Write(line)
+	return err
+}
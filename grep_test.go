@@ -0,0 +1,62 @@
+//go:build !windows
+// +build !windows
+
+package gorun
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+)
+
+func TestStdoutGrep(t *testing.T) {
+	t.Run("filters lines", func(t *testing.T) {
+		got, err := Run(context.Background(), &Request{
+			Path:       "/bin/sh",
+			Args:       []string{"-c", "echo one error; echo two; echo three error; printf 'four error'"},
+			StdoutGrep: regexp.MustCompile(`error$`),
+		})
+		ensureError(t, err, nil)
+		want := &Response{
+			Stderr: []byte{},
+			Stdout: []byte("one error\nthree error\nfour error"),
+		}
+		ensureResponsesMatch(t, got, want)
+		if got, want := got.StdoutBytesRead, int64(36); got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		if got, want := got.StdoutLinesRead, 4; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+
+	t.Run("conflicts with decompress", func(t *testing.T) {
+		_, err := Run(context.Background(), &Request{
+			Path:             "/usr/bin/true",
+			StdoutGrep:       regexp.MustCompile(`.`),
+			DecompressStdout: true,
+		})
+		ensureError(t, err, ErrInvalidRequest{Err: errors.New("cannot set both DecompressStdout and StdoutGrep")})
+	})
+}
+
+func TestGrepWriter(t *testing.T) {
+	t.Run("lines split across writes", func(t *testing.T) {
+		var buf bytes.Buffer
+		gw := &grepWriter{re: regexp.MustCompile(`^ab`), w: &buf}
+		for _, chunk := range []string{"a", "bc\nxa", "b\nabd", "\n\nab"} {
+			if _, err := gw.Write([]byte(chunk)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		ensureError(t, gw.flush(), nil)
+		if got, want := buf.String(), "abc\nabd\nab"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+		if got, want := gw.lines, 5; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+}
@@ -0,0 +1,65 @@
+package gorun
+
+import (
+	"context"
+	"sync"
+)
+
+// Group runs a collection of Requests concurrently, sharing a context
+// that is canceled as soon as any of them fails, so the remaining child
+// processes are terminated rather than left running. A Request fails
+// when its child process cannot be spawned, exits with a non-zero exit
+// code, or one not in its SuccessCodes when they are not empty, or
+// terminates due to receiving a signal.
+type Group struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	lock      sync.Mutex
+	responses []*Response
+	err       error
+}
+
+// NewGroup returns a Group whose shared context is derived from ctx.
+func NewGroup(ctx context.Context) *Group {
+	ctx, cancel := context.WithCancel(ctx)
+	return &Group{ctx: ctx, cancel: cancel}
+}
+
+// Run spawns the child process described by req in a new goroutine,
+// using the shared context of the Group.
+func (g *Group) Run(req *Request) {
+	g.lock.Lock()
+	index := len(g.responses)
+	g.responses = append(g.responses, nil)
+	g.lock.Unlock()
+
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+
+		resp, err := req.Run(g.ctx)
+		if err == nil {
+			err = req.exitError(resp)
+		}
+
+		g.lock.Lock()
+		g.responses[index] = resp
+		if err != nil && g.err == nil {
+			g.err = err
+			g.cancel()
+		}
+		g.lock.Unlock()
+	}()
+}
+
+// Wait blocks until every Request given to Run has finished, then
+// returns their Responses, in the order they were given to Run, along
+// with the error from the first Request that failed. A Response is nil
+// when its child process could not be spawned.
+func (g *Group) Wait() ([]*Response, error) {
+	g.wg.Wait()
+	g.cancel()
+	return g.responses, g.err
+}
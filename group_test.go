@@ -0,0 +1,52 @@
+//go:build !windows
+// +build !windows
+
+package gorun
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestGroup(t *testing.T) {
+	t.Run("all succeed", func(t *testing.T) {
+		g := NewGroup(context.Background())
+		g.Run(&Request{Path: "/bin/echo", Args: []string{"one"}})
+		g.Run(&Request{Path: "/bin/echo", Args: []string{"two"}})
+
+		responses, err := g.Wait()
+		ensureError(t, err, nil)
+		if got, want := len(responses), 2; got != want {
+			t.Fatalf("GOT: %v; WANT: %v", got, want)
+		}
+		for i, want := range []string{"one\n", "two\n"} {
+			if got := string(responses[i].Stdout); got != want {
+				t.Errorf("GOT: %q; WANT: %q", got, want)
+			}
+		}
+	})
+
+	t.Run("first failure cancels others", func(t *testing.T) {
+		g := NewGroup(context.Background())
+		g.Run(&Request{Path: "/bin/sleep", Args: []string{"5"}})
+		g.Run(&Request{Path: "/bin/sh", Args: []string{"-c", "sleep 0.05; exit 3"}})
+		g.Run(&Request{Path: "/bin/sleep", Args: []string{"5"}})
+
+		started := time.Now()
+		responses, err := g.Wait()
+		if elapsed := time.Since(started); elapsed > 2*time.Second {
+			t.Errorf("GOT: %v; WANT: < %v", elapsed, 2*time.Second)
+		}
+		ensureError(t, err, ErrExit{Code: 3})
+
+		if got, want := responses[1].Code, 3; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		for _, i := range []int{0, 2} {
+			if got, want := responses[i].Code, -1; got != want {
+				t.Errorf("GOT: %v; WANT: %v", got, want)
+			}
+		}
+	})
+}
@@ -0,0 +1,47 @@
+//go:build !windows
+// +build !windows
+
+package gorun
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"testing"
+)
+
+func TestHashStdout(t *testing.T) {
+	t.Run("digest and size", func(t *testing.T) {
+		const size = 1 << 20
+		got, err := Run(context.Background(), &Request{
+			Path:       "/bin/sh",
+			Args:       []string{"-c", "yes | head -c 1048576; echo err >&2"},
+			HashStdout: sha256.New,
+		})
+		ensureError(t, err, nil)
+		want := &Response{
+			Stderr: []byte("err\n"),
+		}
+		ensureResponsesMatch(t, got, want)
+		if got.Stdout != nil {
+			t.Errorf("GOT: %d bytes; WANT: %v", len(got.Stdout), nil)
+		}
+		if got, want := got.StdoutBytesRead, int64(size); got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		digest := sha256.Sum256(bytes.Repeat([]byte("y\n"), size/2))
+		if !bytes.Equal(got.StdoutHash, digest[:]) {
+			t.Errorf("GOT: %x; WANT: %x", got.StdoutHash, digest)
+		}
+	})
+
+	t.Run("conflicts with decompress", func(t *testing.T) {
+		_, err := Run(context.Background(), &Request{
+			Path:             "/usr/bin/true",
+			HashStdout:       sha256.New,
+			DecompressStdout: true,
+		})
+		ensureError(t, err, ErrInvalidRequest{Err: errors.New("cannot set HashStdout along with StdoutBuffer, StdoutGrep, or DecompressStdout")})
+	})
+}
@@ -0,0 +1,44 @@
+package gorun
+
+import "io"
+
+// headWriter forwards the first remaining bytes written to it to w, and
+// discards the rest, while reporting every write as successful so the
+// child process is neither blocked nor terminated.
+type headWriter struct {
+	w         io.Writer
+	remaining int64
+	dropped   bool
+}
+
+func (hw *headWriter) Write(p []byte) (int, error) {
+	if int64(len(p)) > hw.remaining {
+		hw.dropped = true
+		if hw.remaining == 0 {
+			return len(p), nil
+		}
+		if _, err := hw.w.Write(p[:hw.remaining]); err != nil {
+			return 0, err
+		}
+		hw.remaining = 0
+		return len(p), nil
+	}
+	hw.remaining -= int64(len(p))
+	return hw.w.Write(p)
+}
+
+// truncated returns true when any bytes were discarded. It returns
+// false when invoked on a nil headWriter.
+func (hw *headWriter) truncated() bool {
+	return hw != nil && hw.dropped
+}
+
+// headLimit returns the number of bytes of a stream to capture, which is
+// the smaller of head and max that is positive, or zero when neither is.
+func headLimit(head int, max int64) int64 {
+	limit := int64(head)
+	if max > 0 && (limit <= 0 || max < limit) {
+		limit = max
+	}
+	return limit
+}
@@ -0,0 +1,113 @@
+//go:build !windows
+// +build !windows
+
+package gorun
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestHeadBytes(t *testing.T) {
+	t.Run("truncated", func(t *testing.T) {
+		got, err := Run(context.Background(), &Request{
+			Path:            "/bin/sh",
+			Args:            []string{"-c", "seq 1 10000; seq 1 10000 >&2"},
+			StderrHeadBytes: 4,
+			StdoutHeadBytes: 6,
+		})
+		ensureError(t, err, nil)
+		want := &Response{
+			Stderr: []byte("1\n2\n"),
+			Stdout: []byte("1\n2\n3\n"),
+		}
+		ensureResponsesMatch(t, got, want)
+		if got, want := got.StdoutBytesRead, int64(48894); got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		if got, want := got.StderrBytesRead, int64(48894); got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		if !got.StdoutTruncated {
+			t.Errorf("GOT: %v; WANT: %v", got.StdoutTruncated, true)
+		}
+		if !got.StderrTruncated {
+			t.Errorf("GOT: %v; WANT: %v", got.StderrTruncated, true)
+		}
+	})
+	t.Run("not truncated", func(t *testing.T) {
+		got, err := Run(context.Background(), &Request{
+			Path:            "/bin/echo",
+			Args:            []string{"hello"},
+			StdoutHeadBytes: 6,
+		})
+		ensureError(t, err, nil)
+		if got, want := string(got.Stdout), "hello\n"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+		if got, want := got.StdoutBytesRead, int64(6); got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		if got.StdoutTruncated {
+			t.Errorf("GOT: %v; WANT: %v", got.StdoutTruncated, false)
+		}
+	})
+	t.Run("with spill", func(t *testing.T) {
+		_, err := Run(context.Background(), &Request{
+			Path:             "/bin/echo",
+			StdoutHeadBytes:  6,
+			SpillStdoutAfter: 1,
+		})
+		ensureError(t, err, ErrInvalidRequest{Err: errors.New("cannot set StdoutHeadBytes along with StdoutWriter, HashStdout, StdoutGrep, SpillStdoutAfter, or DecompressStdout")})
+	})
+}
+
+func TestMaxOutputBytes(t *testing.T) {
+	t.Run("truncated", func(t *testing.T) {
+		got, err := Run(context.Background(), &Request{
+			Path:           "/bin/sh",
+			Args:           []string{"-c", "seq 1 10000; seq 1 10000 >&2"},
+			MaxOutputBytes: 8,
+		})
+		ensureError(t, err, nil)
+		want := &Response{
+			Stderr: []byte("1\n2\n3\n4\n"),
+			Stdout: []byte("1\n2\n3\n4\n"),
+		}
+		ensureResponsesMatch(t, got, want)
+		if got, want := got.StdoutBytesRead, int64(48894); got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		if !got.StdoutTruncated {
+			t.Errorf("GOT: %v; WANT: %v", got.StdoutTruncated, true)
+		}
+		if !got.StderrTruncated {
+			t.Errorf("GOT: %v; WANT: %v", got.StderrTruncated, true)
+		}
+	})
+	t.Run("smaller head", func(t *testing.T) {
+		got, err := Run(context.Background(), &Request{
+			Path:            "/bin/sh",
+			Args:            []string{"-c", "seq 1 10000"},
+			MaxOutputBytes:  8,
+			StdoutHeadBytes: 4,
+		})
+		ensureError(t, err, nil)
+		if got, want := string(got.Stdout), "1\n2\n"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+	})
+	t.Run("within limit", func(t *testing.T) {
+		got, err := Run(context.Background(), &Request{
+			Path:           "/bin/echo",
+			Args:           []string{"hello"},
+			MaxOutputBytes: 8,
+		})
+		ensureError(t, err, nil)
+		ensureResponsesMatch(t, got, &Response{Stderr: []byte{}, Stdout: []byte("hello\n")})
+		if got.StdoutTruncated || got.StderrTruncated {
+			t.Errorf("GOT: %v, %v; WANT: false, false", got.StdoutTruncated, got.StderrTruncated)
+		}
+	})
+}
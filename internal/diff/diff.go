@@ -0,0 +1,68 @@
+// Package diff describes the differences between the lines of two
+// strings.
+package diff
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Lines returns a line oriented description of how to change want into
+// got, where each line of want that is absent from got is prefixed
+// with "-", each line of got that is absent from want is prefixed with
+// "+", and each line common to both is prefixed with a space.
+func Lines(want, got string) string {
+	a := strings.Split(want, "\n")
+	b := strings.Split(got, "\n")
+
+	// lcs[i][j] is the length of the longest common subsequence of
+	// a[i:] and b[j:].
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var sb strings.Builder
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			sb.WriteString(" " + a[i] + "\n")
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			sb.WriteString("-" + a[i] + "\n")
+			i++
+		default:
+			sb.WriteString("+" + b[j] + "\n")
+			j++
+		}
+	}
+	return sb.String()
+}
+
+// Unified returns the difference between want and got as a unified diff
+// with a single hunk holding every line, where wantName and gotName
+// label the two in its header.
+func Unified(wantName, gotName, want, got string) string {
+	return "--- " + wantName + "\n+++ " + gotName + "\n" +
+		"@@ -" + hunkRange(want) + " +" + hunkRange(got) + " @@\n" +
+		Lines(want, got)
+}
+
+// hunkRange returns the range of lines of s in a unified diff hunk
+// header.
+func hunkRange(s string) string {
+	return "1," + strconv.Itoa(strings.Count(s, "\n")+1)
+}
@@ -0,0 +1,26 @@
+package diff
+
+import "testing"
+
+func TestLines(t *testing.T) {
+	t.Run("same", func(t *testing.T) {
+		if got, want := Lines("a\nb", "a\nb"), " a\n b\n"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+	})
+	t.Run("changed", func(t *testing.T) {
+		got := Lines("a\nb\nc", "a\nB\nc\nd")
+		want := " a\n-b\n+B\n c\n+d\n"
+		if got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+	})
+}
+
+func TestUnified(t *testing.T) {
+	got := Unified("golden", "stdout", "a\nb", "a\nc\nd")
+	want := "--- golden\n+++ stdout\n@@ -1,2 +1,3 @@\n a\n-b\n+c\n+d\n"
+	if got != want {
+		t.Errorf("GOT: %q; WANT: %q", got, want)
+	}
+}
@@ -0,0 +1,42 @@
+package gorun
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// RunJSON executes a system command like RunVerboseChecked does, and
+// when the child process exits successfully, decodes its standard
+// output as JSON into v. It returns an ErrDecodeJSON error when the
+// standard output of the child process cannot be decoded into v.
+//
+// The returned Response is the same one Run would have returned, and
+// remains available even when the returned error is an ErrExit or an
+// ErrDecodeJSON.
+func (req *Request) RunJSON(ctx context.Context, v any) (*Response, error) {
+	resp, err := req.RunVerboseChecked(ctx)
+	if err != nil {
+		return resp, err
+	}
+	if err = json.Unmarshal(resp.Stdout, v); err != nil {
+		return resp, ErrDecodeJSON{Err: err}
+	}
+	return resp, nil
+}
+
+// ErrDecodeJSON is returned when the standard output of a child process
+// cannot be decoded as JSON.
+type ErrDecodeJSON struct {
+	Err error
+}
+
+func (e ErrDecodeJSON) Error() string {
+	return "cannot decode standard output as JSON: " + e.Err.Error()
+}
+
+func (e ErrDecodeJSON) Is(err error) bool {
+	_, ok := err.(ErrDecodeJSON)
+	return ok
+}
+
+func (e ErrDecodeJSON) Unwrap() error { return e.Err }
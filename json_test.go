@@ -0,0 +1,47 @@
+//go:build !windows
+// +build !windows
+
+package gorun
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestRunJSON(t *testing.T) {
+	t.Run("decodes", func(t *testing.T) {
+		var got struct {
+			Name  string `json:"name"`
+			Count int    `json:"count"`
+		}
+		_, err := (&Request{
+			Path: "/bin/echo",
+			Args: []string{`{"name":"gorun","count":3}`},
+		}).RunJSON(context.Background(), &got)
+		ensureError(t, err, nil)
+		if got.Name != "gorun" || got.Count != 3 {
+			t.Errorf("GOT: %+v; WANT: %+v", got, `{Name:gorun Count:3}`)
+		}
+	})
+	t.Run("invalid", func(t *testing.T) {
+		var got any
+		resp, err := (&Request{
+			Path: "/bin/echo",
+			Args: []string{"not json"},
+		}).RunJSON(context.Background(), &got)
+		if !errors.Is(err, ErrDecodeJSON{}) {
+			t.Errorf("GOT: %v; WANT: %v", err, ErrDecodeJSON{})
+		}
+		if got, want := string(resp.Stdout), "not json\n"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+	})
+	t.Run("exit", func(t *testing.T) {
+		var got any
+		_, err := (&Request{
+			Path: "/usr/bin/false",
+		}).RunJSON(context.Background(), &got)
+		ensureError(t, err, ErrExit{Code: 1})
+	})
+}
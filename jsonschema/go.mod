@@ -0,0 +1,10 @@
+module github.com/karrick/gorun/jsonschema
+
+go 1.21
+
+require (
+	github.com/karrick/gorun v0.0.0-20261015115613-ded287056610
+	github.com/santhosh-tekuri/jsonschema/v6 v6.0.3
+)
+
+require golang.org/x/text v0.14.0 // indirect
@@ -0,0 +1,110 @@
+// Package jsonschema validates the JSON output of child processes run
+// by gorun against a JSON Schema. It is a separate module so programs
+// that do not validate output need not depend on a JSON Schema
+// implementation.
+package jsonschema
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"github.com/karrick/gorun"
+	js "github.com/santhosh-tekuri/jsonschema/v6"
+)
+
+// schemaURL is the location at which a compiled schema is registered;
+// it is only used in the messages of errors.
+const schemaURL = "schema.json"
+
+// Schema is a compiled JSON Schema.
+type Schema struct {
+	schema *js.Schema
+}
+
+// Compile returns the Schema described by the JSON Schema document
+// schema, or an error when it is not a valid JSON Schema.
+func Compile(schema []byte) (*Schema, error) {
+	doc, err := js.UnmarshalJSON(bytes.NewReader(schema))
+	if err != nil {
+		return nil, err
+	}
+	c := js.NewCompiler()
+	if err = c.AddResource(schemaURL, doc); err != nil {
+		return nil, err
+	}
+	s, err := c.Compile(schemaURL)
+	if err != nil {
+		return nil, err
+	}
+	return &Schema{schema: s}, nil
+}
+
+// Validate returns an ErrInvalidOutput error listing each violation of
+// s by the JSON document b, or an error when b is not valid JSON.
+func (s *Schema) Validate(b []byte) error {
+	doc, err := js.UnmarshalJSON(bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	err = s.schema.Validate(doc)
+	var ve *js.ValidationError
+	if !errors.As(err, &ve) {
+		return err
+	}
+	var violations []string
+	for _, unit := range ve.BasicOutput().Errors {
+		if unit.Error == nil {
+			continue
+		}
+		location := unit.InstanceLocation
+		if location == "" {
+			location = "/"
+		}
+		violations = append(violations, location+": "+unit.Error.String())
+	}
+	return ErrInvalidOutput{Violations: violations}
+}
+
+// RunJSON executes req like gorun.Request.RunJSON does, but before
+// decoding the standard output of the child process into v, validates
+// it against schema. It returns an ErrInvalidOutput error listing the
+// violations when the output does not conform to schema, in which case
+// v is not modified.
+//
+// The returned Response is the same one gorun.Run would have returned,
+// and remains available even when the returned error is an
+// ErrInvalidOutput.
+func RunJSON(ctx context.Context, req *gorun.Request, schema *Schema, v any) (*gorun.Response, error) {
+	var raw json.RawMessage
+	resp, err := req.RunJSON(ctx, &raw)
+	if err != nil {
+		return resp, err
+	}
+	if err = schema.Validate(raw); err != nil {
+		return resp, err
+	}
+	if err = json.Unmarshal(raw, v); err != nil {
+		return resp, gorun.ErrDecodeJSON{Err: err}
+	}
+	return resp, nil
+}
+
+// ErrInvalidOutput is returned when the JSON output of a child process
+// does not conform to a schema.
+type ErrInvalidOutput struct {
+	// Violations describes each way the output does not conform to
+	// the schema, prefixed by the JSON Pointer of the offending value.
+	Violations []string
+}
+
+func (e ErrInvalidOutput) Error() string {
+	return "output does not conform to schema:\n\t" + strings.Join(e.Violations, "\n\t")
+}
+
+func (e ErrInvalidOutput) Is(err error) bool {
+	_, ok := err.(ErrInvalidOutput)
+	return ok
+}
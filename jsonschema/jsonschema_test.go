@@ -0,0 +1,89 @@
+//go:build !windows
+// +build !windows
+
+package jsonschema
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/karrick/gorun"
+)
+
+const testSchema = `{
+	"type": "object",
+	"properties": {
+		"name": {"type": "string"},
+		"count": {"type": "integer", "minimum": 0}
+	},
+	"required": ["name", "count"]
+}`
+
+func TestRunJSON(t *testing.T) {
+	schema, err := Compile([]byte(testSchema))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("conforming", func(t *testing.T) {
+		var got struct {
+			Name  string `json:"name"`
+			Count int    `json:"count"`
+		}
+		_, err := RunJSON(context.Background(), &gorun.Request{
+			Path: "/bin/echo",
+			Args: []string{`{"name":"gorun","count":3}`},
+		}, schema, &got)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Name != "gorun" || got.Count != 3 {
+			t.Errorf("GOT: %+v; WANT: %+v", got, `{Name:gorun Count:3}`)
+		}
+	})
+
+	t.Run("non-conforming", func(t *testing.T) {
+		var got map[string]any
+		resp, err := RunJSON(context.Background(), &gorun.Request{
+			Path: "/bin/echo",
+			Args: []string{`{"count":-1}`},
+		}, schema, &got)
+		var eio ErrInvalidOutput
+		if !errors.As(err, &eio) {
+			t.Fatalf("GOT: %v; WANT: %v", err, ErrInvalidOutput{})
+		}
+		if got, want := len(eio.Violations), 2; got != want {
+			t.Errorf("GOT: %v; WANT: %v (%q)", got, want, eio.Violations)
+		}
+		for _, want := range []string{"/: missing property 'name'", "/count: minimum"} {
+			if !strings.Contains(err.Error(), want) {
+				t.Errorf("GOT: %v; WANT: %v", err, want)
+			}
+		}
+		if got != nil {
+			t.Errorf("GOT: %v; WANT: %v", got, nil)
+		}
+		if resp == nil {
+			t.Errorf("GOT: %v; WANT: response", resp)
+		}
+	})
+
+	t.Run("not json", func(t *testing.T) {
+		var got any
+		_, err := RunJSON(context.Background(), &gorun.Request{
+			Path: "/bin/echo",
+			Args: []string{"not json"},
+		}, schema, &got)
+		if !errors.Is(err, gorun.ErrDecodeJSON{}) {
+			t.Errorf("GOT: %v; WANT: %v", err, gorun.ErrDecodeJSON{})
+		}
+	})
+}
+
+func TestCompile(t *testing.T) {
+	if _, err := Compile([]byte(`{"type": 42}`)); err == nil {
+		t.Errorf("GOT: %v; WANT: error", err)
+	}
+}
@@ -0,0 +1,59 @@
+package gorun
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"strconv"
+	"time"
+)
+
+// clockTicks is the number of clock ticks per second in which Linux
+// reports process times in /proc, known to user space as USER_HZ, which
+// is 100 on every architecture Go supports.
+const clockTicks = 100
+
+// processStartTime returns when the kernel created the process pid,
+// with a resolution of one clock tick, which must not yet have been
+// reaped.
+func processStartTime(pid int) (time.Time, error) {
+	stat, err := os.ReadFile("/proc/" + strconv.Itoa(pid) + "/stat")
+	if err != nil {
+		return time.Time{}, err
+	}
+	uptime, err := os.ReadFile("/proc/uptime")
+	if err != nil {
+		return time.Time{}, err
+	}
+	now := time.Now()
+
+	// The fields follow the parenthesized command name, which may
+	// itself contain parentheses, starting with the state, which is
+	// the third field. The start time is the twenty-second field.
+	i := bytes.LastIndexByte(stat, ')')
+	if i == -1 {
+		return time.Time{}, errors.New("cannot parse process status")
+	}
+	fields := bytes.Fields(stat[i+1:])
+	if len(fields) < 20 {
+		return time.Time{}, errors.New("cannot parse process status")
+	}
+	ticks, err := strconv.ParseInt(string(fields[19]), 10, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	// The start time is measured from boot, which is found by
+	// subtracting the time the system has been up from now.
+	fields = bytes.Fields(uptime)
+	if len(fields) == 0 {
+		return time.Time{}, errors.New("cannot parse uptime")
+	}
+	up, err := strconv.ParseFloat(string(fields[0]), 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+	boot := now.Add(-time.Duration(up * float64(time.Second)))
+
+	return boot.Add(time.Duration(ticks) * time.Second / clockTicks), nil
+}
@@ -0,0 +1,35 @@
+package gorun
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestKernelStartTime(t *testing.T) {
+	got, err := Run(context.Background(), &Request{
+		Path:                  "/bin/sleep",
+		Args:                  []string{"0.1"},
+		RecordKernelStartTime: true,
+	})
+	ensureError(t, err, nil)
+
+	// The kernel reports the start time in clock ticks, and the boot
+	// time it is measured from is derived from an uptime in clock
+	// ticks, so allow for two ticks of error each way.
+	const slop = 2 * time.Second / clockTicks
+	if got.KernelStartTime.Before(got.Started.Add(-slop)) || got.KernelStartTime.After(got.Started.Add(got.Duration)) {
+		t.Errorf("GOT: %v; WANT: between %v and %v", got.KernelStartTime, got.Started.Add(-slop), got.Started.Add(got.Duration))
+	}
+	if got.KernelDuration < 100*time.Millisecond-slop || got.KernelDuration > got.Duration+slop {
+		t.Errorf("GOT: %v; WANT: between %v and %v", got.KernelDuration, 100*time.Millisecond-slop, got.Duration+slop)
+	}
+}
+
+func TestKernelStartTimeNotRequested(t *testing.T) {
+	got, err := Run(context.Background(), &Request{Path: "/usr/bin/true"})
+	ensureError(t, err, nil)
+	if !got.KernelStartTime.IsZero() || got.KernelDuration != 0 {
+		t.Errorf("GOT: %v, %v; WANT: zero", got.KernelStartTime, got.KernelDuration)
+	}
+}
@@ -0,0 +1,13 @@
+//go:build !linux
+// +build !linux
+
+package gorun
+
+import (
+	"errors"
+	"time"
+)
+
+func processStartTime(_ int) (time.Time, error) {
+	return time.Time{}, errors.New("cannot find process start time on this platform")
+}
@@ -0,0 +1,92 @@
+package gorun
+
+import (
+	"bytes"
+	"errors"
+	"io"
+)
+
+// errOutputLimit is returned by limitWriter once its limit is exceeded.
+var errOutputLimit = errors.New("output limit exceeded")
+
+// limitWriter forwards at most limit bytes to w, and invokes exceed the
+// first time more than that are written to it. Once the limit is
+// exceeded, it returns errOutputLimit, which stops os/exec copying the
+// output and closes the pipe, so neither the child process nor any
+// process it spawned keeps Wait from returning by writing to it.
+type limitWriter struct {
+	w         io.Writer
+	remaining int64
+	exceed    func()
+	exceeded  bool
+}
+
+func (lw *limitWriter) Write(p []byte) (int, error) {
+	if lw.exceeded {
+		return 0, errOutputLimit
+	}
+	if int64(len(p)) <= lw.remaining {
+		lw.remaining -= int64(len(p))
+		return lw.w.Write(p)
+	}
+	lw.exceeded = true
+	lw.exceed()
+	n := int(lw.remaining)
+	if n > 0 {
+		if _, err := lw.w.Write(p[:n]); err != nil {
+			return 0, err
+		}
+		lw.remaining = 0
+	}
+	return n, errOutputLimit
+}
+
+// limited returns true when the limit was exceeded. It returns false
+// when invoked on a nil limitWriter.
+func (lw *limitWriter) limited() bool {
+	return lw != nil && lw.exceeded
+}
+
+// lineLimitWriter forwards at most limit lines to w, and invokes exceed
+// the first time the child process starts writing a line beyond them. A
+// final line that lacks a newline terminator counts as a line. Once the
+// limit is exceeded, further writes are discarded, so the child process
+// does not block writing before it exits.
+type lineLimitWriter struct {
+	w         io.Writer
+	remaining int
+	exceed    func()
+	exceeded  bool
+}
+
+func (lw *lineLimitWriter) Write(p []byte) (int, error) {
+	if lw.exceeded {
+		return len(p), nil
+	}
+	var n int // length of the prefix of p within the limit
+	for lw.remaining > 0 && n < len(p) {
+		i := bytes.IndexByte(p[n:], '\n')
+		if i == -1 {
+			n = len(p)
+			break
+		}
+		n += i + 1
+		lw.remaining--
+	}
+	if n < len(p) {
+		lw.exceeded = true
+		lw.exceed()
+	}
+	if n > 0 {
+		if _, err := lw.w.Write(p[:n]); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// limited returns true when the limit was exceeded. It returns false
+// when invoked on a nil lineLimitWriter.
+func (lw *lineLimitWriter) limited() bool {
+	return lw != nil && lw.exceeded
+}
@@ -0,0 +1,185 @@
+//go:build !windows
+// +build !windows
+
+package gorun
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestMaxStdoutBytes(t *testing.T) {
+	t.Run("writer", func(t *testing.T) {
+		var buf bytes.Buffer
+		started := time.Now()
+		got, err := Run(context.Background(), &Request{
+			Path:           "/usr/bin/yes",
+			StdoutWriter:   &buf,
+			MaxStdoutBytes: 10,
+		})
+		ensureError(t, err, nil)
+		if elapsed := time.Since(started); elapsed > 2*time.Second {
+			t.Errorf("GOT: %v; WANT: < %v", elapsed, 2*time.Second)
+		}
+		want := &Response{
+			Code:   -1,
+			Err:    ErrOutputTooLarge{Err: ErrSignal{Err: errors.New("signal: killed")}, Limit: 10, Stream: StreamStdout},
+			Stderr: []byte{},
+		}
+		ensureResponsesMatch(t, got, want)
+		if got.Stdout != nil {
+			t.Errorf("GOT: %q; WANT: %v", got.Stdout, nil)
+		}
+		if got, want := buf.String(), "y\ny\ny\ny\ny\n"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+	})
+
+	t.Run("buffered", func(t *testing.T) {
+		got, err := Run(context.Background(), &Request{
+			Path:           "/usr/bin/yes",
+			MaxStdoutBytes: 5,
+		})
+		ensureError(t, err, nil)
+		if !errors.Is(got.Err, ErrOutputTooLarge{}) || !errors.Is(got.Err, ErrSignal{}) {
+			t.Errorf("GOT: %v; WANT: %v", got.Err, ErrOutputTooLarge{})
+		}
+		if got, want := string(got.Stdout), "y\ny\ny"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+	})
+
+	t.Run("grandchild", func(t *testing.T) {
+		started := time.Now()
+		got, err := Run(context.Background(), &Request{
+			Path:           "/bin/sh",
+			Args:           []string{"-c", "yes; :"},
+			MaxStdoutBytes: 5,
+		})
+		ensureError(t, err, nil)
+		if elapsed := time.Since(started); elapsed > 3*time.Second {
+			t.Errorf("GOT: %v; WANT: < %v", elapsed, 3*time.Second)
+		}
+		if !errors.Is(got.Err, ErrOutputTooLarge{}) {
+			t.Errorf("GOT: %v; WANT: %v", got.Err, ErrOutputTooLarge{})
+		}
+		if got, want := string(got.Stdout), "y\ny\ny"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+	})
+
+	t.Run("within limit", func(t *testing.T) {
+		var buf bytes.Buffer
+		got, err := Run(context.Background(), &Request{
+			Path:           "/bin/echo",
+			Args:           []string{"hello"},
+			StdoutWriter:   &buf,
+			MaxStdoutBytes: 6,
+		})
+		ensureError(t, err, nil)
+		ensureResponsesMatch(t, got, &Response{Stderr: []byte{}})
+		if got, want := buf.String(), "hello\n"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+	})
+
+	t.Run("writer conflicts with buffer", func(t *testing.T) {
+		_, err := Run(context.Background(), &Request{
+			Path:         "/usr/bin/true",
+			StdoutWriter: new(bytes.Buffer),
+			StdoutBuffer: new(bytes.Buffer),
+		})
+		ensureError(t, err, ErrInvalidRequest{Err: errors.New("cannot set StdoutWriter along with StdoutBuffer, StdoutGrep, DecompressStdout, or HashStdout")})
+	})
+}
+
+func TestMaxStdoutLines(t *testing.T) {
+	t.Run("unbounded", func(t *testing.T) {
+		started := time.Now()
+		got, err := Run(context.Background(), &Request{
+			Path:           "/usr/bin/yes",
+			MaxStdoutLines: 3,
+		})
+		ensureError(t, err, nil)
+		if elapsed := time.Since(started); elapsed > 2*time.Second {
+			t.Errorf("GOT: %v; WANT: < %v", elapsed, 2*time.Second)
+		}
+		want := &Response{
+			Code:            -1,
+			Err:             ErrSignal{Err: errors.New("signal: terminated")},
+			Stderr:          []byte{},
+			Stdout:          []byte("y\ny\ny\n"),
+			StdoutTruncated: true,
+		}
+		ensureResponsesMatch(t, got, want)
+		if !got.StdoutTruncated {
+			t.Errorf("GOT: %v; WANT: %v", got.StdoutTruncated, true)
+		}
+	})
+
+	t.Run("ignores terminate", func(t *testing.T) {
+		got, err := Run(context.Background(), &Request{
+			Path:           "/bin/sh",
+			Args:           []string{"-c", "trap '' TERM; while :; do echo line; done"},
+			MaxStdoutLines: 2,
+			GracePeriod:    100 * time.Millisecond,
+		})
+		ensureError(t, err, nil)
+		ensureError(t, got.Err, ErrSignal{Err: errors.New("signal: killed")})
+		if got, want := string(got.Stdout), "line\nline\n"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+		if !got.StdoutTruncated {
+			t.Errorf("GOT: %v; WANT: %v", got.StdoutTruncated, true)
+		}
+	})
+
+	t.Run("final partial line", func(t *testing.T) {
+		got, err := Run(context.Background(), &Request{
+			Path:           "/usr/bin/printf",
+			Args:           []string{"one\ntwo"},
+			MaxStdoutLines: 2,
+		})
+		ensureError(t, err, nil)
+		ensureResponsesMatch(t, got, &Response{Stderr: []byte{}, Stdout: []byte("one\ntwo")})
+		if got.StdoutTruncated {
+			t.Errorf("GOT: %v; WANT: %v", got.StdoutTruncated, false)
+		}
+	})
+
+	t.Run("partial line beyond limit", func(t *testing.T) {
+		got, err := Run(context.Background(), &Request{
+			Path:           "/usr/bin/printf",
+			Args:           []string{"one\ntwo"},
+			MaxStdoutLines: 1,
+		})
+		ensureError(t, err, nil)
+		if got, want := string(got.Stdout), "one\n"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+		if !got.StdoutTruncated {
+			t.Errorf("GOT: %v; WANT: %v", got.StdoutTruncated, true)
+		}
+	})
+
+	t.Run("stderr", func(t *testing.T) {
+		got, err := Run(context.Background(), &Request{
+			Path:           "/bin/sh",
+			Args:           []string{"-c", "while :; do echo line >&2; done"},
+			MaxStderrLines: 2,
+		})
+		ensureError(t, err, nil)
+		if got, want := string(got.Stderr), "line\nline\n"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+		if !got.StderrTruncated {
+			t.Errorf("GOT: %v; WANT: %v", got.StderrTruncated, true)
+		}
+		if got.StdoutTruncated {
+			t.Errorf("GOT: %v; WANT: %v", got.StdoutTruncated, false)
+		}
+	})
+}
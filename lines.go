@@ -0,0 +1,129 @@
+package gorun
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"strings"
+	"time"
+)
+
+// StdoutLines returns the lines the child process wrote to its
+// standard output, without their newline terminators. A final line
+// that lacks a newline terminator is included.
+func (r *Response) StdoutLines() []string {
+	return splitLines(r.Stdout)
+}
+
+// StderrLines returns the lines the child process wrote to its
+// standard error, without their newline terminators. A final line that
+// lacks a newline terminator is included.
+func (r *Response) StderrLines() []string {
+	return splitLines(r.Stderr)
+}
+
+// StdoutLineCount returns the number of lines the child process wrote
+// to its standard output, including a final line that lacks a newline
+// terminator, without allocating. It always equals len(StdoutLines()).
+func (r *Response) StdoutLineCount() int {
+	return countLines(r.Stdout)
+}
+
+// StderrLineCount returns the number of lines the child process wrote
+// to its standard error, including a final line that lacks a newline
+// terminator, without allocating. It always equals len(StderrLines()).
+func (r *Response) StderrLineCount() int {
+	return countLines(r.Stderr)
+}
+
+// StdoutScanner returns a bufio.Scanner that splits what the child
+// process wrote to its standard output into lines, without their
+// newline terminators. Its buffer may grow to hold the whole output, so
+// it never fails with bufio.ErrTooLong, however long a line is. The
+// caller may change its split function before scanning.
+func (r *Response) StdoutScanner() *bufio.Scanner {
+	return newScanner(r.Stdout)
+}
+
+// StderrScanner returns a bufio.Scanner that splits what the child
+// process wrote to its standard error into lines, like StdoutScanner.
+func (r *Response) StderrScanner() *bufio.Scanner {
+	return newScanner(r.Stderr)
+}
+
+func newScanner(buf []byte) *bufio.Scanner {
+	scanner := bufio.NewScanner(bytes.NewReader(buf))
+	// A token can be no longer than all of buf, and the scanner needs
+	// room for one more byte to know it reached the end of buf.
+	if max := len(buf) + 1; max > bufio.MaxScanTokenSize {
+		scanner.Buffer(nil, max)
+	}
+	return scanner
+}
+
+func countLines(buf []byte) int {
+	n := bytes.Count(buf, []byte{'\n'})
+	if len(buf) > 0 && buf[len(buf)-1] != '\n' {
+		n++
+	}
+	return n
+}
+
+func splitLines(buf []byte) []string {
+	if len(buf) == 0 {
+		return nil
+	}
+	lines := strings.Split(string(buf), "\n")
+	if buf[len(buf)-1] == '\n' {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// lineTimer records the elapsed time since it was started when each
+// line written to it is completed. All its methods are no-ops when
+// invoked on a nil lineTimer.
+type lineTimer struct {
+	started time.Time
+	last    time.Duration // when the most recent bytes were written
+	partial bool          // true when the most recent line lacks a newline
+	times   []time.Duration
+}
+
+func (lt *lineTimer) start() {
+	if lt != nil {
+		lt.started = time.Now()
+	}
+}
+
+// writer returns lt as an io.Writer, or nil when lt is nil.
+func (lt *lineTimer) writer() io.Writer {
+	if lt == nil {
+		return nil
+	}
+	return lt
+}
+
+func (lt *lineTimer) Write(p []byte) (int, error) {
+	lt.last = time.Since(lt.started)
+	n := bytes.Count(p, []byte{'\n'})
+	for i := 0; i < n; i++ {
+		lt.times = append(lt.times, lt.last)
+	}
+	if len(p) > 0 {
+		lt.partial = p[len(p)-1] != '\n'
+	}
+	return len(p), nil
+}
+
+// result returns the recorded times, including one for a final line
+// that lacks a newline, when there is one.
+func (lt *lineTimer) result() []time.Duration {
+	if lt == nil {
+		return nil
+	}
+	if lt.partial {
+		return append(lt.times, lt.last)
+	}
+	return lt.times
+}
@@ -0,0 +1,130 @@
+//go:build !windows
+// +build !windows
+
+package gorun
+
+import (
+	"context"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLines(t *testing.T) {
+	t.Run("empty", func(t *testing.T) {
+		if got := (&Response{}).StdoutLines(); got != nil {
+			t.Errorf("GOT: %q; WANT: %v", got, nil)
+		}
+	})
+	t.Run("trailing newline", func(t *testing.T) {
+		got := (&Response{Stdout: []byte("one\n\nthree\n")}).StdoutLines()
+		want := []string{"one", "", "three"}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+	})
+	t.Run("no trailing newline", func(t *testing.T) {
+		got := (&Response{Stderr: []byte("one\ntwo")}).StderrLines()
+		want := []string{"one", "two"}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+	})
+}
+
+func TestScanner(t *testing.T) {
+	const long = 1 << 20
+	got, err := Run(context.Background(), &Request{
+		Path: "/bin/sh",
+		Args: []string{"-c", "echo first; head -c " + strconv.Itoa(long) + " /dev/zero | tr '\\0' x; echo; printf last; echo error >&2"},
+	})
+	ensureError(t, err, nil)
+
+	var lines []string
+	scanner := got.StdoutScanner()
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	ensureError(t, scanner.Err(), nil)
+	want := []string{"first", strings.Repeat("x", long), "last"}
+	if !reflect.DeepEqual(lines, want) {
+		t.Errorf("GOT: %d lines; WANT: %d lines", len(lines), len(want))
+	}
+
+	scanner = got.StderrScanner()
+	if !scanner.Scan() || scanner.Text() != "error" {
+		t.Errorf("GOT: %q; WANT: %q", scanner.Text(), "error")
+	}
+	if scanner.Scan() {
+		t.Errorf("GOT: %q; WANT: end of output", scanner.Text())
+	}
+
+	scanner = (&Response{}).StdoutScanner()
+	if scanner.Scan() {
+		t.Errorf("GOT: %q; WANT: end of output", scanner.Text())
+	}
+}
+
+func TestLineCount(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		output string
+		want   int
+	}{
+		{name: "empty", output: "", want: 0},
+		{name: "trailing newline", output: "one\n\nthree\n", want: 3},
+		{name: "no trailing newline", output: "one\ntwo", want: 2},
+		{name: "only newline", output: "\n", want: 1},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			resp := &Response{Stderr: []byte(tc.output), Stdout: []byte(tc.output)}
+			if got := resp.StdoutLineCount(); got != tc.want {
+				t.Errorf("GOT: %v; WANT: %v", got, tc.want)
+			}
+			if got := resp.StderrLineCount(); got != tc.want {
+				t.Errorf("GOT: %v; WANT: %v", got, tc.want)
+			}
+			if got := len(resp.StdoutLines()); got != tc.want {
+				t.Errorf("GOT: %v; WANT: %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestTimeStdoutLines(t *testing.T) {
+	t.Run("disabled", func(t *testing.T) {
+		got, err := Run(context.Background(), &Request{
+			Path: "/bin/echo",
+		})
+		ensureError(t, err, nil)
+		if got.StdoutLineTimes != nil {
+			t.Errorf("GOT: %v; WANT: %v", got.StdoutLineTimes, nil)
+		}
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		got, err := Run(context.Background(), &Request{
+			Path:            "/bin/sh",
+			Args:            []string{"-c", "echo one; sleep 0.2; echo two; sleep 0.2; printf three"},
+			TimeStdoutLines: true,
+		})
+		ensureError(t, err, nil)
+
+		lines := got.StdoutLines()
+		if g, w := len(got.StdoutLineTimes), len(lines); g != w {
+			t.Fatalf("GOT: %v; WANT: %v", g, w)
+		}
+		if g, w := len(lines), 3; g != w {
+			t.Fatalf("GOT: %v; WANT: %v", g, w)
+		}
+		// Each line is timed when it is read rather than when it is
+		// written, so allow for the previous one being read late.
+		for i := 1; i < len(lines); i++ {
+			if g, w := got.StdoutLineTimes[i]-got.StdoutLineTimes[i-1], 100*time.Millisecond; g < w {
+				t.Errorf("line %d: GOT: %v; WANT: >= %v", i, g, w)
+			}
+		}
+	})
+}
@@ -0,0 +1,57 @@
+package gorun
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"sync"
+)
+
+// RunLogged executes a system command like Run does, and also appends
+// everything the child process writes to its standard output and
+// standard error to the file at logPath, creating it when necessary.
+// The combined output is also returned in Response.Combined, in
+// addition to the separate streams in Response.Stdout and
+// Response.Stderr.
+//
+// When the log file cannot be opened, it returns a nil Response and the
+// error, without spawning the child process. When the log file cannot
+// be closed after an otherwise successful invocation, it returns the
+// Response along with the error.
+func (req *Request) RunLogged(ctx context.Context, logPath string) (*Response, error) {
+	fh, err := os.OpenFile(logPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	var combined bytes.Buffer
+	lw := &lockedWriter{w: io.MultiWriter(&combined, fh)}
+
+	r := *req
+	r.StderrTee = tee(lw, req.StderrTee)
+	r.StdoutTee = tee(lw, req.StdoutTee)
+
+	resp, err := r.Run(ctx)
+	if cerr := fh.Close(); err == nil {
+		err = cerr
+	}
+	if resp != nil {
+		resp.Combined = combined.Bytes()
+	}
+	return resp, err
+}
+
+// lockedWriter serializes writes to w, so multiple streams may be
+// written to it concurrently without interleaving within a write.
+type lockedWriter struct {
+	lock sync.Mutex
+	w    io.Writer
+}
+
+func (lw *lockedWriter) Write(p []byte) (int, error) {
+	lw.lock.Lock()
+	n, err := lw.w.Write(p)
+	lw.lock.Unlock()
+	return n, err
+}
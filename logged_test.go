@@ -0,0 +1,53 @@
+//go:build !windows
+// +build !windows
+
+package gorun
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRunLogged(t *testing.T) {
+	t.Run("cannot open log", func(t *testing.T) {
+		got, err := (&Request{
+			Path: "/usr/bin/true",
+		}).RunLogged(context.Background(), filepath.Join(t.TempDir(), "no-such-dir", "log"))
+		if !os.IsNotExist(err) {
+			t.Errorf("GOT: %v; WANT: %v", err, os.ErrNotExist)
+		}
+		if got != nil {
+			t.Errorf("GOT: %v; WANT: %v", got, nil)
+		}
+	})
+
+	t.Run("appends", func(t *testing.T) {
+		logPath := filepath.Join(t.TempDir(), "log")
+		ensureError(t, os.WriteFile(logPath, []byte("previous\n"), 0o644), nil)
+
+		got, err := (&Request{
+			Path: "/bin/sh",
+			Args: []string{"-c", "echo one; sleep 0.05; echo two >&2; sleep 0.05; echo three; exit 2"},
+		}).RunLogged(context.Background(), logPath)
+		ensureError(t, err, nil)
+
+		want := &Response{
+			Code:   2,
+			Stderr: []byte("two\n"),
+			Stdout: []byte("one\nthree\n"),
+		}
+		ensureResponsesMatch(t, got, want)
+
+		if g, w := string(got.Combined), "one\ntwo\nthree\n"; g != w {
+			t.Errorf("GOT: %q; WANT: %q", g, w)
+		}
+
+		buf, err := os.ReadFile(logPath)
+		ensureError(t, err, nil)
+		if g, w := string(buf), "previous\n"+string(got.Combined); g != w {
+			t.Errorf("GOT: %q; WANT: %q", g, w)
+		}
+	})
+}
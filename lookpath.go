@@ -0,0 +1,46 @@
+package gorun
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// lookPath returns the path of the named program. When ResolvePath is
+// true and name is a bare name, it searches the directories named by the
+// PATH variable of env, the environment of the child process, and
+// otherwise defers to exec.LookPath, which uses the PATH of this
+// process.
+func (req *Request) lookPath(name string, env []string) (string, error) {
+	if !req.ResolvePath || filepath.Base(name) != name {
+		return exec.LookPath(name)
+	}
+	for _, dir := range filepath.SplitList(envPath(env)) {
+		if dir == "" {
+			dir = "."
+		}
+		// Joining with a separator, rather than by filepath.Join,
+		// causes exec.LookPath to check only that file.
+		if path, err := exec.LookPath(dir + string(filepath.Separator) + name); err == nil {
+			return path, nil
+		}
+	}
+	return "", &exec.Error{Name: name, Err: exec.ErrNotFound}
+}
+
+// envPath returns the value of PATH in env, or in the environment of
+// this process when env is nil, as os/exec does when spawning a child
+// process.
+func envPath(env []string) string {
+	if env == nil {
+		return os.Getenv("PATH")
+	}
+	var path string
+	for _, kv := range env {
+		if v, ok := strings.CutPrefix(kv, "PATH="); ok {
+			path = v // last value wins, as it does for os/exec
+		}
+	}
+	return path
+}
@@ -0,0 +1,51 @@
+//go:build !windows
+// +build !windows
+
+package gorun
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestResolvePath(t *testing.T) {
+	t.Run("process path", func(t *testing.T) {
+		got, err := Run(context.Background(), &Request{
+			Path:        "echo",
+			Args:        []string{"hello"},
+			ResolvePath: true,
+		})
+		ensureError(t, err, nil)
+		ensureResponsesMatch(t, got, &Response{Stderr: []byte{}, Stdout: []byte("hello\n")})
+	})
+	t.Run("request path", func(t *testing.T) {
+		dir := t.TempDir()
+		script := filepath.Join(dir, "greet")
+		ensureError(t, os.WriteFile(script, []byte("#!/bin/sh\necho hello from greet\n"), 0o755), nil)
+		got, err := Run(context.Background(), &Request{
+			Path:        "greet",
+			Env:         []string{"PATH=/no-such-dir:" + dir},
+			ResolvePath: true,
+		})
+		ensureError(t, err, nil)
+		ensureResponsesMatch(t, got, &Response{Stderr: []byte{}, Stdout: []byte("hello from greet\n")})
+	})
+	t.Run("not in request path", func(t *testing.T) {
+		_, err := Run(context.Background(), &Request{
+			Path:        "echo",
+			Env:         []string{"PATH=/no-such-dir"},
+			ResolvePath: true,
+		})
+		ensureError(t, err, ErrSpawn{Err: errors.New(`exec: "echo": executable file not found in $PATH`)})
+	})
+	t.Run("not found", func(t *testing.T) {
+		_, err := Run(context.Background(), &Request{
+			Path:        "no-such-program",
+			ResolvePath: true,
+		})
+		ensureError(t, err, ErrSpawn{Err: errors.New(`exec: "no-such-program": executable file not found in $PATH`)})
+	})
+}
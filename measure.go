@@ -0,0 +1,93 @@
+package gorun
+
+import (
+	"context"
+	"os/exec"
+	"time"
+)
+
+// Metrics represents the timing and resource usage of a child process
+// that was spawned by Measure.
+type Metrics struct {
+	// Duration is the elapsed wall clock time from when the child
+	// process was spawned until it exited.
+	Duration time.Duration
+
+	// SpawnLatency is the elapsed wall clock time required to spawn
+	// the child process.
+	SpawnLatency time.Duration
+
+	// UserTime is the CPU time the child process spent executing in
+	// user mode.
+	UserTime time.Duration
+
+	// SystemTime is the CPU time the child process spent executing in
+	// kernel mode.
+	SystemTime time.Duration
+
+	// MaxRSS is the maximum resident set size of the child process in
+	// bytes. It will be 0 on platforms that do not report it.
+	MaxRSS int64
+
+	// Code will be the exit code that the child process returned when
+	// it exited. When its value is -1, the child process was spawned
+	// but terminated in response to receiving a signal.
+	Code int
+}
+
+// Measure executes a system command like Run does, except the output
+// of the child process is connected to the null device rather than
+// copied through this process, and it returns only timing and resource
+// usage metrics for the child process. Because no output is read,
+// fields of Request that examine or transform the output have no
+// effect, and setting RequireOutput is invalid. Retry, RetryOnSignal
+// and RetryOnEmptyOutput are ignored, so each call spawns a single
+// child process.
+//
+// When this cannot spawn the requested program, it returns a zero
+// Metrics and an ErrSpawn error. When it cannot collect the exit status
+// of the child process, it returns a zero Metrics and an ErrWait
+// error. When the child process exited due to receiving a signal, it
+// returns the populated Metrics with a -1 Code, along with an ErrSignal
+// error, which is wrapped in an ErrHardTimeout error when the
+// HardTimeout elapsed, or an ErrTimeout or ErrCanceled error when the
+// signal was sent because the context is done. When the child process
+// exits with a code not listed in a non-empty SuccessCodes, it returns
+// the populated Metrics and an ErrUnexpectedExit error. When the
+// context is done during the StartDelay, it returns a zero Metrics and
+// an ErrCanceled error. When req is misconfigured, it returns a zero
+// Metrics and an ErrInvalidRequest error.
+func (req *Request) Measure(ctx context.Context) (Metrics, error) {
+	return req.measure(ctx, nil)
+}
+
+// measure runs req like Measure does, starting the child process with
+// start when it is not nil.
+func (req *Request) measure(ctx context.Context, start func(context.Context, *exec.Cmd) error) (Metrics, error) {
+	if start == nil {
+		start = func(_ context.Context, cmd *exec.Cmd) error { return cmd.Start() }
+	}
+
+	var spawnLatency time.Duration
+	resp, err := req.run(ctx, runOptions{
+		start: func(ctx context.Context, cmd *exec.Cmd) error {
+			started := time.Now()
+			err := start(ctx, cmd)
+			spawnLatency = time.Since(started)
+			return err
+		},
+		discard: true,
+	})
+	if err != nil {
+		return Metrics{}, err
+	}
+
+	return Metrics{
+		Duration:     resp.Duration,
+		SpawnLatency: spawnLatency,
+		UserTime:     resp.UserTime,
+		SystemTime:   resp.SystemTime,
+		MaxRSS:       resp.MaxRSS,
+		Code:         resp.Code,
+	}, resp.Err
+}
@@ -0,0 +1,129 @@
+//go:build !windows
+// +build !windows
+
+package gorun
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"testing"
+	"time"
+)
+
+func TestMeasure(t *testing.T) {
+	t.Run("cannot spawn", func(t *testing.T) {
+		_, err := (&Request{Path: "/no-such-path"}).Measure(context.Background())
+		ensureError(t, err, ErrSpawn{Err: errors.New("fork/exec /no-such-path: no such file or directory")})
+	})
+
+	t.Run("populated", func(t *testing.T) {
+		m, err := (&Request{
+			Path: "/bin/sh",
+			Args: []string{"-c", "i=0; while [ $i -lt 20000 ]; do i=$((i+1)); done; sleep 0.1; exit 3"},
+		}).Measure(context.Background())
+		ensureError(t, err, nil)
+
+		if got, want := m.Code, 3; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		if got, want := m.Duration, 100*time.Millisecond; got < want {
+			t.Errorf("GOT: %v; WANT: >= %v", got, want)
+		}
+		if m.SpawnLatency <= 0 || m.SpawnLatency > m.Duration {
+			t.Errorf("GOT: %v; WANT: between 0 and %v", m.SpawnLatency, m.Duration)
+		}
+		if m.UserTime+m.SystemTime <= 0 {
+			t.Errorf("GOT: %v; WANT: > 0", m.UserTime+m.SystemTime)
+		}
+		if m.MaxRSS <= 0 {
+			t.Errorf("GOT: %v; WANT: > 0", m.MaxRSS)
+		}
+	})
+
+	t.Run("signal", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+		m, err := (&Request{
+			Path: "/bin/sleep",
+			Args: []string{"1"},
+		}).Measure(ctx)
+		ensureError(t, err, ErrTimeout{Err: ErrSignal{Err: errors.New("signal: killed")}})
+		if got, want := m.Code, -1; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+
+	t.Run("hard timeout", func(t *testing.T) {
+		m, err := (&Request{
+			Path:        "/bin/sleep",
+			Args:        []string{"5"},
+			HardTimeout: 100 * time.Millisecond,
+		}).Measure(context.Background())
+		ensureError(t, err, ErrHardTimeout{Err: ErrSignal{Err: errors.New("signal: killed")}})
+		if got, want := m.Code, -1; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		if m.Duration >= 5*time.Second {
+			t.Errorf("GOT: %v; WANT: < %v", m.Duration, 5*time.Second)
+		}
+	})
+
+	t.Run("success codes", func(t *testing.T) {
+		m, err := (&Request{
+			Path:         "/bin/sh",
+			Args:         []string{"-c", "exit 3"},
+			SuccessCodes: []int{0, 1},
+		}).Measure(context.Background())
+		ensureError(t, err, ErrUnexpectedExit{Code: 3})
+		if got, want := m.Code, 3; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+
+	t.Run("pty", func(t *testing.T) {
+		if !canUsePTY {
+			t.Skip("pseudo-terminals are not supported on this platform")
+		}
+		m, err := (&Request{
+			Path: "/bin/sh",
+			Args: []string{"-c", "test -t 1"},
+			PTY:  true,
+		}).Measure(context.Background())
+		ensureError(t, err, nil)
+		if got, want := m.Code, 0; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+
+	t.Run("require output", func(t *testing.T) {
+		_, err := (&Request{Path: "/usr/bin/true", RequireOutput: true}).Measure(context.Background())
+		ensureError(t, err, ErrInvalidRequest{Err: errors.New("cannot set RequireOutput when discarding output")})
+	})
+
+	t.Run("runner exec func", func(t *testing.T) {
+		var invoked int
+		r := &Runner{
+			ExecFunc: func(ctx context.Context, cmd *exec.Cmd) error {
+				invoked++
+				cmd.Args = append([]string{"/usr/bin/env", "GORUN=sandboxed"}, cmd.Args...)
+				cmd.Path = cmd.Args[0]
+				return cmd.Start()
+			},
+		}
+		m, err := r.Measure(context.Background(), &Request{
+			Path: "/bin/sh",
+			Args: []string{"-c", `test "$GORUN" = sandboxed`},
+		})
+		ensureError(t, err, nil)
+		if got, want := m.Code, 0; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+
+		_, err = r.Benchmark(context.Background(), &Request{Path: "/usr/bin/true"}, 1, 2)
+		ensureError(t, err, nil)
+		if got, want := invoked, 4; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+}
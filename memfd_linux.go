@@ -0,0 +1,27 @@
+//go:build linux && (amd64 || arm64)
+// +build linux
+// +build amd64 arm64
+
+package gorun
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// mfdCloexec is MFD_CLOEXEC, which the syscall package does not define.
+const mfdCloexec = 1
+
+// memfd returns a new anonymous memory backed file.
+func memfd(name string) (*os.File, error) {
+	p, err := syscall.BytePtrFromString(name)
+	if err != nil {
+		return nil, err
+	}
+	fd, _, errno := syscall.Syscall(sysMemfdCreate, uintptr(unsafe.Pointer(p)), mfdCloexec, 0)
+	if errno != 0 {
+		return nil, errno
+	}
+	return os.NewFile(fd, "memfd:"+name), nil
+}
@@ -0,0 +1,5 @@
+package gorun
+
+// sysMemfdCreate is the number of the memfd_create system call, which
+// the syscall package does not define for this architecture.
+const sysMemfdCreate = 319
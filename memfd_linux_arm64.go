@@ -0,0 +1,6 @@
+package gorun
+
+import "syscall"
+
+// sysMemfdCreate is the number of the memfd_create system call.
+const sysMemfdCreate = syscall.SYS_MEMFD_CREATE
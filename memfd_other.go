@@ -0,0 +1,13 @@
+//go:build !linux || !(amd64 || arm64)
+// +build !linux !amd64,!arm64
+
+package gorun
+
+import (
+	"errors"
+	"os"
+)
+
+func memfd(_ string) (*os.File, error) {
+	return nil, errors.New("cannot create memory backed files on this platform")
+}
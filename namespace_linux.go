@@ -0,0 +1,19 @@
+package gorun
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// canUseNewPIDNamespace is true when this platform supports
+// Request.NewPIDNamespace.
+const canUseNewPIDNamespace = true
+
+// useNewPIDNamespace configures cmd to spawn its process in a new PID
+// namespace.
+func useNewPIDNamespace(cmd *exec.Cmd) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = new(syscall.SysProcAttr)
+	}
+	cmd.SysProcAttr.Cloneflags |= syscall.CLONE_NEWPID
+}
@@ -0,0 +1,25 @@
+package gorun
+
+import (
+	"context"
+	"os"
+	"testing"
+)
+
+func TestNewPIDNamespace(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("creating a PID namespace requires CAP_SYS_ADMIN")
+	}
+
+	got, err := Run(context.Background(), &Request{
+		Path:            "/bin/sh",
+		Args:            []string{"-c", "echo $$"},
+		NewPIDNamespace: true,
+	})
+	ensureError(t, err, nil)
+	want := &Response{
+		Stderr: []byte{},
+		Stdout: []byte("1\n"),
+	}
+	ensureResponsesMatch(t, got, want)
+}
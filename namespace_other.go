@@ -0,0 +1,12 @@
+//go:build !linux
+// +build !linux
+
+package gorun
+
+import "os/exec"
+
+// canUseNewPIDNamespace is true when this platform supports
+// Request.NewPIDNamespace.
+const canUseNewPIDNamespace = false
+
+func useNewPIDNamespace(_ *exec.Cmd) {}
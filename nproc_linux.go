@@ -0,0 +1,96 @@
+package gorun
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"runtime"
+	"syscall"
+	"unsafe"
+)
+
+// canLimitChildProcesses is true when this platform supports
+// Request.MaxChildProcesses.
+const canLimitChildProcesses = true
+
+// rlimitNproc is RLIMIT_NPROC, which the syscall package does not
+// define for Linux.
+const rlimitNproc = 6
+
+// rlimit64 is the struct rlimit64 argument of the prlimit64 system call,
+// whose fields are 64 bits wide on every architecture, unlike those of
+// syscall.Rlimit.
+type rlimit64 struct {
+	Cur, Max uint64
+}
+
+// limitChildProcessesStart returns a function that starts cmd like start
+// does, except its process has both its soft and hard RLIMIT_NPROC set
+// to max before its program executes its first instruction. The process
+// is started traced, so it stops once it executes the program, and is
+// released once its limit is set. When the limit cannot be set, the
+// returned function kills the process, reaps it, and returns the error.
+func limitChildProcessesStart(max int, start func(context.Context, *exec.Cmd) error) func(context.Context, *exec.Cmd) error {
+	return func(ctx context.Context, cmd *exec.Cmd) error {
+		if cmd.SysProcAttr == nil {
+			cmd.SysProcAttr = new(syscall.SysProcAttr)
+		}
+		cmd.SysProcAttr.Ptrace = true
+
+		// Only the thread that started a traced process may wait for
+		// it to stop and release it.
+		runtime.LockOSThread()
+		defer runtime.UnlockOSThread()
+
+		if err := start(ctx, cmd); err != nil {
+			return err
+		}
+		pid := cmd.Process.Pid
+		err := waitTraceStop(pid)
+		if err == nil {
+			err = prlimit(pid, rlimitNproc, uint64(max))
+			if derr := syscall.PtraceDetach(pid); err == nil {
+				err = os.NewSyscallError("ptrace", derr)
+			}
+		}
+		if err != nil {
+			_ = cmd.Process.Kill()
+			_ = cmd.Wait()
+			return err
+		}
+		return nil
+	}
+}
+
+// waitTraceStop waits for the traced process pid to stop after it
+// executes its program. It returns an error when the process exits
+// instead, such as when it is killed before then, in which case its exit
+// status is no longer available to Wait.
+func waitTraceStop(pid int) error {
+	var status syscall.WaitStatus
+	for {
+		_, err := syscall.Wait4(pid, &status, syscall.WALL, nil)
+		if err == syscall.EINTR {
+			continue
+		}
+		if err != nil {
+			return os.NewSyscallError("wait4", err)
+		}
+		break
+	}
+	if !status.Stopped() {
+		return os.NewSyscallError("wait4", syscall.ESRCH)
+	}
+	return nil
+}
+
+// prlimit sets both the soft and hard limit of resource of the process
+// pid to limit.
+func prlimit(pid, resource int, limit uint64) error {
+	rlim := rlimit64{Cur: limit, Max: limit}
+	_, _, errno := syscall.RawSyscall6(syscall.SYS_PRLIMIT64, uintptr(pid), uintptr(resource), uintptr(unsafe.Pointer(&rlim)), 0, 0, 0)
+	if errno != 0 {
+		return os.NewSyscallError("prlimit", errno)
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package gorun
+
+import (
+	"context"
+	"errors"
+	"math"
+	"os"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+func TestMaxChildProcesses(t *testing.T) {
+	t.Run("limit set before exec", func(t *testing.T) {
+		got, err := Run(context.Background(), &Request{
+			Path:              "cat",
+			Args:              []string{"/proc/self/limits"},
+			MaxChildProcesses: 3,
+		})
+		ensureError(t, err, nil)
+		if got.Code != 0 {
+			t.Fatalf("GOT: %v; WANT: %v", got.Code, 0)
+		}
+		var line string
+		for _, l := range strings.Split(string(got.Stdout), "\n") {
+			if strings.HasPrefix(l, "Max processes") {
+				line = l
+			}
+		}
+		if g, w := strings.Fields(line), []string{"Max", "processes", "3", "3", "processes"}; strings.Join(g, " ") != strings.Join(w, " ") {
+			t.Errorf("GOT: %q; WANT: %q", g, w)
+		}
+	})
+
+	t.Run("missing program", func(t *testing.T) {
+		_, err := Run(context.Background(), &Request{
+			Path:              "/does/not/exist",
+			MaxChildProcesses: 3,
+		})
+		ensureError(t, err, ErrSpawn{Err: errors.New("fork/exec /does/not/exist: no such file or directory")})
+	})
+
+	t.Run("limit not permitted", func(t *testing.T) {
+		if os.Geteuid() == 0 {
+			t.Skip("privileged users may raise their hard limit")
+		}
+		var limit syscall.Rlimit
+		if err := syscall.Getrlimit(rlimitNproc, &limit); err != nil {
+			t.Fatal(err)
+		}
+		if limit.Max >= math.MaxInt32 {
+			t.Skip("hard limit cannot be exceeded")
+		}
+		_, err := Run(context.Background(), &Request{
+			Path:              "/usr/bin/true",
+			MaxChildProcesses: int(limit.Max) + 1,
+		})
+		ensureError(t, err, ErrSpawn{Err: errors.New("prlimit: operation not permitted")})
+	})
+
+	t.Run("fork refused", func(t *testing.T) {
+		if os.Geteuid() == 0 {
+			t.Skip("kernel does not enforce RLIMIT_NPROC for privileged users")
+		}
+		got, err := Run(context.Background(), &Request{
+			Path:              "/bin/sh",
+			Args:              []string{"-c", "/usr/bin/true && echo forked"},
+			MaxChildProcesses: 1,
+		})
+		ensureError(t, err, nil)
+		if g, w := string(got.Stdout), ""; g != w {
+			t.Errorf("GOT: %q; WANT: %q", g, w)
+		}
+		if got.Code == 0 {
+			t.Errorf("GOT: %v; WANT: non-zero", got.Code)
+		}
+	})
+
+	t.Run("this process unaffected", func(t *testing.T) {
+		got, err := Run(context.Background(), &Request{
+			Path: "cat",
+			Args: []string{"/proc/self/limits"},
+		})
+		ensureError(t, err, nil)
+		if strings.Contains(string(got.Stdout), "Max processes             3") {
+			t.Errorf("GOT: %q; WANT: limit not inherited", got.Stdout)
+		}
+	})
+}
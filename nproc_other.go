@@ -0,0 +1,17 @@
+//go:build !linux
+// +build !linux
+
+package gorun
+
+import (
+	"context"
+	"os/exec"
+)
+
+// canLimitChildProcesses is true when this platform supports
+// Request.MaxChildProcesses.
+const canLimitChildProcesses = false
+
+func limitChildProcessesStart(_ int, start func(context.Context, *exec.Cmd) error) func(context.Context, *exec.Cmd) error {
+	return start
+}
@@ -0,0 +1,99 @@
+package gorun
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// canDetectOOMKill is true when this platform supports
+// Request.DetectOOMKill.
+const canDetectOOMKill = true
+
+// oomKills are counts of the processes the kernel killed for lack of
+// memory, taken when a child process starts, so that they may be
+// compared with the counts after it exits. A count is -1 when it could
+// not be read, and the system count is only read when the cgroup count
+// cannot be.
+type oomKills struct {
+	system int64  // across the entire system, from /proc/vmstat
+	cgroup int64  // within the memory cgroup of the child process
+	events string // the file holding the count for the memory cgroup
+}
+
+// countOOMKills returns the count of processes the kernel has killed
+// for lack of memory within the memory cgroup of the process pid, which
+// must not yet have been reaped, or across the entire system when that
+// cannot be read.
+func countOOMKills(pid int) oomKills {
+	kills := oomKills{system: -1, cgroup: -1}
+	if kills.events = memoryEventsFile(pid); kills.events != "" {
+		kills.cgroup = readOOMKills(kills.events)
+	}
+	if kills.cgroup < 0 {
+		kills.system = readOOMKills("/proc/vmstat")
+	}
+	return kills
+}
+
+// increased returns true when the count of the memory cgroup increased
+// since kills was taken, or, only when that count could not be read,
+// when the count across the entire system increased.
+func (kills oomKills) increased() bool {
+	if kills.cgroup >= 0 {
+		return readOOMKills(kills.events) > kills.cgroup
+	}
+	return kills.system >= 0 && readOOMKills("/proc/vmstat") > kills.system
+}
+
+// memoryEventsFile returns the file that counts the processes killed
+// for lack of memory within the memory cgroup of the process pid, or
+// the empty string when it cannot be found. With cgroup version 1 it is
+// memory.oom_control in the memory hierarchy, and with version 2 it is
+// memory.events in the unified hierarchy.
+func memoryEventsFile(pid int) string {
+	b, err := os.ReadFile("/proc/" + strconv.Itoa(pid) + "/cgroup")
+	if err != nil {
+		return ""
+	}
+	var unified string
+	for _, line := range strings.Split(string(b), "\n") {
+		// Each line is hierarchy-ID:controller-list:cgroup-path.
+		fields := strings.SplitN(line, ":", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		if fields[0] == "0" && fields[1] == "" {
+			unified = fields[2]
+			continue
+		}
+		for _, controller := range strings.Split(fields[1], ",") {
+			if controller == "memory" {
+				return filepath.Join("/sys/fs/cgroup/memory", fields[2], "memory.oom_control")
+			}
+		}
+	}
+	if unified == "" {
+		return ""
+	}
+	return filepath.Join("/sys/fs/cgroup", unified, "memory.events")
+}
+
+// readOOMKills returns the value of the oom_kill line of the file name,
+// or -1 when it cannot be read.
+func readOOMKills(name string) int64 {
+	b, err := os.ReadFile(name)
+	if err != nil {
+		return -1
+	}
+	for _, line := range bytes.Split(b, []byte{'\n'}) {
+		if value, found := bytes.CutPrefix(line, []byte("oom_kill ")); found {
+			if n, err := strconv.ParseInt(string(value), 10, 64); err == nil {
+				return n
+			}
+		}
+	}
+	return -1
+}
@@ -0,0 +1,127 @@
+package gorun
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// newMemoryCgroup creates a memory cgroup limited to limit bytes, below
+// the memory cgroup of this process, and returns its directory, or
+// skips the test when it cannot.
+func newMemoryCgroup(tb testing.TB, limit int) string {
+	tb.Helper()
+	events := memoryEventsFile(os.Getpid())
+	if events == "" {
+		tb.Skip("cannot find memory cgroup")
+	}
+	dir := filepath.Join(filepath.Dir(events), "gorun-oom-"+strconv.Itoa(os.Getpid()))
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		tb.Skipf("cannot create memory cgroup: %v", err)
+	}
+	tb.Cleanup(func() {
+		// The cgroup cannot be removed until its processes exit.
+		for i := 0; i < 50 && os.Remove(dir) != nil; i++ {
+			time.Sleep(10 * time.Millisecond)
+		}
+	})
+
+	// Forbid swapping, so the limit is reached promptly. Version 1
+	// limits memory and swap combined, and version 2 limits swap alone.
+	files := []string{"memory.limit_in_bytes", "memory.memsw.limit_in_bytes"}
+	values := []string{strconv.Itoa(limit), strconv.Itoa(limit)}
+	if filepath.Base(events) == "memory.events" {
+		files = []string{"memory.max", "memory.swap.max"}
+		values = []string{strconv.Itoa(limit), "0"}
+	}
+	if err := os.WriteFile(filepath.Join(dir, files[0]), []byte(values[0]), 0o644); err != nil {
+		tb.Skipf("cannot limit memory cgroup: %v", err)
+	}
+	// Without swap accounting the file does not exist.
+	_ = os.WriteFile(filepath.Join(dir, files[1]), []byte(values[1]), 0o644)
+	return dir
+}
+
+func TestOOMKilled(t *testing.T) {
+	t.Run("killed for lack of memory", func(t *testing.T) {
+		dir := newMemoryCgroup(t, 32<<20)
+		// The count is taken from the memory cgroup the child process
+		// starts in, so move it there before Run takes the count, and
+		// have it wait until it has been moved before it allocates.
+		r := &Runner{
+			ExecFunc: func(_ context.Context, cmd *exec.Cmd) error {
+				if err := cmd.Start(); err != nil {
+					return err
+				}
+				return os.WriteFile(filepath.Join(dir, "cgroup.procs"), []byte(strconv.Itoa(cmd.Process.Pid)), 0o644)
+			},
+		}
+		got, err := r.Run(context.Background(), &Request{
+			Path:          "/bin/sh",
+			Args:          []string{"-c", `until grep -q "$1" /proc/$$/cgroup; do sleep 0.01; done; x=$(head -c 1073741824 /dev/zero | tr '\0' x)`, "sh", filepath.Base(dir)},
+			DetectOOMKill: true,
+		})
+		ensureError(t, err, nil)
+		ensureError(t, got.Err, ErrSignal{Err: errors.New("signal: killed")})
+		if !got.OOMKilled {
+			t.Errorf("GOT: %v; WANT: %v", got.OOMKilled, true)
+		}
+	})
+
+	t.Run("not requested", func(t *testing.T) {
+		dir := newMemoryCgroup(t, 32<<20)
+		got, err := Run(context.Background(), &Request{
+			Path: "/bin/sh",
+			Args: []string{"-c", `echo $$ > "$1/cgroup.procs" && x=$(head -c 1073741824 /dev/zero | tr '\0' x)`, "sh", dir},
+		})
+		ensureError(t, err, nil)
+		ensureError(t, got.Err, ErrSignal{Err: errors.New("signal: killed")})
+		if got.OOMKilled {
+			t.Errorf("GOT: %v; WANT: %v", got.OOMKilled, false)
+		}
+	})
+}
+
+func TestOOMKillsIncreased(t *testing.T) {
+	// The counts are read from a file this test controls, rather than
+	// the memory cgroup of this process, where another process may be
+	// killed for lack of memory at any time.
+	events := filepath.Join(t.TempDir(), "memory.events")
+	write := func(count int) {
+		t.Helper()
+		if err := os.WriteFile(events, []byte("oom 0\noom_kill "+strconv.Itoa(count)+"\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Run("cgroup unchanged", func(t *testing.T) {
+		write(3)
+		// A system count of zero is below that of any host that
+		// ever killed a process for lack of memory, so it would
+		// report an increase were it consulted.
+		kills := oomKills{system: 0, cgroup: 3, events: events}
+		if kills.increased() {
+			t.Errorf("GOT: %v; WANT: %v", true, false)
+		}
+	})
+
+	t.Run("cgroup increased", func(t *testing.T) {
+		write(4)
+		kills := oomKills{system: -1, cgroup: 3, events: events}
+		if !kills.increased() {
+			t.Errorf("GOT: %v; WANT: %v", false, true)
+		}
+	})
+
+	t.Run("cgroup unreadable", func(t *testing.T) {
+		kills := oomKills{system: -1, cgroup: 3, events: filepath.Join(t.TempDir(), "missing")}
+		if kills.increased() {
+			t.Errorf("GOT: %v; WANT: %v", true, false)
+		}
+	})
+}
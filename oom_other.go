@@ -0,0 +1,14 @@
+//go:build !linux
+// +build !linux
+
+package gorun
+
+// canDetectOOMKill is true when this platform supports
+// Request.DetectOOMKill.
+const canDetectOOMKill = false
+
+type oomKills struct{}
+
+func countOOMKills(_ int) oomKills { return oomKills{} }
+
+func (oomKills) increased() bool { return false }
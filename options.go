@@ -0,0 +1,48 @@
+package gorun
+
+import (
+	"io"
+	"time"
+)
+
+// Option configures a Request built by New.
+type Option func(*Request)
+
+// New returns a Request to run the program at path, configured by
+// opts, which are applied in order. It is an alternative to a Request
+// literal when most fields keep their zero values. Options that set the
+// same field override each other, so the last one wins, except for
+// WithEnv, whose assignments accumulate.
+func New(path string, opts ...Option) *Request {
+	req := &Request{Path: path}
+	for _, opt := range opts {
+		opt(req)
+	}
+	return req
+}
+
+// WithArgs sets the Args of a Request.
+func WithArgs(args ...string) Option {
+	return func(req *Request) { req.Args = args }
+}
+
+// WithDir sets the Dir of a Request.
+func WithDir(dir string) Option {
+	return func(req *Request) { req.Dir = dir }
+}
+
+// WithEnv appends environment variable assignments, each in the form
+// "key=value", to the Env of a Request.
+func WithEnv(env ...string) Option {
+	return func(req *Request) { req.Env = append(req.Env, env...) }
+}
+
+// WithStdin sets the Stdin of a Request.
+func WithStdin(r io.Reader) Option {
+	return func(req *Request) { req.Stdin = r }
+}
+
+// WithTimeout sets the Timeout of a Request.
+func WithTimeout(d time.Duration) Option {
+	return func(req *Request) { req.Timeout = d }
+}
@@ -0,0 +1,61 @@
+//go:build !windows
+// +build !windows
+
+package gorun
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNew(t *testing.T) {
+	t.Run("equivalent to literal", func(t *testing.T) {
+		dir := t.TempDir()
+		script := `echo "$A $B $(pwd)"; cat`
+		literal := &Request{
+			Path:    "/bin/sh",
+			Args:    []string{"-c", script},
+			Env:     []string{"A=1", "B=2"},
+			Dir:     dir,
+			Stdin:   strings.NewReader("input\n"),
+			Timeout: time.Minute,
+		}
+		built := New("/bin/sh",
+			WithTimeout(time.Minute),
+			WithEnv("A=1"),
+			WithDir(dir),
+			WithArgs("-c", script),
+			WithStdin(strings.NewReader("input\n")),
+			WithEnv("B=2"),
+		)
+		if !reflect.DeepEqual(built, literal) {
+			t.Errorf("GOT: %+v; WANT: %+v", built, literal)
+		}
+
+		want, err := Run(context.Background(), literal)
+		ensureError(t, err, nil)
+		got, err := Run(context.Background(), built)
+		ensureError(t, err, nil)
+		ensureResponsesMatch(t, got, want)
+		if g, w := string(got.Stdout), "1 2 "+dir+"\ninput\n"; g != w {
+			t.Errorf("GOT: %q; WANT: %q", g, w)
+		}
+	})
+
+	t.Run("override", func(t *testing.T) {
+		got := New("/bin/echo", WithArgs("first"), WithDir("/tmp"), WithArgs("second"), WithDir("/"))
+		want := &Request{Path: "/bin/echo", Args: []string{"second"}, Dir: "/"}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("GOT: %+v; WANT: %+v", got, want)
+		}
+	})
+
+	t.Run("no options", func(t *testing.T) {
+		if got, want := New("/usr/bin/true"), (&Request{Path: "/usr/bin/true"}); !reflect.DeepEqual(got, want) {
+			t.Errorf("GOT: %+v; WANT: %+v", got, want)
+		}
+	})
+}
@@ -0,0 +1,132 @@
+package gorun
+
+import (
+	"context"
+	"errors"
+	"strconv"
+)
+
+// Outcome describes how a child process finished, so callers may switch
+// on a single value rather than combining checks of Response.Code and
+// Response.Err.
+type Outcome int
+
+const (
+	// OutcomeSuccess means the child process exited with a zero exit
+	// code.
+	OutcomeSuccess Outcome = iota
+
+	// OutcomeNonZero means the child process exited on its own with a
+	// non-zero exit code.
+	OutcomeNonZero
+
+	// OutcomeSignaled means the child process terminated due to
+	// receiving a signal not sent in response to a timeout or
+	// cancellation.
+	OutcomeSignaled
+
+	// OutcomeTimeout means the child process was terminated because
+	// the deadline of the context expired, its HardTimeout elapsed,
+	// or its StdinIdleTimeout elapsed.
+	OutcomeTimeout
+
+	// OutcomeCanceled means the child process was terminated because
+	// the context was canceled.
+	OutcomeCanceled
+
+	// OutcomeNoOutput means the child process exited with a zero exit
+	// code, but did not write the output required by
+	// Request.RequireOutput.
+	OutcomeNoOutput
+
+	// OutcomeMissingFiles means the child process exited with a zero
+	// exit code, but did not create all of Request.ExpectFiles.
+	OutcomeMissingFiles
+
+	// OutcomeUnexpectedExit means the child process exited on its own
+	// with an exit code not listed in Request.SuccessCodes.
+	OutcomeUnexpectedExit
+
+	// OutcomeSinkAborted means the sink given to RunSink returned an
+	// error, so the child process was terminated.
+	OutcomeSinkAborted
+
+	// OutcomeOutputTooLarge means the child process was terminated
+	// because it wrote more than Request.MaxStdoutBytes to its
+	// standard output.
+	OutcomeOutputTooLarge
+
+	// OutcomeInvalidOutput means the child process exited on its own,
+	// but its standard output could not be decoded, decompressed, or
+	// transformed as requested by Request.DecodeBase64Stdout,
+	// Request.DecompressStdout, or Request.StdoutTransform, or
+	// Request.ExitCodeFile held an invalid exit code.
+	OutcomeInvalidOutput
+)
+
+func (o Outcome) String() string {
+	switch o {
+	case OutcomeSuccess:
+		return "success"
+	case OutcomeNonZero:
+		return "non-zero exit"
+	case OutcomeSignaled:
+		return "signaled"
+	case OutcomeTimeout:
+		return "timeout"
+	case OutcomeCanceled:
+		return "canceled"
+	case OutcomeNoOutput:
+		return "no output"
+	case OutcomeMissingFiles:
+		return "missing files"
+	case OutcomeUnexpectedExit:
+		return "unexpected exit"
+	case OutcomeSinkAborted:
+		return "sink aborted"
+	case OutcomeOutputTooLarge:
+		return "output too large"
+	case OutcomeInvalidOutput:
+		return "invalid output"
+	default:
+		return "Outcome(" + strconv.Itoa(int(o)) + ")"
+	}
+}
+
+// outcome returns the Outcome of a child process that finished with the
+// specified exit code and error, given the error of the context used to
+// run it.
+func outcome(code int, err, ctxErr error) Outcome {
+	switch {
+	// These wrap or replace the error that reports how the child
+	// process was terminated, which is only their consequence.
+	case errors.Is(err, ErrSinkAborted{}):
+		return OutcomeSinkAborted
+	case errors.Is(err, ErrOutputTooLarge{}):
+		return OutcomeOutputTooLarge
+	case errors.Is(err, ErrHardTimeout{}), errors.Is(err, ErrStdinIdleTimeout{}), errors.Is(err, ErrTimeout{}):
+		return OutcomeTimeout
+	case errors.Is(err, ErrCanceled{}):
+		return OutcomeCanceled
+	case errors.Is(err, ErrSignal{}):
+		switch ctxErr {
+		case context.DeadlineExceeded:
+			return OutcomeTimeout
+		case context.Canceled:
+			return OutcomeCanceled
+		}
+		return OutcomeSignaled
+	case errors.Is(err, ErrUnexpectedExit{}):
+		return OutcomeUnexpectedExit
+	case errors.Is(err, ErrDecodeBase64{}), errors.Is(err, ErrDecompress{}), errors.Is(err, ErrTransform{}), errors.Is(err, ErrInvalidExitCode{}):
+		return OutcomeInvalidOutput
+	case code != 0:
+		return OutcomeNonZero
+	case errors.Is(err, ErrNoOutput{}):
+		return OutcomeNoOutput
+	case errors.Is(err, ErrMissingOutputFiles{}):
+		return OutcomeMissingFiles
+	default:
+		return OutcomeSuccess
+	}
+}
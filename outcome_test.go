@@ -0,0 +1,160 @@
+//go:build !windows
+// +build !windows
+
+package gorun
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestOutcome(t *testing.T) {
+	run := func(tb testing.TB, ctx context.Context, req *Request) Outcome {
+		tb.Helper()
+		resp, err := Run(ctx, req)
+		ensureError(tb, err, nil)
+		return resp.Outcome
+	}
+
+	t.Run("success", func(t *testing.T) {
+		if got, want := run(t, context.Background(), &Request{Path: "/usr/bin/true"}), OutcomeSuccess; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+	t.Run("non-zero", func(t *testing.T) {
+		if got, want := run(t, context.Background(), &Request{Path: "/usr/bin/false"}), OutcomeNonZero; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+	t.Run("signaled", func(t *testing.T) {
+		got := run(t, context.Background(), &Request{
+			Path: "/bin/sh",
+			Args: []string{"-c", "kill -9 $$"},
+		})
+		if want := OutcomeSignaled; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+	t.Run("timeout", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		got := run(t, ctx, &Request{Path: "/bin/sleep", Args: []string{"1"}})
+		if want := OutcomeTimeout; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+	t.Run("hard timeout", func(t *testing.T) {
+		got := run(t, context.Background(), &Request{
+			Path:        "/bin/sleep",
+			Args:        []string{"1"},
+			HardTimeout: 50 * time.Millisecond,
+		})
+		if want := OutcomeTimeout; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+	t.Run("canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(50*time.Millisecond, cancel)
+		got := run(t, ctx, &Request{Path: "/bin/sleep", Args: []string{"1"}})
+		if want := OutcomeCanceled; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+	t.Run("no output", func(t *testing.T) {
+		got := run(t, context.Background(), &Request{Path: "/usr/bin/true", RequireOutput: true})
+		if want := OutcomeNoOutput; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+	t.Run("missing files", func(t *testing.T) {
+		got := run(t, context.Background(), &Request{
+			Path:        "/usr/bin/true",
+			Dir:         t.TempDir(),
+			ExpectFiles: []string{"out.txt"},
+		})
+		if want := OutcomeMissingFiles; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+	t.Run("unexpected exit", func(t *testing.T) {
+		got := run(t, context.Background(), &Request{Path: "/usr/bin/true", SuccessCodes: []int{1}})
+		if want := OutcomeUnexpectedExit; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+	t.Run("sink aborted", func(t *testing.T) {
+		resp, err := (&Request{
+			Path: "/bin/sh",
+			Args: []string{"-c", "echo attempt; exec sleep 5"},
+		}).RunSink(context.Background(), func([]byte, int) error { return errors.New("enough") })
+		ensureError(t, err, nil)
+		if got, want := resp.Outcome, OutcomeSinkAborted; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+	t.Run("output too large", func(t *testing.T) {
+		got := run(t, context.Background(), &Request{
+			Path:           "/bin/sh",
+			Args:           []string{"-c", "echo 0123456789; exec sleep 5"},
+			MaxStdoutBytes: 4,
+		})
+		if want := OutcomeOutputTooLarge; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+	t.Run("invalid base64", func(t *testing.T) {
+		got := run(t, context.Background(), &Request{
+			Path:               "/bin/echo",
+			Args:               []string{"not base64!"},
+			DecodeBase64Stdout: true,
+		})
+		if want := OutcomeInvalidOutput; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+	t.Run("invalid gzip", func(t *testing.T) {
+		got := run(t, context.Background(), &Request{
+			Path:             "/usr/bin/printf",
+			Args:             []string{`\037\213truncated`},
+			DecompressStdout: true,
+		})
+		if want := OutcomeInvalidOutput; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+	t.Run("transform failed", func(t *testing.T) {
+		got := run(t, context.Background(), &Request{
+			Path:            "/bin/echo",
+			StdoutTransform: func([]byte) ([]byte, error) { return nil, errors.New("cannot transform") },
+		})
+		if want := OutcomeInvalidOutput; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+	t.Run("invalid exit code", func(t *testing.T) {
+		dir := t.TempDir()
+		got := run(t, context.Background(), &Request{
+			Path:         "/bin/sh",
+			Args:         []string{"-c", "echo 300 > code.txt"},
+			Dir:          dir,
+			ExitCodeFile: "code.txt",
+		})
+		if want := OutcomeInvalidOutput; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+	t.Run("string", func(t *testing.T) {
+		if got, want := OutcomeTimeout.String(), "timeout"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+		if got, want := OutcomeInvalidOutput.String(), "invalid output"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+		if got, want := Outcome(42).String(), "Outcome(42)"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+	})
+}
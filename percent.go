@@ -0,0 +1,106 @@
+package gorun
+
+import (
+	"bytes"
+	"io"
+	"regexp"
+	"strconv"
+	"sync"
+)
+
+// defaultPercentPattern matches a progress percentage when
+// PercentPattern is nil.
+var defaultPercentPattern = regexp.MustCompile(`(\d+)%`)
+
+// percentParser parses progress percentages from the lines written to
+// any of its streams, and reports each one that differs from the one
+// before it.
+type percentParser struct {
+	re     *regexp.Regexp
+	report func(int)
+
+	lock     sync.Mutex
+	last     int
+	reported bool
+	partial  [3][]byte // the most recent line of each stream, when incomplete
+}
+
+// newPercentParser returns a percentParser that reports percentages
+// matched by re, or by defaultPercentPattern when re is nil, to report.
+// It returns nil when report is nil.
+func newPercentParser(re *regexp.Regexp, report func(int)) *percentParser {
+	if report == nil {
+		return nil
+	}
+	if re == nil {
+		re = defaultPercentPattern
+	}
+	return &percentParser{re: re, report: report}
+}
+
+// stream returns an io.Writer that parses the lines written to it as
+// having been read from the specified stream, or nil when pp is nil.
+func (pp *percentParser) stream(stream int) io.Writer {
+	if pp == nil {
+		return nil
+	}
+	return percentWriter{pp: pp, stream: stream}
+}
+
+type percentWriter struct {
+	pp     *percentParser
+	stream int
+}
+
+// Write parses each line in p, where a carriage return ends a line as
+// well as a newline, because programs that show progress commonly
+// rewrite the same line of a terminal by beginning each update with a
+// carriage return. Such programs also commonly wait for the next update
+// before ending the line, so the incomplete line is parsed as well.
+func (pw percentWriter) Write(p []byte) (int, error) {
+	pw.pp.lock.Lock()
+	defer pw.pp.lock.Unlock()
+
+	n := len(p)
+	partial := &pw.pp.partial[pw.stream]
+	for len(p) > 0 {
+		i := bytes.IndexAny(p, "\r\n")
+		if i == -1 {
+			*partial = append(*partial, p...)
+			break
+		}
+		line := p[:i]
+		if len(*partial) > 0 {
+			line = append(*partial, line...)
+			*partial = (*partial)[:0]
+		}
+		pw.pp.line(line)
+		p = p[i+1:]
+	}
+	if len(*partial) > 0 {
+		pw.pp.line(*partial)
+	}
+	return n, nil
+}
+
+// line reports the last percentage in line, when it has one that
+// differs from the one previously reported. It uses the first
+// subexpression of the pattern when it has one, and otherwise the
+// entire match. The caller must hold the lock.
+func (pp *percentParser) line(line []byte) {
+	matches := pp.re.FindAllSubmatch(line, -1)
+	if len(matches) == 0 {
+		return
+	}
+	match := matches[len(matches)-1]
+	text := match[0]
+	if len(match) > 1 {
+		text = match[1]
+	}
+	percent, err := strconv.Atoi(string(text))
+	if err != nil || (pp.reported && percent == pp.last) {
+		return
+	}
+	pp.last, pp.reported = percent, true
+	pp.report(percent)
+}
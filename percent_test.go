@@ -0,0 +1,85 @@
+//go:build !windows
+// +build !windows
+
+package gorun
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"regexp"
+	"sort"
+	"testing"
+)
+
+func TestOnPercent(t *testing.T) {
+	t.Run("carriage return updates", func(t *testing.T) {
+		var got []int
+		resp, err := Run(context.Background(), &Request{
+			Path: "/bin/sh",
+			Args: []string{"-c", `
+				echo "starting 0%" >&2
+				for p in 10 25 25 60; do printf '\rdownloading %3d%% of 5%% files' $p >&2; sleep 0.01; done
+				printf '\r100%%' >&2`},
+			OnPercent: func(percent int) { got = append(got, percent) },
+		})
+		ensureError(t, err, nil)
+		ensureError(t, resp.Err, nil)
+		// Only the last percentage on each line is reported, and only
+		// when it changes.
+		if want := []int{0, 5, 100}; !reflect.DeepEqual(got, want) {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+
+	t.Run("increasing", func(t *testing.T) {
+		var got []int
+		_, err := Run(context.Background(), &Request{
+			Path:      "/bin/sh",
+			Args:      []string{"-c", `for p in 0 10 10 25 60 99; do printf '\r[%3d%%]' $p >&2; sleep 0.01; done; echo ' done 100%'`},
+			OnPercent: func(percent int) { got = append(got, percent) },
+		})
+		ensureError(t, err, nil)
+		if want := []int{0, 10, 25, 60, 99, 100}; !reflect.DeepEqual(got, want) {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+
+	t.Run("both streams", func(t *testing.T) {
+		var got []int
+		_, err := Run(context.Background(), &Request{
+			Path:      "/bin/sh",
+			Args:      []string{"-c", "echo 10%; echo 20% >&2"},
+			OnPercent: func(percent int) { got = append(got, percent) },
+		})
+		ensureError(t, err, nil)
+		// The streams are copied concurrently, so their lines may be
+		// parsed in either order.
+		sort.Ints(got)
+		if want := []int{10, 20}; !reflect.DeepEqual(got, want) {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+
+	t.Run("pattern", func(t *testing.T) {
+		var got []int
+		_, err := Run(context.Background(), &Request{
+			Path:           "/usr/bin/printf",
+			Args:           []string{"step 1 of 4 (25%%)\nprogress=50\nprogress=75\nprogress=100"},
+			PercentPattern: regexp.MustCompile(`progress=(\d+)`),
+			OnPercent:      func(percent int) { got = append(got, percent) },
+		})
+		ensureError(t, err, nil)
+		if want := []int{50, 75, 100}; !reflect.DeepEqual(got, want) {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+
+	t.Run("pattern without callback", func(t *testing.T) {
+		_, err := Run(context.Background(), &Request{
+			Path:           "/usr/bin/true",
+			PercentPattern: regexp.MustCompile(`(\d+)%`),
+		})
+		ensureError(t, err, ErrInvalidRequest{Err: errors.New("cannot set PercentPattern without OnPercent")})
+	})
+}
@@ -0,0 +1,51 @@
+//go:build !windows
+// +build !windows
+
+package gorun
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// shareProcessGroup configures cmd to spawn its process in the process
+// group of this process, which is already the default.
+func shareProcessGroup(cmd *exec.Cmd) {
+	if cmd.SysProcAttr != nil {
+		cmd.SysProcAttr.Setpgid = false
+		cmd.SysProcAttr.Pgid = 0
+	}
+}
+
+// canUseProcessGroup is true when this platform supports
+// Request.ProcessGroup.
+const canUseProcessGroup = true
+
+// useProcessGroup configures cmd to spawn its process as the leader of a
+// new process group, whose ID is the same as its process ID.
+func useProcessGroup(cmd *exec.Cmd) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = new(syscall.SysProcAttr)
+	}
+	cmd.SysProcAttr.Setpgid = true
+	cmd.SysProcAttr.Pgid = 0
+}
+
+// killProcessGroup sends SIGKILL to every process in the process group
+// led by p.
+func killProcessGroup(p *os.Process) error {
+	return syscall.Kill(-p.Pid, syscall.SIGKILL)
+}
+
+// terminateProcessGroup sends SIGTERM to every process in the process
+// group led by p.
+func terminateProcessGroup(p *os.Process) error {
+	return syscall.Kill(-p.Pid, syscall.SIGTERM)
+}
+
+// interruptProcessGroup sends SIGINT to every process in the process
+// group led by p.
+func interruptProcessGroup(p *os.Process) error {
+	return syscall.Kill(-p.Pid, syscall.SIGINT)
+}
@@ -0,0 +1,90 @@
+//go:build !windows
+// +build !windows
+
+package gorun
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestShareProcessGroup(t *testing.T) {
+	got, err := Run(context.Background(), &Request{
+		Path:              "/bin/sh",
+		Args:              []string{"-c", "ps -o pgid= -p $$"},
+		ShareProcessGroup: true,
+	})
+	ensureError(t, err, nil)
+	if got.Code != 0 {
+		t.Skipf("cannot run ps: %s", got.Stderr)
+	}
+	pgid, err := strconv.Atoi(strings.TrimSpace(string(got.Stdout)))
+	ensureError(t, err, nil)
+	if want := syscall.Getpgrp(); pgid != want {
+		t.Errorf("GOT: %v; WANT: %v", pgid, want)
+	}
+}
+
+func TestProcessGroup(t *testing.T) {
+	// processGone returns true when pid does not exist, or is a zombie
+	// waiting to be reaped by a parent that may never do so.
+	processGone := func(pid int) bool {
+		if err := syscall.Kill(pid, 0); err == syscall.ESRCH {
+			return true
+		}
+		got, err := Run(context.Background(), &Request{
+			Path: "/bin/ps",
+			Args: []string{"-o", "stat=", "-p", strconv.Itoa(pid)},
+		})
+		return err == nil && strings.HasPrefix(strings.TrimSpace(string(got.Stdout)), "Z")
+	}
+
+	run := func(t *testing.T, req *Request) int {
+		t.Helper()
+		ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+		defer cancel()
+		req.Path = "/bin/sh"
+		req.Args = []string{"-c", "sleep 30 & echo $!; wait"}
+		req.ProcessGroup = true
+		got, err := Run(ctx, req)
+		ensureError(t, err, nil)
+		if !errors.Is(got.Err, ErrTimeout{}) {
+			t.Errorf("GOT: %v; WANT: %v", got.Err, ErrTimeout{Err: errors.New("signal: killed")})
+		}
+		pid, err := strconv.Atoi(strings.TrimSpace(string(got.Stdout)))
+		ensureError(t, err, nil)
+		return pid
+	}
+
+	ensureGone := func(t *testing.T, pid int) {
+		t.Helper()
+		deadline := time.Now().Add(time.Second)
+		for !processGone(pid) {
+			if time.Now().After(deadline) {
+				_ = syscall.Kill(pid, syscall.SIGKILL)
+				t.Fatalf("GOT: process %d running; WANT: process gone", pid)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	t.Run("kill", func(t *testing.T) {
+		ensureGone(t, run(t, &Request{}))
+	})
+	t.Run("grace period", func(t *testing.T) {
+		ensureGone(t, run(t, &Request{GracePeriod: 100 * time.Millisecond}))
+	})
+	t.Run("with share process group", func(t *testing.T) {
+		_, err := Run(context.Background(), &Request{
+			Path:              "/bin/echo",
+			ProcessGroup:      true,
+			ShareProcessGroup: true,
+		})
+		ensureError(t, err, ErrInvalidRequest{Err: errors.New("cannot set both ProcessGroup and ShareProcessGroup")})
+	})
+}
@@ -0,0 +1,23 @@
+package gorun
+
+import (
+	"os"
+	"os/exec"
+)
+
+// shareProcessGroup is a no-op because Windows child processes already
+// share the console process group of their parent unless created with
+// CREATE_NEW_PROCESS_GROUP.
+func shareProcessGroup(_ *exec.Cmd) {}
+
+// canUseProcessGroup is true when this platform supports
+// Request.ProcessGroup.
+const canUseProcessGroup = false
+
+func useProcessGroup(_ *exec.Cmd) {}
+
+func killProcessGroup(_ *os.Process) error { return nil }
+
+func terminateProcessGroup(_ *os.Process) error { return nil }
+
+func interruptProcessGroup(_ *os.Process) error { return nil }
@@ -0,0 +1,159 @@
+package gorun
+
+import (
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+// canUsePTY is true when this platform supports Request.PTY.
+const canUsePTY = true
+
+// ptyDrainTimeout is how long output continues to be read from a
+// pseudo-terminal after the child process exits, when a process it
+// spawned keeps the terminal open.
+const ptyDrainTimeout = time.Second
+
+// ptyCopier copies the output written to a pseudo-terminal by a child
+// process to an io.Writer. All its methods are no-ops when invoked on a
+// nil ptyCopier.
+type ptyCopier struct {
+	master, slave *os.File
+	w             io.Writer
+	done          chan error
+}
+
+// usePTY allocates a pseudo-terminal and configures cmd to spawn its
+// process attached to it, as its controlling terminal and both its
+// standard output and standard error, whose output is to be copied to
+// the former standard output of cmd, or discarded when it is nil.
+func usePTY(cmd *exec.Cmd) (*ptyCopier, error) {
+	master, slave, err := openPTY()
+	if err != nil {
+		return nil, err
+	}
+	w := cmd.Stdout
+	if w == nil {
+		// The terminal must still be drained for the child process
+		// not to block once its buffer fills.
+		w = io.Discard
+	}
+	pc := &ptyCopier{master: master, slave: slave, w: w, done: make(chan error, 1)}
+	cmd.Stdout, cmd.Stderr = slave, slave
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = new(syscall.SysProcAttr)
+	}
+	cmd.SysProcAttr.Setsid = true
+	cmd.SysProcAttr.Setctty = true
+	cmd.SysProcAttr.Ctty = 1 // standard output of the child process
+	return pc, nil
+}
+
+// start begins copying after the child process has started, or releases
+// the pseudo-terminal when it failed to start.
+func (pc *ptyCopier) start(started bool) {
+	if pc == nil {
+		return
+	}
+	// Only the child process may hold the terminal open, so reading it
+	// fails once every process attached to it has closed it.
+	_ = pc.slave.Close()
+	if !started {
+		_ = pc.master.Close()
+		return
+	}
+	go func() {
+		_, err := io.Copy(pc.w, ptyReader{pc.master})
+		pc.done <- err
+	}()
+}
+
+// wait waits for the output of the child process to be copied after it
+// exits, and returns any error writing it.
+func (pc *ptyCopier) wait() error {
+	if pc == nil {
+		return nil
+	}
+	_ = pc.master.SetReadDeadline(time.Now().Add(ptyDrainTimeout))
+	err := <-pc.done
+	_ = pc.master.Close()
+	return err
+}
+
+// ptyReader reads the master of a pseudo-terminal, and reports the end
+// of its output rather than the error reading it returns once every
+// process attached to it has closed it, or when its read deadline
+// passes while a process still holds it open.
+type ptyReader struct {
+	f *os.File
+}
+
+func (pr ptyReader) Read(p []byte) (int, error) {
+	n, err := pr.f.Read(p)
+	if err != nil {
+		err = io.EOF
+	}
+	return n, err
+}
+
+// openPTY returns the master and slave of a new pseudo-terminal. Output
+// post-processing is disabled, so newlines written by the child process
+// are not translated to carriage return and newline pairs.
+func openPTY() (master, slave *os.File, err error) {
+	master, err = os.OpenFile("/dev/ptmx", os.O_RDWR|syscall.O_NOCTTY|syscall.O_CLOEXEC, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() {
+		if err != nil {
+			_ = master.Close()
+		}
+	}()
+
+	var unlock int32
+	if err = ioctl(master, syscall.TIOCSPTLCK, unsafe.Pointer(&unlock)); err != nil {
+		return nil, nil, err
+	}
+	var n uint32
+	if err = ioctl(master, syscall.TIOCGPTN, unsafe.Pointer(&n)); err != nil {
+		return nil, nil, err
+	}
+	slave, err = os.OpenFile("/dev/pts/"+strconv.Itoa(int(n)), os.O_RDWR|syscall.O_NOCTTY|syscall.O_CLOEXEC, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var termios syscall.Termios
+	if err = ioctl(slave, syscall.TCGETS, unsafe.Pointer(&termios)); err == nil {
+		termios.Oflag &^= syscall.ONLCR
+		err = ioctl(slave, syscall.TCSETS, unsafe.Pointer(&termios))
+	}
+	if err != nil {
+		_ = slave.Close()
+		return nil, nil, err
+	}
+	return master, slave, nil
+}
+
+// ioctl performs the ioctl request on f without putting it in blocking
+// mode, as invoking its Fd method would.
+func ioctl(f *os.File, request uintptr, arg unsafe.Pointer) error {
+	rc, err := f.SyscallConn()
+	if err != nil {
+		return err
+	}
+	var errno syscall.Errno
+	if err = rc.Control(func(fd uintptr) {
+		_, _, errno = syscall.Syscall(syscall.SYS_IOCTL, fd, request, uintptr(arg))
+	}); err != nil {
+		return err
+	}
+	if errno != 0 {
+		return os.NewSyscallError("ioctl", errno)
+	}
+	return nil
+}
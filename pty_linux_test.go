@@ -0,0 +1,84 @@
+package gorun
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestPTY(t *testing.T) {
+	isatty := `if [ -t 1 ]; then echo terminal; else echo pipe; fi; if [ -t 2 ]; then echo terminal >&2; else echo pipe >&2; fi`
+
+	t.Run("attached", func(t *testing.T) {
+		got, err := Run(context.Background(), &Request{
+			Path: "/bin/sh",
+			Args: []string{"-c", isatty},
+			PTY:  true,
+		})
+		ensureError(t, err, nil)
+		ensureResponsesMatch(t, got, &Response{Stderr: []byte{}, Stdout: []byte("terminal\nterminal\n")})
+	})
+
+	t.Run("detached", func(t *testing.T) {
+		got, err := Run(context.Background(), &Request{
+			Path: "/bin/sh",
+			Args: []string{"-c", isatty},
+		})
+		ensureError(t, err, nil)
+		ensureResponsesMatch(t, got, &Response{Stderr: []byte("pipe\n"), Stdout: []byte("pipe\n")})
+	})
+
+	t.Run("stdin", func(t *testing.T) {
+		got, err := Run(context.Background(), &Request{
+			Path:        "/bin/sh",
+			Args:        []string{"-c", `if [ -t 0 ]; then echo terminal; fi; cat`},
+			StdinString: "input\n",
+			PTY:         true,
+		})
+		ensureError(t, err, nil)
+		ensureResponsesMatch(t, got, &Response{Stderr: []byte{}, Stdout: []byte("input\n")})
+	})
+
+	t.Run("exceeds terminal buffer", func(t *testing.T) {
+		const size = 1 << 20
+		got, err := Run(context.Background(), &Request{
+			Path: "/bin/sh",
+			Args: []string{"-c", "yes | head -c 1048576; exit 3"},
+			PTY:  true,
+		})
+		ensureError(t, err, nil)
+		if got, want := got.Code, 3; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		if want := bytes.Repeat([]byte("y\n"), size/2); !bytes.Equal(got.Stdout, want) {
+			t.Errorf("GOT: %d bytes; WANT: %d bytes", len(got.Stdout), len(want))
+		}
+	})
+
+	t.Run("descendant holds terminal open", func(t *testing.T) {
+		started := time.Now()
+		got, err := Run(context.Background(), &Request{
+			Path: "/bin/sh",
+			Args: []string{"-c", "sleep 5 & echo done"},
+			PTY:  true,
+		})
+		ensureError(t, err, nil)
+		if elapsed := time.Since(started); elapsed > ptyDrainTimeout+time.Second {
+			t.Errorf("GOT: %v; WANT: < %v", elapsed, ptyDrainTimeout+time.Second)
+		}
+		if got, want := string(got.Stdout), "done\n"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+	})
+
+	t.Run("conflicts with combine output", func(t *testing.T) {
+		_, err := Run(context.Background(), &Request{
+			Path:          "/usr/bin/true",
+			PTY:           true,
+			CombineOutput: true,
+		})
+		ensureError(t, err, ErrInvalidRequest{Err: errors.New("cannot set PTY along with CombineOutput, ProcessGroup, ShareProcessGroup, or SwapStdoutStderr")})
+	})
+}
@@ -0,0 +1,22 @@
+//go:build !linux
+// +build !linux
+
+package gorun
+
+import (
+	"errors"
+	"os/exec"
+)
+
+// canUsePTY is true when this platform supports Request.PTY.
+const canUsePTY = false
+
+type ptyCopier struct{}
+
+func usePTY(_ *exec.Cmd) (*ptyCopier, error) {
+	return nil, errors.New("cannot allocate a pseudo-terminal on this platform")
+}
+
+func (*ptyCopier) start(_ bool) {}
+
+func (*ptyCopier) wait() error { return nil }
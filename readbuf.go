@@ -0,0 +1,28 @@
+package gorun
+
+import "io"
+
+// minReadBufferSize is the smallest permitted Request.ReadBufferSize.
+const minReadBufferSize = 512
+
+// bufferedCopier is an io.Writer that forwards writes to w, and whose
+// ReadFrom method copies from a reader using a buffer of size bytes.
+// Because os/exec copies the output of a child process from its pipe by
+// invoking io.Copy, which prefers the ReadFrom method of its
+// destination, this controls the size of each read from the pipe.
+type bufferedCopier struct {
+	w    io.Writer
+	size int
+}
+
+func (bc *bufferedCopier) Write(p []byte) (int, error) {
+	return bc.w.Write(p)
+}
+
+// ReadFrom copies from r to w until EOF, reading at most size bytes at a
+// time.
+func (bc *bufferedCopier) ReadFrom(r io.Reader) (int64, error) {
+	// Hiding the WriterTo and ReaderFrom methods of r and w prevents
+	// io.CopyBuffer from bypassing the buffer.
+	return io.CopyBuffer(struct{ io.Writer }{bc.w}, struct{ io.Reader }{r}, make([]byte, bc.size))
+}
@@ -0,0 +1,73 @@
+//go:build !windows
+// +build !windows
+
+package gorun
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestReadBufferSize(t *testing.T) {
+	t.Run("small buffer", func(t *testing.T) {
+		// Lines of varying length straddle the boundaries of the reads.
+		var want strings.Builder
+		for i := 0; i < 2000; i++ {
+			want.WriteString(strings.Repeat("x", i%37) + strconv.Itoa(i) + "\n")
+		}
+		got, err := Run(context.Background(), &Request{
+			Path:           "/bin/cat",
+			StdinString:    want.String(),
+			ReadBufferSize: minReadBufferSize,
+		})
+		ensureError(t, err, nil)
+		ensureResponsesMatch(t, got, &Response{Stderr: []byte{}, Stdout: []byte(want.String())})
+	})
+
+	t.Run("combined", func(t *testing.T) {
+		got, err := Run(context.Background(), &Request{
+			Path:           "/bin/sh",
+			Args:           []string{"-c", "echo out; echo err >&2; echo out"},
+			CombineOutput:  true,
+			ReadBufferSize: minReadBufferSize,
+		})
+		ensureError(t, err, nil)
+		if got, want := string(got.Combined), "out\nerr\nout\n"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+	})
+
+	t.Run("too small", func(t *testing.T) {
+		_, err := Run(context.Background(), &Request{
+			Path:           "/usr/bin/true",
+			ReadBufferSize: 16,
+		})
+		ensureError(t, err, ErrInvalidRequest{Err: errors.New("cannot set ReadBufferSize below 512")})
+	})
+}
+
+func BenchmarkReadBufferSize(b *testing.B) {
+	const size = 64 << 20
+	for _, bufferSize := range []int{0, minReadBufferSize, 4 << 10, 256 << 10} {
+		b.Run(strconv.Itoa(bufferSize), func(b *testing.B) {
+			b.SetBytes(size)
+			req := &Request{
+				Path:           "/usr/bin/head",
+				Args:           []string{"-c", strconv.Itoa(size), "/dev/zero"},
+				ReadBufferSize: bufferSize,
+			}
+			for i := 0; i < b.N; i++ {
+				resp, err := Run(context.Background(), req)
+				if err != nil {
+					b.Fatal(err)
+				}
+				if got := len(resp.Stdout); got != size {
+					b.Fatalf("GOT: %v; WANT: %v", got, size)
+				}
+			}
+		})
+	}
+}
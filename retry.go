@@ -0,0 +1,49 @@
+package gorun
+
+import "time"
+
+// Retry specifies when and how often Run spawns a child process again
+// after an attempt that failed transiently.
+type Retry struct {
+	// MaxAttempts is the maximum number of times to spawn the child
+	// process, including the first attempt. When less than 2, no
+	// attempt is retried.
+	MaxAttempts int
+
+	// Backoff is the delay before the first retry.
+	Backoff time.Duration
+
+	// Multiplier is the factor by which the delay grows after each
+	// retry. When not greater than 1, every delay is Backoff.
+	Multiplier float64
+
+	// RetryOn, when not nil, returns true when the attempt that
+	// returned resp and err ought to be retried. Note that resp is nil
+	// when err is not nil. When nil, only attempts whose child process
+	// terminated due to receiving a signal are retried.
+	RetryOn func(resp *Response, err error) bool
+}
+
+// retry returns true when the attempt that returned resp and err ought
+// to be retried, given that attempts have already been made. It returns
+// false when invoked on a nil Retry.
+func (r *Retry) retry(attempts int, resp *Response, err error) bool {
+	if r == nil || attempts >= r.MaxAttempts {
+		return false
+	}
+	if r.RetryOn != nil {
+		return r.RetryOn(resp, err)
+	}
+	return err == nil && resp.Signaled()
+}
+
+// delay returns the delay before the retry following attempts attempts.
+func (r *Retry) delay(attempts int) time.Duration {
+	d := r.Backoff
+	if r.Multiplier > 1 {
+		for i := 1; i < attempts; i++ {
+			d = time.Duration(float64(d) * r.Multiplier)
+		}
+	}
+	return d
+}
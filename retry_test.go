@@ -0,0 +1,152 @@
+//go:build !windows
+// +build !windows
+
+package gorun
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// failingScript fails the first n times it is run in a directory, by
+// running fail, and then prints the number of the attempt.
+func failingScript(n, fail string) []string {
+	return []string{"-c", `echo x >> attempts; n=$(wc -l < attempts); if [ $n -le ` + n + ` ]; then ` + fail + `; fi; echo $n`}
+}
+
+func TestRetry(t *testing.T) {
+	attempts := func(t *testing.T, dir string) int {
+		t.Helper()
+		buf, err := os.ReadFile(filepath.Join(dir, "attempts"))
+		ensureError(t, err, nil)
+		return len(buf) / len("x\n")
+	}
+
+	t.Run("signal by default", func(t *testing.T) {
+		dir := t.TempDir()
+		got, err := Run(context.Background(), &Request{
+			Path:  "/bin/sh",
+			Args:  failingScript("2", "kill -9 $$"),
+			Dir:   dir,
+			Retry: &Retry{MaxAttempts: 3},
+		})
+		ensureError(t, err, nil)
+		ensureResponsesMatch(t, got, &Response{Stderr: []byte{}, Stdout: []byte("3\n")})
+	})
+
+	t.Run("non-zero exit not retried by default", func(t *testing.T) {
+		dir := t.TempDir()
+		got, err := Run(context.Background(), &Request{
+			Path:  "/bin/sh",
+			Args:  failingScript("2", "exit 3"),
+			Dir:   dir,
+			Retry: &Retry{MaxAttempts: 3},
+		})
+		ensureError(t, err, nil)
+		if g, w := got.Code, 3; g != w {
+			t.Errorf("GOT: %v; WANT: %v", g, w)
+		}
+		if g, w := attempts(t, dir), 1; g != w {
+			t.Errorf("GOT: %v; WANT: %v", g, w)
+		}
+	})
+
+	t.Run("retry on", func(t *testing.T) {
+		dir := t.TempDir()
+		got, err := Run(context.Background(), &Request{
+			Path: "/bin/sh",
+			Args: failingScript("2", "exit 3"),
+			Dir:  dir,
+			Retry: &Retry{
+				MaxAttempts: 5,
+				RetryOn:     func(resp *Response, err error) bool { return err == nil && resp.Code != 0 },
+			},
+		})
+		ensureError(t, err, nil)
+		ensureResponsesMatch(t, got, &Response{Stderr: []byte{}, Stdout: []byte("3\n")})
+		if g, w := attempts(t, dir), 3; g != w {
+			t.Errorf("GOT: %v; WANT: %v", g, w)
+		}
+	})
+
+	t.Run("exhausted", func(t *testing.T) {
+		dir := t.TempDir()
+		got, err := Run(context.Background(), &Request{
+			Path: "/bin/sh",
+			Args: []string{"-c", "echo x >> attempts; wc -l < attempts; exit 3"},
+			Dir:  dir,
+			Retry: &Retry{
+				MaxAttempts: 2,
+				RetryOn:     func(resp *Response, err error) bool { return err == nil && resp.Code != 0 },
+			},
+		})
+		ensureError(t, err, nil)
+		// The Response is from the final attempt.
+		ensureResponsesMatch(t, got, &Response{Code: 3, Stderr: []byte{}, Stdout: []byte("2\n")})
+	})
+
+	t.Run("spawn error", func(t *testing.T) {
+		var calls int
+		_, err := Run(context.Background(), &Request{
+			Path: "/no-such-path",
+			Retry: &Retry{
+				MaxAttempts: 3,
+				RetryOn: func(resp *Response, err error) bool {
+					calls++
+					return errors.Is(err, ErrSpawn{})
+				},
+			},
+		})
+		ensureError(t, err, ErrSpawn{Err: errors.New("fork/exec /no-such-path: no such file or directory")})
+		if g, w := calls, 2; g != w {
+			t.Errorf("GOT: %v; WANT: %v", g, w)
+		}
+	})
+
+	t.Run("backoff", func(t *testing.T) {
+		dir := t.TempDir()
+		started := time.Now()
+		_, err := Run(context.Background(), &Request{
+			Path:  "/bin/sh",
+			Args:  failingScript("2", "kill -9 $$"),
+			Dir:   dir,
+			Retry: &Retry{MaxAttempts: 3, Backoff: 50 * time.Millisecond, Multiplier: 2},
+		})
+		ensureError(t, err, nil)
+		if elapsed, want := time.Since(started), 150*time.Millisecond; elapsed < want {
+			t.Errorf("GOT: %v; WANT: >= %v", elapsed, want)
+		}
+	})
+
+	t.Run("delay", func(t *testing.T) {
+		r := &Retry{Backoff: 10 * time.Millisecond, Multiplier: 1.5}
+		for i, want := range []time.Duration{10 * time.Millisecond, 15 * time.Millisecond, 22500 * time.Microsecond} {
+			if got := r.delay(i + 1); got != want {
+				t.Errorf("GOT: %v; WANT: %v", got, want)
+			}
+		}
+	})
+
+	t.Run("canceled", func(t *testing.T) {
+		dir := t.TempDir()
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		got, err := Run(ctx, &Request{
+			Path:  "/bin/sh",
+			Args:  failingScript("5", "kill -9 $$"),
+			Dir:   dir,
+			Retry: &Retry{MaxAttempts: 5, Backoff: time.Second},
+		})
+		ensureError(t, err, nil)
+		if !got.Signaled() {
+			t.Errorf("GOT: %v; WANT: %v", got.Signaled(), true)
+		}
+		if g, w := attempts(t, dir), 1; g != w {
+			t.Errorf("GOT: %v; WANT: %v", g, w)
+		}
+	})
+}
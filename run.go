@@ -3,8 +3,20 @@ package gorun
 import (
 	"bytes"
 	"context"
+	"errors"
+	"hash"
 	"io"
+	"io/fs"
+	"os"
 	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"time"
 )
 
 // Run executes a system command.
@@ -22,8 +34,10 @@ import (
 // that specifies the signal it receives in Err.
 //
 // NOTE: If context.Context expires, Go will send termination signal
-// to spawned child process, and Response will have Code and Err set
-// in accordance with this case.
+// to spawned child process, and Response will have Code set to -1,
+// and Err set to an ErrTimeout error when the deadline of the context
+// passed, or an ErrCanceled error when the context was canceled. Both
+// wrap the ErrSignal error that describes the signal.
 //
 // 4. When the child program exits on its own and not due to receiving
 // a signal as described above, it returns Response with Code set
@@ -36,25 +50,720 @@ func Run(ctx context.Context, req *Request) (*Response, error) {
 
 // Request represents a request to spawn a child process.
 type Request struct {
+	// AlarmAfter, when positive, is the duration after the child
+	// process is spawned to send it SIGALRM, without otherwise
+	// terminating it, for programs that handle SIGALRM to enforce their
+	// own timeouts. A program that does not handle SIGALRM is
+	// terminated by it. It is only supported on Unix like platforms,
+	// and on other platforms Run returns an ErrInvalidRequest error.
+	AlarmAfter time.Duration
+
 	// Args is a potentially empty list of command line arguments to
 	// be sent to the child process.
 	Args []string
 
 	// Env is a potentially empty list of environment variable
-	// assignments to be sent to the child process.
+	// assignments to be sent to the child process. When nil, the child
+	// process inherits the environment of this process. Otherwise Env
+	// replaces that environment, unless InheritEnv is true.
 	Env []string
 
+	// EnvMap is a potentially nil map of environment variable names to
+	// their values to be sent to the child process, which spares the
+	// caller from formatting each assignment. Its assignments are sent
+	// in the order of their sorted names, before those of Env, which
+	// take precedence when both assign the same variable. When EnvMap
+	// is not nil, the child process does not inherit the environment
+	// of this process, just as when Env is not nil, unless InheritEnv
+	// is true. Names must not be empty nor contain the equals sign.
+	EnvMap map[string]string
+
+	// InheritEnv, when true, starts the environment of the child
+	// process with that of this process, and applies the assignments
+	// of Env to it, rather than Env replacing it. When a variable is
+	// assigned more than once, the last assignment wins, and the child
+	// process receives only that one. When ExpandEnv is also true, the
+	// values of Env are expanded before they are applied.
+	InheritEnv bool
+
 	// Stdin is the potentially nil io.Reader that will be available
 	// for the child process to read from when it reads from its
-	// standard input.
+	// standard input. When Stdin and all the other sources of standard
+	// input below are unset, the standard input of the child process
+	// is the null device, so a program that reads from it sees end of
+	// file immediately, rather than blocking on, or consuming, the
+	// standard input of this process.
 	Stdin io.Reader
 
+	// StdinBytes, when not nil and Stdin is nil, is the data that will
+	// be available for the child process to read from when it reads
+	// from its standard input. Unlike Stdin, it is not consumed by
+	// running the child process, so a Request with StdinBytes may be
+	// run repeatedly.
+	StdinBytes []byte
+
+	// StdinString, when not empty and both Stdin and StdinBytes are
+	// nil, is the data that will be available for the child process to
+	// read from when it reads from its standard input. Like StdinBytes,
+	// it is not consumed by running the child process.
+	StdinString string
+
+	// StdinReaders is a potentially empty list of io.Reader instances
+	// that will be concatenated, in order, and made available for the
+	// child process to read from when it reads from its standard
+	// input. It is an error to set more than one of Stdin,
+	// StdinReaders, and StdinReaderAt, where setting StdinBytes or
+	// StdinString counts as setting Stdin.
+	StdinReaders []io.Reader
+
+	// StdinReaderAt is the potentially nil io.ReaderAt from which the
+	// section of StdinLength bytes starting at StdinOffset will be
+	// available for the child process to read from when it reads from
+	// its standard input, which avoids copying an entire file when
+	// only part of it is needed. It is an error to set more than one
+	// of Stdin, StdinReaders, and StdinReaderAt.
+	StdinReaderAt io.ReaderAt
+
+	// StdinOffset is the offset into StdinReaderAt of the first byte
+	// available on the standard input of the child process.
+	StdinOffset int64
+
+	// StdinLength is the number of bytes of StdinReaderAt available on
+	// the standard input of the child process.
+	StdinLength int64
+
+	// StdinIdleTimeout, when positive, is the maximum duration to wait
+	// for the caller to provide more input from Stdin, StdinReaders, or
+	// StdinReaderAt, after which the child process is killed and
+	// Response.Err is an ErrStdinIdleTimeout error. The timeout only
+	// runs while waiting to read more input for the child process, and
+	// restarts with each read, so a child process that is slow to
+	// consume its input is not killed. When it elapses, the blocked read
+	// of the caller's reader is abandoned rather than interrupted. It is
+	// an error to set it without one of Stdin, StdinReaders, or
+	// StdinReaderAt.
+	StdinIdleTimeout time.Duration
+
+	// StdinTee is the potentially nil io.Writer that will receive a copy
+	// of everything sent to the standard input of the child process,
+	// from whichever source provides it, as it is sent. The child
+	// process still reads end of file as soon as its source is
+	// exhausted. Writes are passed to it without any additional
+	// buffering, and an error writing to it stops sending input to the
+	// child process.
+	StdinTee io.Writer
+
+	// BlockSignals is a potentially empty list of signals the child
+	// process starts with blocked, in place of the signal mask the Go
+	// runtime restores before executing it, which is the mask this
+	// process was started with. The standard library cannot change the
+	// mask between fork and exec, so the child program is executed by
+	// the gorun-blocksignals helper program, which must be installed in
+	// the PATH of this process from the cmd/gorun-blocksignals
+	// directory of this module. It blocks exactly these signals and
+	// then replaces itself with the child program. The kernel silently
+	// declines to block the kill and stop signals. When the helper or
+	// the child program cannot be executed, Run returns an ErrSpawn
+	// error. It is only supported on Linux on amd64 and arm64, and on
+	// other platforms Run returns an ErrInvalidRequest error.
+	BlockSignals []syscall.Signal
+
+	// CLIFriendlyCancel, when true, asks the child process to stop when
+	// the context is done the way a user at a terminal would, which
+	// command line programs like docker and kubectl handle by cleaning
+	// up before they exit. It first sends the interrupt signal, then
+	// after GracePeriod the terminate signal, and after GracePeriod
+	// again the kill signal, which cannot be ignored. When GracePeriod
+	// is not positive, each step waits one second. When ProcessGroup is
+	// true, the interrupt and terminate signals are sent to the entire
+	// process group. It is not supported on Windows, where Run returns
+	// an ErrInvalidRequest error.
+	CLIFriendlyCancel bool
+
+	// CombineOutput, when true, connects both the standard output and
+	// standard error of the child process to the same pipe, and
+	// captures what it writes to either one in Response.Combined, in
+	// the order it wrote it, as a terminal would show it. Response.Stdout
+	// and Response.Stderr are then nil. Because the streams cannot be
+	// told apart, it is an error to set CombineOutput along with any
+	// field that handles one stream separately from the other, and
+	// RunConcurrent, RunLines, RunLogged, and RunSink return an
+	// ErrInvalidRequest error when it is set.
+	CombineOutput bool
+
+	// DecodeBase64Stdout, when true, decodes the standard output of the
+	// child process returned in the Response from standard base64
+	// encoding, ignoring any white space, such as the newlines that
+	// wrap long lines of it. When it cannot be decoded, the encoded
+	// output is returned, and Response.Err is an ErrDecodeBase64 error,
+	// unless the child process already failed. Decoding precedes any
+	// decompression by DecompressStdout. It is an error to set
+	// DecodeBase64Stdout along with StdoutWriter, HashStdout,
+	// StdoutGrep, SpillStdoutAfter, or StdoutHeadBytes.
+	DecodeBase64Stdout bool
+
+	// DecompressStdout, when true, decompresses the standard output of
+	// the child process returned in the Response when it begins with
+	// the gzip magic bytes, and otherwise leaves it untouched. When it
+	// cannot be decompressed, the compressed output is returned, and
+	// Response.Err is an ErrDecompress error. Only Response.Stdout is
+	// decompressed; StdoutBuffer, StdoutTee, and the other records of
+	// the output of the child process receive it as it was written.
+	DecompressStdout bool
+
+	// DetectOOMKill, when true, reports in Response.OOMKilled whether
+	// the child process was likely killed for lack of memory. It reads
+	// counts from the /proc and /sys file systems when the child
+	// process starts, and again when it is terminated by the kill
+	// signal, so it is off by default. It is only supported on Linux,
+	// and on other platforms Run returns an ErrInvalidRequest error.
+	DetectOOMKill bool
+
 	// Dir is the directory to set as the child process' initial
 	// current working directory when it starts.
 	Dir string
 
+	// DumpOnTimeout, when true, sends SIGQUIT to the child process when
+	// the context deadline expires, and waits up to one second for it
+	// to exit before terminating it. Go programs respond to SIGQUIT by
+	// writing the stack traces of all goroutines to their standard
+	// error and exiting, and the JVM responds by writing a thread dump
+	// to its standard output and continuing to run, so the dump is
+	// included in the Response. Programs that do not handle SIGQUIT are
+	// terminated by it, and some also dump core. It is only supported
+	// on Unix like platforms, and on other platforms Run returns an
+	// ErrInvalidRequest error.
+	DumpOnTimeout bool
+
+	// Events is the potentially nil io.Writer to which a line of JSON
+	// is written for each lifecycle event of the child process: when
+	// it starts, for each chunk of output read from it, and when it
+	// exits. Each line is the JSON encoding of an Event. Errors writing
+	// to Events are ignored.
+	Events io.Writer
+
+	// ExitCodeErrors maps exit codes that the program documents as
+	// having specific meanings to errors describing them. When the
+	// child process exits with one of them, the ErrExit error returned
+	// by RunVerboseChecked, and by Group, wraps the corresponding
+	// error, so callers may test for it with errors.Is. Run itself does
+	// not consult it.
+	ExitCodeErrors map[int]error
+
+	// ExitCodeFile is the potentially empty path to a file from which
+	// to read the exit code of the child process, for wrapper programs
+	// that cannot return the exit code of the program they wrap. When
+	// set, and the child process was not terminated by a signal, the
+	// integer read from this file replaces the exit code of the child
+	// process. When the file cannot be read or does not contain an
+	// integer, the exit code of the child process is used. When the
+	// integer is not between 0 and 255, the exit code of the child
+	// process is used and Response.Err is an ErrInvalidExitCode error.
+	// A relative path is resolved relative to Dir.
+	ExitCodeFile string
+
+	// ExpectFiles is a potentially empty list of paths of files that
+	// the child process is expected to create. After the child process
+	// exits with a zero exit code, Run checks that each of them
+	// exists, and when any do not, Response.Err is an
+	// ErrMissingOutputFiles error listing them. Relative paths are
+	// resolved relative to Dir.
+	ExpectFiles []string
+
+	// ExpandEnv, when true, expands references to other variables, as
+	// either $NAME or ${NAME}, in the values of Env, so an assignment
+	// can be built from others, like "PATH=/opt/bin:$PATH". Variables
+	// are expanded after the variables they reference, regardless of
+	// their order in Env. A reference to a variable not assigned in Env,
+	// or a variable's reference to itself, expands to the value of that
+	// variable in the environment of the current process. When
+	// variables reference each other in a cycle, Run returns an
+	// ErrInvalidRequest error.
+	ExpandEnv bool
+
+	// Fallbacks is the optional list of alternative paths or names of
+	// programs, tried in order, when Path cannot be found, for instance
+	// to prefer gtar but fall back to tar. Names without a path
+	// separator are searched for in the directories named by the PATH
+	// environment variable. Only a program that does not exist causes a
+	// fallback to be tried, and not one that fails to spawn for any
+	// other reason, or one that runs but fails. When none can be found,
+	// Run returns an ErrSpawn error describing Path.
+	Fallbacks []string
+
+	// GracePeriod, when positive, is how long the child process has to
+	// exit after it is sent a terminate signal when the context is done,
+	// before it is sent a kill signal, which it cannot ignore. This
+	// allows a well behaved program to clean up before it exits. When
+	// Terminator is not nil, it is used to terminate the child process
+	// rather than the terminate signal, but the child process is still
+	// killed once GracePeriod elapses. When CLIFriendlyCancel is true,
+	// GracePeriod is instead the time between each of the signals it
+	// sends. Response.Err describes whichever signal terminated the
+	// child process. It is not supported on Windows, where Run returns
+	// an ErrInvalidRequest error.
+	GracePeriod time.Duration
+
+	// HardTimeout, when positive, is the maximum duration the child
+	// process may run, enforced by a timer started when the child
+	// process is spawned, independently of the context and of any
+	// Terminator. When it elapses, the child process is sent a kill
+	// signal, which it cannot ignore, and Response.Err is set to an
+	// ErrHardTimeout error, which Measure and Benchmark return as their
+	// error. It is a safety net for child processes that do not exit
+	// when asked to. Once the child process exits, Run waits at most
+	// GracePeriod, or one second when GracePeriod is not positive, for
+	// any processes it spawned to close its output.
+	HardTimeout time.Duration
+
+	// HashStdout, when not nil, returns the hash.Hash into which the
+	// standard output of the child process is written, rather than
+	// being captured, so its digest and size are available in
+	// Response.StdoutHash and Response.StdoutBytesRead without
+	// retaining any of it. Response.Stdout is nil. It is an error to
+	// set HashStdout along with StdoutBuffer, StdoutGrep, or
+	// DecompressStdout.
+	HashStdout func() hash.Hash
+
+	// LatencyBudget, when positive, is how long the child process is
+	// expected to run. Unlike Timeout, it does not terminate the child
+	// process, but when Response.Duration exceeds it,
+	// Response.BudgetExceeded is set, and an EventBudgetExceeded event
+	// is written to Events.
+	LatencyBudget time.Duration
+
+	// MaxChildProcesses, when positive, sets RLIMIT_NPROC of the child
+	// process, so it fails to fork once the real user ID of the child
+	// process owns that many processes. Note the kernel counts every
+	// process owned by the user, not merely the descendants of the
+	// child process, and does not enforce the limit for privileged
+	// users. The child process is started traced, so it stops once
+	// it executes the program, and is released once the limit is set,
+	// so the limit is in effect from the first instruction of the
+	// program. Being traced, a set-user-ID program runs without its
+	// privileges. When the limit cannot be set, such as when it
+	// exceeds the hard limit of this unprivileged process, the child
+	// process is killed and Run returns an ErrSpawn error. It is only
+	// supported on Linux, and on other platforms Run returns an
+	// ErrInvalidRequest error.
+	MaxChildProcesses int
+
+	// MaxOutputBytes, when positive, is the maximum number of bytes of
+	// each of the standard output and standard error of the child
+	// process to capture in memory, protecting this process from a
+	// child process that writes without bound. Once either stream
+	// reaches it, further output to that stream is discarded, but still
+	// counted in Response.StdoutBytesRead or Response.StderrBytesRead,
+	// and Response.StdoutTruncated or Response.StderrTruncated is set.
+	// Unlike MaxStdoutBytes, the child process is not terminated. When
+	// StdoutHeadBytes or StderrHeadBytes is also set, the smaller limit
+	// applies. It does not limit output written to StdoutWriter,
+	// StderrWriter, or the tees, nor output spilled to a file by
+	// SpillStdoutAfter.
+	MaxOutputBytes int64
+
+	// MaxStderrLines, when positive, is the maximum number of lines of
+	// the standard error of the child process to accept, counting a
+	// final line that lacks a newline terminator. Once it starts
+	// writing another line, the excess is discarded rather than
+	// forwarded to any of its destinations, Response.StderrTruncated is
+	// set, and the child process is sent a SIGTERM signal, then killed
+	// if it has not exited once GracePeriod elapses, or one second when
+	// GracePeriod is not positive. On platforms that do not support
+	// GracePeriod, the child process is killed immediately.
+	MaxStderrLines int
+
+	// MaxStdoutBytes, when positive, is the maximum number of bytes of
+	// the standard output of the child process to accept. Once it
+	// writes more than that, the excess is discarded rather than
+	// forwarded to any of its destinations, its standard output is
+	// closed, the child process is killed, and Response.Err is an
+	// ErrOutputTooLarge error. It applies equally whether the output
+	// is captured in Response.Stdout or streamed to StdoutWriter. Run
+	// waits at most GracePeriod, or one second when GracePeriod is not
+	// positive, for any processes it spawned to close its output.
+	MaxStdoutBytes int64
+
+	// MaxStdoutLines, when positive, is the maximum number of lines of
+	// the standard output of the child process to accept, counting a
+	// final line that lacks a newline terminator. It is otherwise like
+	// MaxStderrLines, and sets Response.StdoutTruncated.
+	MaxStdoutLines int
+
+	// Name is an optional label identifying the request, which
+	// RunConcurrent uses to tag the lines of output of its child
+	// process.
+	Name string
+
+	// NoDefaultArgs, when true, prevents a Runner from sending its
+	// DefaultArgs to the child process.
+	NoDefaultArgs bool
+
+	// NewPIDNamespace, when true, spawns the child process in a new
+	// PID namespace, in which it is PID 1. When the child process
+	// exits, the kernel kills every other process in its namespace, so
+	// no descendant of the child process can outlive it. Like any init
+	// process, the child process must reap its orphaned descendants.
+	// The child process still sees the /proc file system of the parent
+	// process unless it mounts its own. It requires CAP_SYS_ADMIN, or
+	// also creating a new user namespace, and is only supported on
+	// Linux; on other platforms Run returns an ErrInvalidRequest error.
+	NewPIDNamespace bool
+
+	// OnPercent, when not nil, is invoked with each progress percentage
+	// parsed from the lines the child process writes to its standard
+	// output or standard error, such as the 42 in "42% complete", so
+	// the progress of a long running program may be shown while it
+	// runs. A carriage return ends a line as well as a newline, so the
+	// updates of a program that rewrites the same line of a terminal
+	// are each parsed. When a line has more than one percentage, the
+	// last is used, and a percentage equal to the previous one is not
+	// reported again. It is invoked synchronously while the output is
+	// copied, never concurrently, so it should return promptly.
+	OnPercent func(percent int)
+
+	// OnStart, when not nil, is invoked with the process ID of the
+	// child process immediately after it starts, before waiting for it
+	// to exit. It is called synchronously, so the process ID is valid
+	// until OnStart returns, but the child process does not wait for
+	// it.
+	OnStart func(pid int)
+
 	// Path is the path to the child process program executable file.
 	Path string
+
+	// PercentPattern is the potentially nil regular expression that
+	// matches a progress percentage for OnPercent. Its first
+	// parenthesized subexpression, or the entire match when it has
+	// none, must be the decimal digits of the percentage. When nil,
+	// `(\d+)%` is used. It is an error to set it without OnPercent.
+	PercentPattern *regexp.Regexp
+
+	// PTY, when true, allocates a pseudo-terminal and spawns the child
+	// process attached to it, as its controlling terminal and both its
+	// standard output and standard error, for programs that only
+	// color their output, or that buffer it differently, when writing
+	// to a terminal. Because the two streams share the terminal, what
+	// the child process writes to either is captured in
+	// Response.Stdout, in the order it wrote it, and Response.Stderr is
+	// empty. Newlines are not translated to the carriage return and
+	// newline pairs a terminal would show. Its standard input is not
+	// the terminal, but remains the null device or the configured
+	// source of standard input. The terminal is read continuously, so
+	// the child process does not block when writing more than it
+	// buffers, until the child process exits, and for up to one second
+	// longer while any process it spawned holds the terminal open. It
+	// is an error to set PTY along with CombineOutput, ProcessGroup,
+	// ShareProcessGroup, or SwapStdoutStderr. It is only supported on
+	// Linux; on other platforms Run returns an ErrInvalidRequest error.
+	PTY bool
+
+	// ProcessGroup, when true, spawns the child process as the leader
+	// of a new process group, and when the context is done, sends the
+	// kill signal to the entire process group rather than just the
+	// child process, so that processes it spawned, such as commands a
+	// shell script runs in the background, do not outlive it. When
+	// GracePeriod is positive, the terminate signal is sent to the
+	// process group instead, and any process remaining in it is killed
+	// after the child process exits. Because the child process is no
+	// longer in the process group of this process, it does not receive
+	// signals sent to that group, such as SIGINT from a terminal. It is
+	// not supported on Windows, where Run returns an ErrInvalidRequest
+	// error.
+	ProcessGroup bool
+
+	// ReadBufferSize, when positive, is the size in bytes of the buffer
+	// used to copy each of the standard output and standard error of
+	// the child process from the pipe it writes to. Larger buffers
+	// reduce the number of reads of a child process that writes a lot
+	// of output. When zero, the buffer size chosen by os/exec is used.
+	// It is an error to set it below 512.
+	ReadBufferSize int
+
+	// RecordKernelStartTime, when true, reports in
+	// Response.KernelStartTime and Response.KernelDuration when the
+	// operating system created the child process and how long it ran
+	// from then. On Linux it reads the /proc file system after the
+	// child process is spawned, so it is off by default. On other
+	// platforms both remain zero.
+	RecordKernelStartTime bool
+
+	// RequireOutput, when true, causes Run to set Response.Err to an
+	// ErrNoOutput error when the child process exits without writing
+	// anything to the stream identified by RequireOutputStream,
+	// regardless of its exit code. This catches programs that silently
+	// do nothing.
+	RequireOutput bool
+
+	// RequireOutputStream identifies the stream that RequireOutput
+	// checks, either StreamStdout or StreamStderr. When zero,
+	// RequireOutput checks the standard output.
+	RequireOutputStream int
+
+	// ResolvePath, when true and Path is a bare name without a path
+	// separator, finds the program by searching the directories named
+	// by the PATH variable of Env, as a shell running in that
+	// environment would, rather than those named by the PATH of this
+	// process. When Env is nil, the child process inherits the
+	// environment of this process, so its PATH is used. When the
+	// program cannot be found, Run returns an ErrSpawn error without
+	// attempting to spawn the child process. Fallbacks are found the
+	// same way.
+	ResolvePath bool
+
+	// Retry, when not nil, specifies spawning the child process again
+	// when an attempt fails transiently, as decided by Retry.RetryOn,
+	// up to Retry.MaxAttempts times in all. No further attempts are
+	// made once the context is done, and the Response and error of the
+	// final attempt are returned. Attempts retried for RetryOnSignal or
+	// RetryOnEmptyOutput are not counted. Note that Stdin is not
+	// rewound between attempts, although StdinBytes and StdinString are
+	// read again from their start.
+	Retry *Retry
+
+	// RetryOnEmptyOutput is the maximum number of times to spawn the
+	// child process again when it exits with a zero exit code, but
+	// without any standard output captured in Response.Stdout, for
+	// programs that occasionally and spuriously produce nothing. When
+	// RequireOutput is also set, the ErrNoOutput error is only returned
+	// once the retries are exhausted. Retries are delayed and limited by
+	// the context like those of RetryOnSignal, and counted separately
+	// from them. It is an error to set RetryOnEmptyOutput along with
+	// StdoutWriter or HashStdout.
+	RetryOnEmptyOutput int
+
+	// RetryOnSignal is the maximum number of times to spawn the child
+	// process again when it terminates due to receiving a signal, such
+	// as when killed by the operating system because it ran out of
+	// memory. Child processes that exit on their own are not retried,
+	// regardless of their exit code. The delay before each retry
+	// starts at 100 milliseconds and doubles after each attempt, and
+	// no further attempts are made once the context is done. The
+	// Response of the final attempt is returned. Note that Stdin is
+	// not rewound between attempts.
+	RetryOnSignal int
+
+	// SampleFDs, when true, periodically counts the file descriptors
+	// the child process has open while it runs, and reports the most
+	// recent count in Response.OpenFDs, to help diagnose file
+	// descriptor leaks. The count is taken every 10 milliseconds from
+	// the /proc file system, so it is a best effort approximation of
+	// the count shortly before the child process exits: descriptors
+	// opened or closed after the final sample are not reflected, and a
+	// child process that exits before the first sample reports zero.
+	// It is only supported on Linux; on other platforms Run returns an
+	// ErrInvalidRequest error.
+	SampleFDs bool
+
+	// Secrets maps the names of environment variables to secrets to
+	// pass to the child process without exposing them in its command
+	// line or environment, where other processes could observe them.
+	// Each secret is written to a file, and the path of that file is
+	// added to the environment of the child process, in a variable
+	// named by appending "_FILE" to its name, so Secrets of
+	// {"TOKEN": token} sets TOKEN_FILE. On Linux the file is memory
+	// backed and only available to the child process, and on other
+	// platforms it is a temporary file readable only by its owner. The
+	// files are removed after the child process exits. Names must be
+	// valid environment variable names.
+	Secrets map[string][]byte
+
+	// ShareProcessGroup, when true, ensures the child process remains
+	// in the process group of this process, so a signal sent to the
+	// group, such as SIGINT from a terminal when the user presses
+	// Control-C, reaches both of them. This is already the default
+	// unless ProcessGroup is set, so setting it only documents and
+	// guards that intent. A child process in its own process group, by
+	// contrast, is isolated from signals sent to the group of its
+	// parent, but must then be signaled separately. It is an error to
+	// set both ShareProcessGroup and ProcessGroup.
+	ShareProcessGroup bool
+
+	// SpillStdoutAfter, when positive, is the maximum number of bytes of
+	// standard output to capture in memory. Once the child process
+	// writes more than that, all of its standard output is moved to a
+	// new temporary file, which receives the remainder, and whose path
+	// is returned in Response.StdoutFile rather than the output being
+	// returned in Response.Stdout. The caller is responsible for
+	// removing the file. It is an error to set SpillStdoutAfter along
+	// with StdoutWriter, HashStdout, or DecompressStdout.
+	SpillStdoutAfter int64
+
+	// StartDelay is the potentially zero duration to wait before
+	// spawning the child process. When the context is done while
+	// waiting, the child process is not spawned and Run returns an
+	// ErrCanceled error.
+	StartDelay time.Duration
+
+	// StderrBuffer is the potentially nil bytes.Buffer into which the
+	// standard error of the child process is captured, allowing the
+	// caller to control its lifetime, for instance by pooling and
+	// reusing it. Run appends to StderrBuffer without resetting it, and
+	// Response.Stderr aliases the portion of it appended by Run, so
+	// Response.Stderr is only valid until StderrBuffer is modified.
+	// When nil, Run allocates a new buffer.
+	StderrBuffer *bytes.Buffer
+
+	// StderrHeadBytes, when positive, limits the standard error
+	// captured in Response.Stderr to the first StderrHeadBytes bytes the
+	// child process writes to it, discarding the rest while still
+	// counting it in Response.StderrBytesRead. Response.StderrTruncated
+	// reports whether any was discarded. The child process is neither
+	// blocked nor terminated when it writes more than that.
+	StderrHeadBytes int
+
+	// StderrTee is the potentially nil io.Writer that will receive a
+	// copy of everything the child process writes to its standard
+	// error, as it is written, in addition to it being captured in
+	// Response.Stderr. Set it to os.Stderr to show the output of the
+	// child process live while still capturing it. Writes are passed
+	// to it without any additional buffering, so when it is a
+	// *bufio.Writer the caller is responsible for flushing it after
+	// Run returns.
+	StderrTee io.Writer
+
+	// StderrWriter is the potentially nil io.Writer to which the
+	// standard error of the child process is written, as it is
+	// written, rather than being captured, like StdoutWriter is for the
+	// standard output. When set, Response.Stderr is nil. It is an error
+	// to set StderrWriter along with StderrBuffer or StderrHeadBytes.
+	StderrWriter io.Writer
+
+	// SuccessCodes, when not empty, lists the exit codes with which the
+	// child process succeeds. When it exits on its own with any other
+	// exit code, Response.Err is an ErrUnexpectedExit error. When
+	// empty, Run does not consider any exit code an error, which suits
+	// programs like grep that use non-zero exit codes to report
+	// results.
+	SuccessCodes []int
+
+	// SwapStdoutStderr, when true, exchanges the standard output and
+	// standard error of the child process, as though it wrote to each
+	// what it writes to the other. Every field of Request and Response
+	// that refers to standard output, such as StdoutWriter,
+	// MaxStdoutBytes, and Response.Stdout, then refers to what the child
+	// process writes to its standard error, and every field that refers
+	// to standard error refers to what it writes to its standard
+	// output. This is useful for programs whose primary output is
+	// written to standard error.
+	SwapStdoutStderr bool
+
+	// Terminator is the potentially nil Terminator used to terminate
+	// the child process when the context is done before the child
+	// process exits. When nil, the child process is sent a kill
+	// signal.
+	Terminator Terminator
+
+	// TimeStdoutLines, when true, records the elapsed time from when
+	// the child process was spawned until each line of its standard
+	// output was read in Response.StdoutLineTimes.
+	TimeStdoutLines bool
+
+	// TimedOutput, when true, records each chunk of output as it is
+	// read from the child process, along with the time it was read
+	// and the stream it was read from, in Response.TimedOutput.
+	TimedOutput bool
+
+	// Timeout, when positive, is the maximum duration the child process
+	// may run before it is killed, as though the context had been
+	// given that deadline, while the context remains available for
+	// cancellation. Whichever of the context and Timeout expires first
+	// terminates the child process, and the Response is the same as
+	// when the context deadline expires. The timeout starts after any
+	// StartDelay, and applies to each attempt made for Retry,
+	// RetryOnEmptyOutput, or RetryOnSignal. When the context is already
+	// done, Timeout has no effect, and Run does not spawn the child
+	// process, returning the same ErrSpawn error that it would without
+	// Timeout.
+	Timeout time.Duration
+
+	// UseTimeoutCommand, when true and either the context has a
+	// deadline or Timeout is set, runs the child process under the
+	// timeout(1) program, limited to the time remaining until the
+	// earlier deadline, as a safety net in addition to Run killing the
+	// child process. When the deadline expires, timeout sends SIGTERM
+	// to the command, then SIGKILL one second later when it is still
+	// running, and exits with code 124. When the context is done, the
+	// timeout program is sent SIGTERM, which it forwards to the
+	// command, rather than being killed, which would leave the command
+	// running. When there is no deadline, on Windows, or when the
+	// timeout program cannot be found in PATH, the child process is run
+	// directly. Note that Path is resolved by the timeout program
+	// rather than by Run, and that timeout programs other than the GNU
+	// coreutils and BusyBox versions may not accept the same
+	// arguments.
+	UseTimeoutCommand bool
+
+	// StdoutBuffer is the potentially nil bytes.Buffer into which the
+	// standard output of the child process is captured, allowing the
+	// caller to control its lifetime, for instance by pooling and
+	// reusing it. Run appends to StdoutBuffer without resetting it, and
+	// Response.Stdout aliases the portion of it appended by Run, so
+	// Response.Stdout is only valid until StdoutBuffer is modified.
+	// When nil, Run allocates a new buffer.
+	StdoutBuffer *bytes.Buffer
+
+	// StdoutGrep is the potentially nil regular expression that, when
+	// set, limits the standard output captured in Response.Stdout to
+	// the lines that match it, including their newline terminators,
+	// while Response.StdoutBytesRead and Response.StdoutLinesRead count
+	// all of it. Lines are matched without their newline terminators.
+	// Only the captured output is filtered; StdoutTee and the other
+	// records of the output of the child process receive all of it. It
+	// is an error to set both StdoutGrep and DecompressStdout.
+	StdoutGrep *regexp.Regexp
+
+	// StdoutHeadBytes, when positive, limits the standard output
+	// captured in Response.Stdout to the first StdoutHeadBytes bytes the
+	// child process writes to it, discarding the rest while still
+	// counting it in Response.StdoutBytesRead. Response.StdoutTruncated
+	// reports whether any was discarded. Unlike MaxStdoutBytes, the
+	// child process is not terminated when it writes more than that. It
+	// is an error to set StdoutHeadBytes along with StdoutWriter,
+	// HashStdout, StdoutGrep, SpillStdoutAfter, or DecompressStdout.
+	StdoutHeadBytes int
+
+	// StdoutTee is the potentially nil io.Writer that will receive a
+	// copy of everything the child process writes to its standard
+	// output, as it is written, in addition to it being captured in
+	// Response.Stdout. Set it to os.Stdout to show the output of the
+	// child process live while still capturing it. Writes are passed
+	// to it without any additional buffering, so when it is a
+	// *bufio.Writer the caller is responsible for flushing it after
+	// Run returns.
+	StdoutTee io.Writer
+
+	// StdoutTransform is the potentially nil function invoked once with
+	// the complete standard output captured in Response.Stdout, after
+	// any decoding and decompression, whose result is stored in
+	// Response.StdoutTransformed, leaving Response.Stdout unchanged.
+	// When it returns an error, Response.Err is set to an ErrTransform
+	// error, unless the child process already failed. It is an error to
+	// set StdoutTransform along with StdoutWriter, HashStdout, or
+	// SpillStdoutAfter.
+	StdoutTransform func([]byte) ([]byte, error)
+
+	// StdoutWriter is the potentially nil io.Writer to which the
+	// standard output of the child process is written, as it is
+	// written, rather than being captured, so output of any size may
+	// be streamed to a file or network connection without being held
+	// in memory. When set, Response.Stdout is nil. Like StdoutTee, it
+	// is written without any additional buffering. It is an error to
+	// set StdoutWriter along with StdoutBuffer, StdoutGrep,
+	// DecompressStdout, or HashStdout.
+	StdoutWriter io.Writer
+
+	// StdoutWriters is the optional list of destinations that each
+	// receive the lines of standard output of the child process
+	// selected by their filters, in addition to it being captured or
+	// written to StdoutWriter. Each line is written in a single call,
+	// once its newline terminator is read, or when the child process
+	// exits for a final line that lacks one. Lines are written to each
+	// destination in turn, without any additional buffering, so a slow
+	// destination blocks the others and, once the pipe fills, the child
+	// process, but no line is dropped. When a destination returns an
+	// error, Run stops copying the output and returns an ErrWait error.
+	StdoutWriters []FilteredWriter
 }
 
 // Run executes a system command.
@@ -72,37 +781,922 @@ type Request struct {
 // that specifies the signal it receives in Err.
 //
 // NOTE: If context.Context expires, Go will send termination signal
-// to spawned child process, and Response will have Code and Err set
-// in accordance with this case.
+// to spawned child process, and Response will have Code set to -1,
+// and Err set to an ErrTimeout error when the deadline of the context
+// passed, or an ErrCanceled error when the context was canceled. Both
+// wrap the ErrSignal error that describes the signal.
 //
 // 4. When the child program exits on its own and not due to receiving
 // a signal as described above, it returns Response with Code set
 // to the exit code of the child program, and Err set to nil.
 func (req *Request) Run(ctx context.Context) (*Response, error) {
-	var stderr, stdout bytes.Buffer
-	var err error
+	return req.runWith(ctx, runOptions{})
+}
 
-	cmd := exec.CommandContext(ctx, req.Path, req.Args...)
-	cmd.Dir = req.Dir
-	cmd.Env = req.Env
-	cmd.Stderr = &stderr
-	cmd.Stdout = &stdout
+// runOptions alter how run spawns a child process and collects its
+// output, for features not configured by fields of Request.
+type runOptions struct {
+	// start, when not nil, is invoked to start the child process in
+	// place of exec.Cmd.Start.
+	start func(context.Context, *exec.Cmd) error
+
+	// stderr and stdout, when not nil, receive the respective output
+	// of the child process in place of it being captured in the
+	// Response.
+	stderr, stdout io.Writer
+
+	// sink, when not nil, receives the output of the child process in
+	// place of it being captured in the Response.
+	sink *sinkWriter
+
+	// discard, when true, connects the output of the child process to
+	// the null device in place of capturing it, so none of it is
+	// copied through this process.
+	discard bool
+}
+
+// runWith runs req, retrying as configured.
+func (req *Request) runWith(ctx context.Context, opts runOptions) (*Response, error) {
+	resp, err := req.run(ctx, opts)
+
+	backoff := retryBackoff
+	attempts := 1
+	var signalRetries, emptyRetries int
+	for {
+		var delay time.Duration
+		switch {
+		case opts.sink.abortErr() != nil:
+			// The sink declined further output, so it must not be
+			// invoked again by another attempt.
+			return resp, err
+		case err == nil && signalRetries < req.RetryOnSignal && resp.Signaled():
+			signalRetries++
+			delay, backoff = backoff, 2*backoff
+		case err == nil && emptyRetries < req.RetryOnEmptyOutput && resp.emptyOutput():
+			emptyRetries++
+			delay, backoff = backoff, 2*backoff
+		case req.Retry.retry(attempts, resp, err):
+			delay = req.Retry.delay(attempts)
+			attempts++
+		default:
+			return resp, err
+		}
+		if sleep(ctx, delay) != nil {
+			return resp, err
+		}
+		resp, err = req.run(ctx, opts)
+	}
+}
+
+// run spawns the child process once and waits for it to exit.
+func (req *Request) run(ctx context.Context, opts runOptions) (*Response, error) {
+	var stderr, stdout *bytes.Buffer
+	var stderrOffset, stdoutOffset int
+	var stderrCount, stdoutCount byteCounter
+	var grep *grepWriter
+	var fanout *fanoutWriter
+	var stderrHead, stdoutHead *headWriter
+	var spill *spillWriter
+	var stdoutHash hash.Hash
+	var timed *timedRecorder
+	var lineTimes *lineTimer
+
+	if err := req.validate(); err != nil {
+		return nil, err
+	}
+	if req.CombineOutput && (opts.stderr != nil || opts.stdout != nil || opts.sink != nil) {
+		return nil, ErrInvalidRequest{Err: errors.New("cannot set CombineOutput when streaming output")}
+	}
+	if req.RequireOutput && opts.discard {
+		return nil, ErrInvalidRequest{Err: errors.New("cannot set RequireOutput when discarding output")}
+	}
+
+	events := newEventLog(req.Events)
+
+	if err := req.delay(ctx); err != nil {
+		return nil, err
+	}
 
-	if req.Stdin != nil {
-		cmd.Stdin = req.Stdin
+	if req.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, req.Timeout)
+		defer cancel()
 	}
 
-	if err = cmd.Start(); err != nil {
+	cmd := req.Command(ctx)
+
+	secrets, err := req.addSecrets(cmd)
+	defer secrets.release()
+	if err != nil {
 		return nil, ErrSpawn{Err: err}
 	}
 
-	err = cmd.Wait()
+	// kill is only invoked after the child process is spawned.
+	kill := func() { _ = cmd.Process.Kill() }
+
+	var stdinIdle *idleReader
+	if req.StdinIdleTimeout > 0 {
+		stdinIdle = newIdleReader(cmd.Stdin, req.StdinIdleTimeout, kill)
+		cmd.Stdin = stdinIdle
+	}
+
+	stderrDst, stdoutDst := opts.stderr, opts.stdout
+	if opts.sink != nil {
+		stderrDst = opts.sink.stream(StreamStderr)
+		stdoutDst = opts.sink.stream(StreamStdout)
+		opts.sink.kill = kill
+	}
+	if stderrDst == nil && req.StderrWriter != nil {
+		stderrDst = req.StderrWriter
+	}
+	if stderrDst == nil {
+		stderr = req.StderrBuffer
+		if stderr == nil {
+			stderr = new(bytes.Buffer)
+		}
+		stderrOffset = stderr.Len()
+		stderrDst = stderr
+		if limit := headLimit(req.StderrHeadBytes, req.MaxOutputBytes); limit > 0 {
+			stderrHead = &headWriter{w: stderr, remaining: limit}
+			stderrDst = stderrHead
+		}
+	}
+	if stdoutDst == nil && req.StdoutWriter != nil {
+		stdoutDst = req.StdoutWriter
+	}
+	if stdoutDst == nil && req.HashStdout != nil {
+		stdoutHash = req.HashStdout()
+		stdoutDst = stdoutHash
+	}
+	if stdoutDst == nil {
+		stdout = req.StdoutBuffer
+		if stdout == nil {
+			stdout = new(bytes.Buffer)
+		}
+		stdoutOffset = stdout.Len()
+		stdoutDst = stdout
+		if limit := headLimit(req.StdoutHeadBytes, req.MaxOutputBytes); limit > 0 && req.SpillStdoutAfter <= 0 {
+			stdoutHead = &headWriter{w: stdout, remaining: limit}
+			stdoutDst = stdoutHead
+		}
+		if req.SpillStdoutAfter > 0 {
+			spill = &spillWriter{buf: stdout, offset: stdoutOffset, threshold: req.SpillStdoutAfter}
+			stdoutDst = spill
+		}
+		if req.StdoutGrep != nil {
+			grep = &grepWriter{re: req.StdoutGrep, w: stdoutDst}
+			stdoutDst = grep
+		}
+	}
+
+	if len(req.StdoutWriters) > 0 {
+		fanout = &fanoutWriter{writers: req.StdoutWriters}
+	}
+	if req.TimedOutput {
+		timed = new(timedRecorder)
+	}
+	if req.TimeStdoutLines {
+		lineTimes = new(lineTimer)
+	}
+	percents := newPercentParser(req.PercentPattern, req.OnPercent)
+	cmd.Stderr = tee(stderrDst, &stderrCount, req.StderrTee, timed.stream(StreamStderr), events.stream(StreamStderr), percents.stream(StreamStderr))
+	cmd.Stdout = tee(stdoutDst, &stdoutCount, req.StdoutTee, timed.stream(StreamStdout), events.stream(StreamStdout), lineTimes.writer(), fanout.writer(), percents.stream(StreamStdout))
+
+	var combined *bytes.Buffer
+	if req.CombineOutput {
+		// When both refer to the same io.Writer, os/exec connects
+		// them to the same pipe, preserving the order of the output.
+		combined = new(bytes.Buffer)
+		stderr, stdout = nil, nil
+		cmd.Stderr, cmd.Stdout = combined, combined
+	}
+
+	var stdoutLimit *limitWriter
+	if req.MaxStdoutBytes > 0 {
+		stdoutLimit = &limitWriter{w: cmd.Stdout, remaining: req.MaxStdoutBytes, exceed: kill}
+		cmd.Stdout = stdoutLimit
+	}
+
+	// stop asks the child process to exit, and kills it if it has not
+	// exited once the grace period elapses.
+	stop := kill
+	if canUseGracePeriod {
+		grace := req.GracePeriod
+		if grace <= 0 {
+			grace = defaultGracePeriod
+		}
+		terminate := sendTerminate
+		if req.ProcessGroup {
+			terminate = terminateProcessGroup
+		}
+		stop = func() {
+			_ = terminate(cmd.Process)
+			time.AfterFunc(grace, kill)
+		}
+	}
+
+	var stderrLines, stdoutLines *lineLimitWriter
+	if req.MaxStderrLines > 0 {
+		stderrLines = &lineLimitWriter{w: cmd.Stderr, remaining: req.MaxStderrLines, exceed: stop}
+		cmd.Stderr = stderrLines
+	}
+	if req.MaxStdoutLines > 0 {
+		stdoutLines = &lineLimitWriter{w: cmd.Stdout, remaining: req.MaxStdoutLines, exceed: stop}
+		cmd.Stdout = stdoutLines
+	}
+
+	if req.SwapStdoutStderr {
+		cmd.Stderr, cmd.Stdout = cmd.Stdout, cmd.Stderr
+	}
+
+	if req.ReadBufferSize > 0 {
+		if req.CombineOutput {
+			// Both must remain the same io.Writer to share a pipe.
+			w := &bufferedCopier{w: cmd.Stdout, size: req.ReadBufferSize}
+			cmd.Stderr, cmd.Stdout = w, w
+		} else {
+			cmd.Stderr = &bufferedCopier{w: cmd.Stderr, size: req.ReadBufferSize}
+			cmd.Stdout = &bufferedCopier{w: cmd.Stdout, size: req.ReadBufferSize}
+		}
+	}
+
+	if opts.discard {
+		// Leaving both nil connects the child process to the null
+		// device.
+		cmd.Stderr, cmd.Stdout = nil, nil
+	}
+
+	if (req.HardTimeout > 0 || req.MaxStdoutBytes > 0) && cmd.WaitDelay == 0 {
+		// Processes the child process spawned may still hold its
+		// output open after it is killed, so bound how long Wait
+		// waits for them to close it.
+		cmd.WaitDelay = defaultGracePeriod
+	}
+
+	var pty *ptyCopier
+	if req.PTY {
+		if pty, err = usePTY(cmd); err != nil {
+			return nil, ErrSpawn{Err: err}
+		}
+	}
+
+	start := opts.start
+	if start == nil {
+		start = func(_ context.Context, cmd *exec.Cmd) error { return cmd.Start() }
+	}
+	if req.MaxChildProcesses > 0 {
+		start = limitChildProcessesStart(req.MaxChildProcesses, start)
+	}
+	if len(req.BlockSignals) > 0 {
+		start = blockSignalsStart(req.BlockSignals, start)
+	}
+
+	events.spawning()
+	lineTimes.start()
+	started := time.Now()
+	program := cmd.Path // before any wrapper applied by start replaces it
+	if err := start(ctx, cmd); err != nil {
+		pty.start(false)
+		events.abort()
+		return nil, ErrSpawn{Err: req.explainSpawnError(program, err)}
+	}
+	pty.start(true)
+	events.start(cmd.Process.Pid)
+	// The process cannot be reaped before Wait is invoked, so its
+	// status remains available until then.
+	var kernelStarted time.Time
+	if req.RecordKernelStartTime {
+		kernelStarted, _ = processStartTime(cmd.Process.Pid)
+	}
+	var oomKillsBefore oomKills
+	if req.DetectOOMKill {
+		oomKillsBefore = countOOMKills(cmd.Process.Pid)
+	}
+	if req.OnStart != nil {
+		req.OnStart(cmd.Process.Pid)
+	}
+
+	var fds *fdSampler
+	if req.SampleFDs {
+		fds = sampleFDs(cmd.Process.Pid)
+	}
+
+	if req.AlarmAfter > 0 {
+		timer := time.AfterFunc(req.AlarmAfter, func() { _ = sendAlarm(cmd.Process) })
+		defer timer.Stop()
+	}
+
+	var hardTimedOut atomic.Bool
+	if req.HardTimeout > 0 {
+		timer := time.AfterFunc(req.HardTimeout, func() {
+			hardTimedOut.Store(true)
+			_ = cmd.Process.Kill()
+		})
+		defer timer.Stop()
+	}
+
+	werr := cmd.Wait()
+	if errors.Is(werr, errOutputLimit) {
+		// Copying the output stops once MaxStdoutBytes is exceeded,
+		// which is reported as an ErrOutputTooLarge error below.
+		werr = nil
+	}
+	code, err := exitStatus(werr)
+	duration := time.Since(started)
+	var oomKilled bool
+	if se, ok := err.(ErrSignal); ok && se.Signal == syscall.SIGKILL && req.DetectOOMKill {
+		oomKilled = oomKillsBefore.increased()
+	}
+	var kernelDuration time.Duration
+	if !kernelStarted.IsZero() {
+		kernelDuration = started.Add(duration).Sub(kernelStarted)
+	}
+	openFDs := fds.stop()
+	if req.ProcessGroup && (req.GracePeriod > 0 || req.CLIFriendlyCancel) && ctx.Err() != nil {
+		// Wait only kills the child process once the grace period
+		// elapses, so kill whatever remains of its process group.
+		_ = killProcessGroup(cmd.Process)
+	}
+	if perr := pty.wait(); perr != nil && err == nil && !errors.Is(perr, errOutputLimit) {
+		err = ErrWait{Err: perr}
+	}
+	if grep != nil {
+		if ferr := grep.flush(); ferr != nil && err == nil {
+			err = ErrWait{Err: ferr}
+		}
+	}
+	if ferr := fanout.flush(); ferr != nil && err == nil {
+		err = ErrWait{Err: ferr}
+	}
+	stdoutFile, serr := spill.close()
+	if serr != nil && err == nil {
+		err = ErrWait{Err: serr}
+	}
+	if _, ok := err.(ErrWait); ok {
+		if stdoutFile != "" {
+			_ = os.Remove(stdoutFile)
+		}
+		events.exit(code, err)
+		return nil, err
+	}
+	if err == nil {
+		code, err = req.exitCode(code)
+	}
+	if _, ok := err.(ErrSignal); ok && hardTimedOut.Load() {
+		err = ErrHardTimeout{Err: err}
+	} else if ok && stdinIdle.timedOut() {
+		err = ErrStdinIdleTimeout{Err: err}
+	} else {
+		err = contextError(ctx, err)
+	}
+	if stdoutLimit.limited() {
+		err = ErrOutputTooLarge{Err: err, Limit: req.MaxStdoutBytes, Stream: StreamStdout}
+	}
+	if serr := opts.sink.abortErr(); serr != nil {
+		err = ErrSinkAborted{Err: serr}
+	}
+	if err == nil && len(req.SuccessCodes) > 0 && !slices.Contains(req.SuccessCodes, code) {
+		err = ErrUnexpectedExit{Code: code}
+	}
+	if err == nil && code == 0 && len(req.ExpectFiles) > 0 {
+		if missing := req.missingFiles(); len(missing) > 0 {
+			err = ErrMissingOutputFiles{Paths: missing}
+		}
+	}
+	if err == nil && req.RequireOutput {
+		stream, output := StreamStdout, stdoutCount
+		if req.RequireOutputStream == StreamStderr {
+			stream, output = StreamStderr, stderrCount
+		}
+		if output == 0 {
+			err = ErrNoOutput{Stream: stream}
+		}
+	}
+	budgetExceeded := req.LatencyBudget > 0 && duration > req.LatencyBudget
+	if budgetExceeded {
+		events.budgetExceeded(duration)
+	}
+	events.exit(code, err)
 
 	resp := &Response{
-		Stdout: stdout.Bytes(),
-		Stderr: stderr.Bytes(),
+		Code:            code,
+		Err:             err,
+		Started:         started,
+		Duration:        duration,
+		KernelStartTime: kernelStarted,
+		KernelDuration:  kernelDuration,
+		UserTime:        cmd.ProcessState.UserTime(),
+		SystemTime:      cmd.ProcessState.SystemTime(),
+		MaxRSS:          maxRSS(cmd.ProcessState),
+		OOMKilled:       oomKilled,
+	}
+	resp.BudgetExceeded = budgetExceeded
+	resp.OpenFDs = openFDs
+	if combined != nil {
+		resp.Combined = combined.Bytes()
+	}
+	if stderr != nil {
+		resp.Stderr = stderr.Bytes()[stderrOffset:]
+	}
+	if stdoutFile != "" {
+		resp.StdoutFile = stdoutFile
+	} else if stdout != nil {
+		resp.Stdout = stdout.Bytes()[stdoutOffset:]
+		if req.DecodeBase64Stdout {
+			if b, err := decodeBase64(resp.Stdout); err != nil {
+				if resp.Err == nil {
+					resp.Err = ErrDecodeBase64{Err: err}
+				}
+			} else {
+				resp.Stdout = b
+			}
+		}
+		if req.DecompressStdout {
+			if b, err := gunzip(resp.Stdout); err != nil {
+				if resp.Err == nil {
+					resp.Err = ErrDecompress{Err: err}
+				}
+			} else {
+				resp.Stdout = b
+			}
+		}
+		if req.StdoutTransform != nil {
+			if b, err := req.StdoutTransform(resp.Stdout); err != nil {
+				if resp.Err == nil {
+					resp.Err = ErrTransform{Err: err}
+				}
+			} else {
+				resp.StdoutTransformed = b
+			}
+		}
+	}
+	if grep != nil {
+		resp.StdoutBytesRead = int64(stdoutCount)
+		resp.StdoutLinesRead = grep.lines
+	}
+	if stdoutHash != nil {
+		resp.StdoutBytesRead = int64(stdoutCount)
+		resp.StdoutHash = stdoutHash.Sum(nil)
+	}
+	if stderrHead != nil {
+		resp.StderrBytesRead = int64(stderrCount)
+		resp.StderrTruncated = stderrHead.truncated()
+	}
+	if stdoutHead != nil {
+		resp.StdoutBytesRead = int64(stdoutCount)
+		resp.StdoutTruncated = stdoutHead.truncated()
+	}
+	if stderrLines.limited() {
+		resp.StderrTruncated = true
+	}
+	if stdoutLines.limited() {
+		resp.StdoutTruncated = true
+	}
+	if timed != nil {
+		resp.TimedOutput = timed.chunks
+	}
+	resp.StdoutLineTimes = lineTimes.result()
+	// Only now is the final Err known.
+	resp.Outcome = outcome(resp.Code, resp.Err, ctx.Err())
+	return resp, nil
+}
+
+// Validate returns an ErrInvalidRequest error when req is misconfigured,
+// without spawning its child process, for example when loading a
+// configuration. In addition to the checks Run performs before spawning
+// the child process, it checks that Path is not empty, that Dir, when
+// not empty, is an existing directory on this host, and that each
+// element of Env contains an '=' sign.
+func (req *Request) Validate() error {
+	if req.Path == "" {
+		return ErrInvalidRequest{Err: errors.New("cannot leave Path empty")}
+	}
+	if req.Dir != "" {
+		fi, err := os.Stat(req.Dir)
+		if err != nil {
+			return ErrInvalidRequest{Err: err}
+		}
+		if !fi.IsDir() {
+			return ErrInvalidRequest{Err: errors.New("Dir is not a directory: " + strconv.Quote(req.Dir))}
+		}
+	}
+	for _, kv := range req.Env {
+		if !strings.Contains(kv, "=") {
+			return ErrInvalidRequest{Err: errors.New("invalid Env entry: " + strconv.Quote(kv))}
+		}
+	}
+	return req.validate()
+}
+
+// validate returns an ErrInvalidRequest error when req is configured in
+// a way that prevents spawning its child process.
+func (req *Request) validate() error {
+	var stdins int
+	if req.Stdin != nil || req.StdinBytes != nil || req.StdinString != "" {
+		stdins++
+	}
+	if len(req.StdinReaders) > 0 {
+		stdins++
+	}
+	if req.StdinReaderAt != nil {
+		stdins++
+	}
+	if stdins > 1 {
+		return ErrInvalidRequest{Err: errors.New("cannot set more than one of Stdin, StdinReaders, and StdinReaderAt")}
+	}
+	if req.MaxChildProcesses > 0 && !canLimitChildProcesses {
+		return ErrInvalidRequest{Err: errors.New("cannot set MaxChildProcesses on this platform")}
+	}
+	if req.AlarmAfter > 0 && !canSendAlarm {
+		return ErrInvalidRequest{Err: errors.New("cannot set AlarmAfter on this platform")}
+	}
+	if req.DecompressStdout && req.StdoutGrep != nil {
+		return ErrInvalidRequest{Err: errors.New("cannot set both DecompressStdout and StdoutGrep")}
+	}
+	if req.HashStdout != nil && (req.StdoutBuffer != nil || req.StdoutGrep != nil || req.DecompressStdout) {
+		return ErrInvalidRequest{Err: errors.New("cannot set HashStdout along with StdoutBuffer, StdoutGrep, or DecompressStdout")}
+	}
+	if req.StdoutWriter != nil && (req.StdoutBuffer != nil || req.StdoutGrep != nil || req.DecompressStdout || req.HashStdout != nil) {
+		return ErrInvalidRequest{Err: errors.New("cannot set StdoutWriter along with StdoutBuffer, StdoutGrep, DecompressStdout, or HashStdout")}
+	}
+	for name := range req.EnvMap {
+		if name == "" || strings.ContainsAny(name, "=\x00") {
+			return ErrInvalidRequest{Err: errors.New("invalid EnvMap name: " + strconv.Quote(name))}
+		}
+	}
+	for name := range req.Secrets {
+		if name == "" || strings.ContainsAny(name, "=\x00") {
+			return ErrInvalidRequest{Err: errors.New("invalid secret name: " + strconv.Quote(name))}
+		}
+	}
+	if req.PercentPattern != nil && req.OnPercent == nil {
+		return ErrInvalidRequest{Err: errors.New("cannot set PercentPattern without OnPercent")}
+	}
+	if req.StdinIdleTimeout > 0 && stdins == 0 {
+		return ErrInvalidRequest{Err: errors.New("cannot set StdinIdleTimeout without Stdin, StdinReaders, or StdinReaderAt")}
+	}
+	if req.ReadBufferSize != 0 && req.ReadBufferSize < minReadBufferSize {
+		return ErrInvalidRequest{Err: errors.New("cannot set ReadBufferSize below " + strconv.Itoa(minReadBufferSize))}
+	}
+	if req.StderrWriter != nil && (req.StderrBuffer != nil || req.StderrHeadBytes > 0) {
+		return ErrInvalidRequest{Err: errors.New("cannot set StderrWriter along with StderrBuffer or StderrHeadBytes")}
+	}
+	if req.SpillStdoutAfter > 0 && (req.StdoutWriter != nil || req.HashStdout != nil || req.DecompressStdout) {
+		return ErrInvalidRequest{Err: errors.New("cannot set SpillStdoutAfter along with StdoutWriter, HashStdout, or DecompressStdout")}
+	}
+	if req.StdoutHeadBytes > 0 && (req.StdoutWriter != nil || req.HashStdout != nil || req.StdoutGrep != nil || req.SpillStdoutAfter > 0 || req.DecompressStdout) {
+		return ErrInvalidRequest{Err: errors.New("cannot set StdoutHeadBytes along with StdoutWriter, HashStdout, StdoutGrep, SpillStdoutAfter, or DecompressStdout")}
+	}
+	if req.DecodeBase64Stdout && (req.StdoutWriter != nil || req.HashStdout != nil || req.StdoutGrep != nil || req.SpillStdoutAfter > 0 || req.StdoutHeadBytes > 0) {
+		return ErrInvalidRequest{Err: errors.New("cannot set DecodeBase64Stdout along with StdoutWriter, HashStdout, StdoutGrep, SpillStdoutAfter, or StdoutHeadBytes")}
+	}
+	if req.StdoutTransform != nil && (req.StdoutWriter != nil || req.HashStdout != nil || req.SpillStdoutAfter > 0) {
+		return ErrInvalidRequest{Err: errors.New("cannot set StdoutTransform along with StdoutWriter, HashStdout, or SpillStdoutAfter")}
+	}
+	if req.ProcessGroup && !canUseProcessGroup {
+		return ErrInvalidRequest{Err: errors.New("cannot set ProcessGroup on this platform")}
+	}
+	if req.PTY && !canUsePTY {
+		return ErrInvalidRequest{Err: errors.New("cannot set PTY on this platform")}
+	}
+	if req.PTY && (req.CombineOutput || req.ProcessGroup || req.ShareProcessGroup || req.SwapStdoutStderr) {
+		return ErrInvalidRequest{Err: errors.New("cannot set PTY along with CombineOutput, ProcessGroup, ShareProcessGroup, or SwapStdoutStderr")}
+	}
+	if req.ProcessGroup && req.ShareProcessGroup {
+		return ErrInvalidRequest{Err: errors.New("cannot set both ProcessGroup and ShareProcessGroup")}
+	}
+	if req.CLIFriendlyCancel && !canUseGracePeriod {
+		return ErrInvalidRequest{Err: errors.New("cannot set CLIFriendlyCancel on this platform")}
+	}
+	if req.GracePeriod > 0 && !canUseGracePeriod {
+		return ErrInvalidRequest{Err: errors.New("cannot set GracePeriod on this platform")}
+	}
+	if req.CombineOutput && req.separatesOutput() {
+		return ErrInvalidRequest{Err: errors.New("cannot set CombineOutput along with fields that handle standard output or standard error separately")}
+	}
+	if req.RetryOnEmptyOutput > 0 && (req.StdoutWriter != nil || req.HashStdout != nil) {
+		return ErrInvalidRequest{Err: errors.New("cannot set RetryOnEmptyOutput along with StdoutWriter or HashStdout")}
+	}
+	if req.DumpOnTimeout && !canDumpOnTimeout {
+		return ErrInvalidRequest{Err: errors.New("cannot set DumpOnTimeout on this platform")}
+	}
+	if len(req.BlockSignals) > 0 && !canBlockSignals {
+		return ErrInvalidRequest{Err: errors.New("cannot set BlockSignals on this platform")}
+	}
+	for _, sig := range req.BlockSignals {
+		if sig < 1 || sig > 64 {
+			return ErrInvalidRequest{Err: errors.New("cannot block signal " + strconv.Itoa(int(sig)))}
+		}
+	}
+	if req.DetectOOMKill && !canDetectOOMKill {
+		return ErrInvalidRequest{Err: errors.New("cannot set DetectOOMKill on this platform")}
+	}
+	if req.SampleFDs && !canSampleFDs {
+		return ErrInvalidRequest{Err: errors.New("cannot set SampleFDs on this platform")}
+	}
+	if req.NewPIDNamespace && !canUseNewPIDNamespace {
+		return ErrInvalidRequest{Err: errors.New("cannot set NewPIDNamespace on this platform")}
+	}
+	if req.ExpandEnv {
+		if _, err := expandEnv(req.EnvList()); err != nil {
+			return ErrInvalidRequest{Err: err}
+		}
+	}
+	return nil
+}
+
+// separatesOutput returns true when any field of req handles the
+// standard output or standard error of the child process separately from
+// the other.
+func (req *Request) separatesOutput() bool {
+	return req.StderrBuffer != nil || req.StderrHeadBytes > 0 || req.StderrTee != nil || req.StderrWriter != nil ||
+		req.MaxStderrLines > 0 || req.MaxStdoutLines > 0 ||
+		req.StdoutBuffer != nil || req.StdoutGrep != nil || req.StdoutHeadBytes > 0 ||
+		req.StdoutTee != nil || req.StdoutTransform != nil || req.StdoutWriter != nil ||
+		len(req.StdoutWriters) > 0 || req.DecodeBase64Stdout || req.DecompressStdout || req.HashStdout != nil ||
+		req.MaxStdoutBytes > 0 || req.RequireOutput || req.SpillStdoutAfter > 0 ||
+		req.TimedOutput || req.TimeStdoutLines || req.Events != nil || req.MaxOutputBytes > 0 ||
+		req.OnPercent != nil
+}
+
+// contextError returns err wrapped in an ErrTimeout error when err is
+// an ErrSignal error and the deadline of ctx passed, or wrapped in an
+// ErrCanceled error when err is an ErrSignal error and ctx was
+// canceled. Otherwise it returns err.
+func contextError(ctx context.Context, err error) error {
+	if _, ok := err.(ErrSignal); ok {
+		switch ctx.Err() {
+		case context.DeadlineExceeded:
+			return ErrTimeout{Err: err}
+		case context.Canceled:
+			return ErrCanceled{Err: err}
+		}
+	}
+	return err
+}
+
+// delay waits for req.StartDelay to elapse, returning an ErrCanceled
+// error if the context is done before then.
+func (req *Request) delay(ctx context.Context) error {
+	return sleep(ctx, req.StartDelay)
+}
+
+// sleep waits for d to elapse, returning an ErrCanceled error if the
+// context is done before then.
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ErrCanceled{Err: ctx.Err()}
+	case <-timer.C:
+		return nil
+	}
+}
+
+// Command returns the exec.Cmd that Run would start to spawn the child
+// process described by req, with its Path, Args, Dir, Env, Stdin, and
+// cancellation configured as Run would configure them, but does not
+// start it, so the caller may adjust fields Request does not expose,
+// such as SysProcAttr or ExtraFiles, before starting it. Its Stdout and
+// Stderr are StdoutWriter and StderrWriter when set, otherwise
+// StdoutBuffer and StderrBuffer when set, otherwise a new *bytes.Buffer
+// each, or a single *bytes.Buffer shared by both when CombineOutput is
+// true. Command does not validate req, so invoke Validate first, and
+// the fields that Run handles while the child process runs, such as
+// Timeout, Delay, Retry, Secrets, and those that filter or limit the
+// output, as well as BlockSignals and MaxChildProcesses, which Run
+// applies when it starts the child process, have no effect on the
+// exec.Cmd. Any error locating the program is returned when it is
+// started.
+func (req *Request) Command(ctx context.Context) *exec.Cmd {
+	env := req.EnvList()
+	if req.ExpandEnv {
+		// Any error was already reported by validate.
+		env, _ = expandEnv(env)
+	}
+	if req.InheritEnv {
+		env = mergeEnv(os.Environ(), env)
+	}
+
+	path, args := req.path(env), req.Args
+	var lookErr error
+	if req.ResolvePath {
+		if resolved, err := req.lookPath(path, env); err != nil {
+			lookErr = err
+		} else {
+			path = resolved
+		}
 	}
 
+	var underTimeout bool
+	if req.UseTimeoutCommand {
+		path, args, underTimeout = timeoutCommand(ctx, path, args)
+	}
+
+	cmd := exec.CommandContext(ctx, path, args...)
+	if lookErr != nil {
+		// Start returns this error without spawning the child process.
+		cmd.Err = lookErr
+	}
+	cmd.Dir = req.Dir
+	cmd.Env = env
+
+	cmd.Stdin = req.StdinReader()
+	if cmd.Stdin != nil && req.StdinTee != nil {
+		cmd.Stdin = io.TeeReader(cmd.Stdin, req.StdinTee)
+	}
+
+	switch {
+	case req.CombineOutput:
+		combined := new(bytes.Buffer)
+		cmd.Stdout, cmd.Stderr = combined, combined
+	default:
+		cmd.Stdout = outputWriter(req.StdoutWriter, req.StdoutBuffer)
+		cmd.Stderr = outputWriter(req.StderrWriter, req.StderrBuffer)
+	}
+
+	if req.NewPIDNamespace {
+		useNewPIDNamespace(cmd)
+	}
+
+	if req.ShareProcessGroup {
+		shareProcessGroup(cmd)
+	}
+
+	if req.ProcessGroup {
+		useProcessGroup(cmd)
+		cmd.Cancel = func() error { return killProcessGroup(cmd.Process) }
+	}
+
+	if underTimeout {
+		cmd.Cancel = terminateTimeoutCommand(cmd)
+	}
+
+	if req.GracePeriod > 0 {
+		switch {
+		case req.ProcessGroup:
+			cmd.Cancel = func() error { return terminateProcessGroup(cmd.Process) }
+		case !underTimeout:
+			cmd.Cancel = func() error { return sendTerminate(cmd.Process) }
+		}
+		// After invoking Cancel, Wait kills the child process if it
+		// has not exited once WaitDelay elapses.
+		cmd.WaitDelay = req.GracePeriod
+	}
+
+	if req.CLIFriendlyCancel {
+		grace := req.GracePeriod
+		if grace <= 0 {
+			grace = defaultGracePeriod
+		}
+		interrupt, terminate := sendInterrupt, sendTerminate
+		if req.ProcessGroup {
+			interrupt, terminate = interruptProcessGroup, terminateProcessGroup
+		}
+		cmd.Cancel = func() error {
+			time.AfterFunc(grace, func() { _ = terminate(cmd.Process) })
+			return interrupt(cmd.Process)
+		}
+		// After invoking Cancel, Wait kills the child process if it
+		// has not exited once WaitDelay elapses.
+		cmd.WaitDelay = 2 * grace
+	}
+
+	if req.Terminator != nil {
+		cmd.Cancel = func() error {
+			// The context is already done when this is invoked, so
+			// provide the terminator one that retains its values but
+			// is not itself done.
+			return req.Terminator.Terminate(context.WithoutCancel(ctx), cmd.Process)
+		}
+	}
+
+	if req.DumpOnTimeout {
+		terminate := cmd.Cancel
+		cmd.Cancel = func() error {
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				dumpBeforeTerminate(cmd.Process)
+			}
+			return terminate()
+		}
+	}
+
+	return cmd
+}
+
+// outputWriter returns w when it is not nil, otherwise buf when it is
+// not nil, otherwise a new bytes.Buffer.
+func outputWriter(w io.Writer, buf *bytes.Buffer) io.Writer {
+	switch {
+	case w != nil:
+		return w
+	case buf != nil:
+		return buf
+	default:
+		return new(bytes.Buffer)
+	}
+}
+
+// StdinReader returns the io.Reader from which the child process reads
+// its standard input, or nil when it reads from the null device. Stdin
+// takes precedence over StdinBytes, which takes precedence over
+// StdinString. Each invocation returns a new io.Reader for StdinBytes,
+// StdinString, and StdinReaderAt, but not for Stdin or StdinReaders,
+// which are consumed as they are read.
+func (req *Request) StdinReader() io.Reader {
+	switch {
+	case req.Stdin != nil:
+		return req.Stdin
+	case req.StdinBytes != nil:
+		return bytes.NewReader(req.StdinBytes)
+	case req.StdinString != "":
+		return strings.NewReader(req.StdinString)
+	case len(req.StdinReaders) > 0:
+		return io.MultiReader(req.StdinReaders...)
+	case req.StdinReaderAt != nil:
+		return io.NewSectionReader(req.StdinReaderAt, req.StdinOffset, req.StdinLength)
+	default:
+		return nil
+	}
+}
+
+// missingFiles returns the paths in req.ExpectFiles that do not exist.
+func (req *Request) missingFiles() []string {
+	var missing []string
+	for _, name := range req.ExpectFiles {
+		path := name
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(req.Dir, path)
+		}
+		if _, err := os.Stat(path); err != nil {
+			missing = append(missing, name)
+		}
+	}
+	return missing
+}
+
+// exitCode returns the exit code read from req.ExitCodeFile, or code
+// when that is not set or does not hold an integer. When it holds an
+// integer outside the range of exit codes, it returns code and an
+// ErrInvalidExitCode error.
+func (req *Request) exitCode(code int) (int, error) {
+	if req.ExitCodeFile == "" {
+		return code, nil
+	}
+	name := req.ExitCodeFile
+	if !filepath.IsAbs(name) {
+		name = filepath.Join(req.Dir, name)
+	}
+	buf, err := os.ReadFile(name)
+	if err != nil {
+		return code, nil
+	}
+	c, err := strconv.Atoi(strings.TrimSpace(string(buf)))
+	if err != nil {
+		return code, nil
+	}
+	if c < 0 || c > 255 {
+		return code, ErrInvalidExitCode{Code: c, Path: req.ExitCodeFile}
+	}
+	return c, nil
+}
+
+// tee returns an io.Writer that writes to w and each non-nil io.Writer
+// in ts, or just w when there are none. When the returned io.Writer is
+// not an *os.File, os/exec copies the child output to it from a
+// dedicated goroutine, so a slow writer delays, but does not deadlock,
+// the child process.
+func tee(w io.Writer, ts ...io.Writer) io.Writer {
+	writers := []io.Writer{w}
+	for _, t := range ts {
+		if t != nil {
+			writers = append(writers, t)
+		}
+	}
+	if len(writers) == 1 {
+		return w
+	}
+	return io.MultiWriter(writers...)
+}
+
+// byteCounter is an io.Writer that counts the bytes written to it.
+type byteCounter int64
+
+func (bc *byteCounter) Write(p []byte) (int, error) {
+	*bc += byteCounter(len(p))
+	return len(p), nil
+}
+
+// exitStatus interprets the error returned from waiting for a child
+// process to exit. It returns the exit code of the child process,
+// along with an ErrSignal when the child process terminated due to
+// receiving a signal. It returns an ErrWait when it was unable to
+// collect the exit status of the child process.
+func exitStatus(err error) (int, error) {
 	// Go standard library interprets whether a child program was
 	// successful based on its exit code. However many programs this
 	// expects to invoke work properly and return information in the
@@ -113,30 +1707,41 @@ func (req *Request) Run(ctx context.Context) (*Response, error) {
 	// code not due to a signal is not itself an error.
 	switch e := err.(type) {
 	case nil:
-		// happy case: note Code is already 0 which is exit code of program
-		return resp, nil
+		// happy case: exit code of program is 0
+		return 0, nil
 	case exitCoder:
 		// Go standard library returns an error that implements
 		// exitCoder when the child process either returns a non-zero
 		// exit code, which includes when the process terminates from
 		// a signal.
-		resp.Code = e.ExitCode()
-		if resp.Code == -1 {
+		code := e.ExitCode()
+		if code == -1 {
 			// Go standard library returns exit code -1 when program
 			// has either not yet exited, or when it was terminated by
 			// a signal. Because this library only checks the exit
 			// code after the child program exits, it is only -1 when
 			// the child program exited due to receiving a signal.
-			resp.Err = ErrSignal{Err: err}
+			sig, _ := signalNumber(err)
+			return code, ErrSignal{Err: err, Signal: syscall.Signal(sig)}
 		}
-		return resp, nil
+		return code, nil
 	default:
 		// Some other meta error due to trying to manage child
 		// process.
-		return nil, ErrWait{Err: err}
+		return 0, ErrWait{Err: err}
 	}
 }
 
+// defaultGracePeriod is how long CLIFriendlyCancel, MaxStderrLines, and
+// MaxStdoutLines wait after each signal before sending the next one,
+// and how long Run waits for output after HardTimeout or MaxStdoutBytes,
+// when GracePeriod is not positive.
+const defaultGracePeriod = time.Second
+
+// retryBackoff is the delay before the first retry of a child process
+// that terminated due to receiving a signal, or that produced no output.
+const retryBackoff = 100 * time.Millisecond
+
 type exitCoder interface {
 	ExitCode() int
 }
@@ -147,27 +1752,380 @@ type Response struct {
 	// the child process terminated without being sent a signal. Err
 	// will be non-nil when the program could not properly spawn or
 	// collect the exit status of the child process, or when the child
-	// process exited as a result of receiving a signal.
+	// process exited as a result of receiving a signal. Err will be an
+	// ErrNoOutput when Request.RequireOutput is true and the child
+	// process did not write to the required stream, and will be an
+	// ErrMissingOutputFiles when the child process did not create all
+	// of Request.ExpectFiles.
 	Err error
 
 	// Stderr will be a potentially empty slice of bytes that
 	// represent whatever the child process wrote to its standard
-	// error file stream.
+	// error file stream, or nil when it was not captured because
+	// Request.StderrWriter was set.
 	Stderr []byte
 
 	// Stdout will be a potentially empty slice of bytes that
 	// represent whatever the child process wrote to its standard
-	// output file stream.
+	// output file stream, or nil when it was not captured because
+	// Request.StdoutWriter or Request.HashStdout was set, or was
+	// spilled to StdoutFile.
 	Stdout []byte
 
+	// Outcome describes how the child process finished.
+	Outcome Outcome
+
+	// BudgetExceeded will be true when Request.LatencyBudget is
+	// positive and Duration exceeds it, or false otherwise.
+	BudgetExceeded bool
+
+	// Combined will be everything the child process wrote to both its
+	// standard output and standard error, interleaved in the order it
+	// was written when Request.CombineOutput is true, or in the order it
+	// was read when requested by RunLogged, or nil otherwise.
+	Combined []byte
+
+	// Duration will be how long the child process ran, from just before
+	// it was spawned until it exited.
+	Duration time.Duration
+
+	// KernelStartTime will be when the operating system reports the
+	// child process was created, when Request.RecordKernelStartTime is
+	// true and the platform reports it, or the zero Time otherwise.
+	// Unlike Started, which is read by this process before it asks the
+	// operating system to spawn the child process, it excludes the time
+	// spent preparing to spawn it, but has a coarser resolution, of 10
+	// milliseconds on Linux.
+	KernelStartTime time.Time
+
+	// KernelDuration will be how long the child process ran, from
+	// KernelStartTime until it exited, when KernelStartTime is not the
+	// zero Time, or zero otherwise. It is shorter than Duration by the
+	// time this process took to spawn the child process, within the
+	// resolution of KernelStartTime.
+	KernelDuration time.Duration
+
+	// UserTime will be the CPU time the child process spent executing
+	// in user mode.
+	UserTime time.Duration
+
+	// SystemTime will be the CPU time the child process spent executing
+	// in kernel mode.
+	SystemTime time.Duration
+
+	// MaxRSS will be the maximum resident set size of the child process
+	// in bytes, or zero on platforms that do not report it.
+	MaxRSS int64
+
+	// OOMKilled will be true when Request.DetectOOMKill is true, the
+	// child process was terminated by the kill signal, and the kernel
+	// killed a process for lack of memory while it ran, in which case
+	// it was most likely the child process. It is a best effort
+	// heuristic. It compares the oom_kill count of memory.oom_control
+	// or memory.events of the memory cgroup the child process started
+	// in, or of /proc/vmstat when that cannot be read, before and after
+	// it runs, which are readable without any special permission,
+	// unlike the kernel log. It may therefore be true when another
+	// process in the same memory cgroup, or on the same host, was
+	// killed for lack of memory while the child process was killed by
+	// something else, such as the kill signal Run sends when the
+	// context is done, and it is false on kernels older than 4.13,
+	// which do not keep these counts, or when /proc is not mounted.
+	OOMKilled bool
+
+	// OpenFDs will be the most recent count of the file descriptors the
+	// child process had open before it exited, when Request.SampleFDs
+	// is true, or zero otherwise.
+	OpenFDs int
+
+	// Started will be the time just before the child process was
+	// spawned.
+	Started time.Time
+
+	// StderrBytesRead will be the number of bytes the child process
+	// wrote to its standard error, including those excluded from
+	// Stderr, when Request.StderrHeadBytes or Request.MaxOutputBytes is
+	// positive, or zero otherwise.
+	StderrBytesRead int64
+
+	// StderrTruncated will be true when the child process wrote more to
+	// its standard error than Request.StderrHeadBytes or
+	// Request.MaxOutputBytes permits capturing, or more lines than
+	// Request.MaxStderrLines permits, or false otherwise.
+	StderrTruncated bool
+
+	// StdoutBytesRead will be the number of bytes the child process
+	// wrote to its standard output, including those excluded from
+	// Stdout, when either Request.StdoutGrep or Request.HashStdout is
+	// not nil, or Request.StdoutHeadBytes or Request.MaxOutputBytes is
+	// positive, or zero otherwise.
+	StdoutBytesRead int64
+
+	// StdoutFile will be the path of the temporary file holding the
+	// standard output of the child process, when it exceeded
+	// Request.SpillStdoutAfter, in which case Stdout is nil, or the
+	// empty string otherwise. The caller is responsible for removing
+	// the file.
+	StdoutFile string
+
+	// StdoutHash will be the digest of everything the child process
+	// wrote to its standard output, when Request.HashStdout is not nil,
+	// or nil otherwise.
+	StdoutHash []byte
+
+	// StdoutLinesRead will be the number of lines the child process
+	// wrote to its standard output, including a final line that lacks
+	// a newline terminator and those excluded from Stdout, when
+	// Request.StdoutGrep is not nil, or zero otherwise.
+	StdoutLinesRead int
+
+	// StdoutLineTimes will be the elapsed time from when the child
+	// process was spawned until each line of its standard output was
+	// read, aligned with the lines returned by StdoutLines, when
+	// Request.TimeStdoutLines is true, or nil otherwise.
+	StdoutLineTimes []time.Duration
+
+	// StdoutTransformed will be the result of Request.StdoutTransform
+	// applied to Stdout, when it is not nil and succeeds, or nil
+	// otherwise.
+	StdoutTransformed []byte
+
+	// StdoutTruncated will be true when the child process wrote more to
+	// its standard output than Request.StdoutHeadBytes or
+	// Request.MaxOutputBytes permits capturing, or more lines than
+	// Request.MaxStdoutLines permits, or false otherwise.
+	StdoutTruncated bool
+
+	// TimedOutput will be the chunks of output read from the child
+	// process in the order they were read, when Request.TimedOutput is
+	// true, or nil otherwise.
+	TimedOutput []TimedChunk
+
 	// Code will be the exit code that the child process returned when
 	// it exited. When its value is -1, the child process was spawned
 	// but terminated in response to receiving a signal.
 	Code int
 }
 
+// ExitCode returns an exit code suitable for passing to os.Exit, so a
+// program that wraps a child process can mirror its exit status. It
+// returns Code when the child process exited on its own. When the child
+// process was terminated by a signal, it returns 128 plus the signal
+// number, as POSIX shells do, or 1 when the signal number is not known.
+func (r *Response) ExitCode() int {
+	if r.Code != -1 {
+		return r.Code
+	}
+	if n, ok := signalNumber(r.Err); ok {
+		return 128 + n
+	}
+	return 1
+}
+
+// emptyOutput returns true when the child process exited with a zero
+// exit code without any standard output captured, regardless of whether
+// that caused an ErrNoOutput error.
+func (r *Response) emptyOutput() bool {
+	if r.Code != 0 || (r.Err != nil && !errors.Is(r.Err, ErrNoOutput{})) {
+		return false
+	}
+	return len(r.Stdout) == 0 && r.StdoutFile == ""
+}
+
+// Success returns true when the child process exited on its own with a
+// zero exit code and without any error. It does not consult
+// Request.SuccessCodes, under which a nil Err alone means the exit code
+// was among them.
+func (r *Response) Success() bool {
+	return r.Err == nil && r.Code == 0
+}
+
+// Signaled returns true when the child process terminated due to
+// receiving a signal.
+func (r *Response) Signaled() bool {
+	return errors.Is(r.Err, ErrSignal{})
+}
+
+type ErrCanceled struct {
+	Err error
+}
+
+func (e ErrCanceled) Error() string {
+	return e.Err.Error()
+}
+
+func (e ErrCanceled) Is(err error) bool {
+	_, ok := err.(ErrCanceled)
+	return ok
+}
+
+func (e ErrCanceled) Unwrap() error { return e.Err }
+
+type ErrDecodeBase64 struct {
+	Err error
+}
+
+func (e ErrDecodeBase64) Error() string {
+	return "cannot decode base64 standard output: " + e.Err.Error()
+}
+
+func (e ErrDecodeBase64) Is(err error) bool {
+	_, ok := err.(ErrDecodeBase64)
+	return ok
+}
+
+func (e ErrDecodeBase64) Unwrap() error { return e.Err }
+
+type ErrDecompress struct {
+	Err error
+}
+
+func (e ErrDecompress) Error() string {
+	return "cannot decompress standard output: " + e.Err.Error()
+}
+
+func (e ErrDecompress) Is(err error) bool {
+	_, ok := err.(ErrDecompress)
+	return ok
+}
+
+func (e ErrDecompress) Unwrap() error { return e.Err }
+
+type ErrHardTimeout struct {
+	Err error
+}
+
+func (e ErrHardTimeout) Error() string {
+	return "hard timeout: " + e.Err.Error()
+}
+
+func (e ErrHardTimeout) Is(err error) bool {
+	_, ok := err.(ErrHardTimeout)
+	return ok
+}
+
+func (e ErrHardTimeout) Unwrap() error { return e.Err }
+
+// ErrInvalidExitCode is the Response.Err of a child process whose
+// Request.ExitCodeFile holds an integer that is not between 0 and 255.
+type ErrInvalidExitCode struct {
+	// Code is the integer read from the file.
+	Code int
+
+	// Path is Request.ExitCodeFile.
+	Path string
+}
+
+func (e ErrInvalidExitCode) Error() string {
+	return "invalid exit code in " + e.Path + ": " + strconv.Itoa(e.Code) + " is not between 0 and 255"
+}
+
+func (e ErrInvalidExitCode) Is(err error) bool {
+	_, ok := err.(ErrInvalidExitCode)
+	return ok
+}
+
+type ErrInvalidRequest struct {
+	Err error
+}
+
+func (e ErrInvalidRequest) Error() string {
+	return "invalid request: " + e.Err.Error()
+}
+
+func (e ErrInvalidRequest) Is(err error) bool {
+	_, ok := err.(ErrInvalidRequest)
+	return ok
+}
+
+func (e ErrInvalidRequest) Unwrap() error { return e.Err }
+
+type ErrMissingOutputFiles struct {
+	// Paths are the expected files that do not exist, as they were
+	// listed in Request.ExpectFiles.
+	Paths []string
+}
+
+func (e ErrMissingOutputFiles) Error() string {
+	return "missing expected output files: " + strings.Join(e.Paths, ", ")
+}
+
+func (e ErrMissingOutputFiles) Is(err error) bool {
+	_, ok := err.(ErrMissingOutputFiles)
+	return ok
+}
+
+type ErrNoOutput struct {
+	// Stream is either StreamStdout or StreamStderr, to identify the
+	// stream to which the child process did not write.
+	Stream int
+}
+
+func (e ErrNoOutput) Error() string {
+	if e.Stream == StreamStderr {
+		return "no output written to standard error"
+	}
+	return "no output written to standard output"
+}
+
+func (e ErrNoOutput) Is(err error) bool {
+	_, ok := err.(ErrNoOutput)
+	return ok
+}
+
+type ErrOutputTooLarge struct {
+	// Err is the potentially nil error that describes how the child
+	// process terminated after exceeding the limit.
+	Err error
+
+	// Limit is the maximum number of bytes that was exceeded.
+	Limit int64
+
+	// Stream is either StreamStdout or StreamStderr, to identify the
+	// stream that exceeded the limit.
+	Stream int
+}
+
+func (e ErrOutputTooLarge) Error() string {
+	stream := "standard output"
+	if e.Stream == StreamStderr {
+		stream = "standard error"
+	}
+	msg := stream + " exceeded limit of " + strconv.FormatInt(e.Limit, 10) + " bytes"
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+func (e ErrOutputTooLarge) Is(err error) bool {
+	_, ok := err.(ErrOutputTooLarge)
+	return ok
+}
+
+func (e ErrOutputTooLarge) Unwrap() error { return e.Err }
+
+type ErrSinkAborted struct {
+	Err error
+}
+
+func (e ErrSinkAborted) Error() string {
+	return "output sink aborted process: " + e.Err.Error()
+}
+
+func (e ErrSinkAborted) Is(err error) bool {
+	_, ok := err.(ErrSinkAborted)
+	return ok
+}
+
+func (e ErrSinkAborted) Unwrap() error { return e.Err }
+
 type ErrSignal struct {
 	Err error
+
+	// Signal is the signal that terminated the child process, or zero
+	// when it is not known, as on Windows.
+	Signal syscall.Signal
 }
 
 func (e ErrSignal) Error() string {
@@ -196,6 +2154,81 @@ func (e ErrSpawn) Is(err error) bool {
 
 func (e ErrSpawn) Unwrap() error { return e.Err }
 
+// IsNotFound returns true when err indicates the child process could not
+// be spawned because its program executable file does not exist, as
+// opposed to some other reason, such as lacking permission to execute
+// it, or its initial working directory not existing.
+func IsNotFound(err error) bool {
+	if errors.Is(err, exec.ErrNotFound) {
+		return true
+	}
+	var pe *fs.PathError
+	if errors.As(err, &pe) && pe.Op == "chdir" {
+		return false
+	}
+	return errors.Is(err, fs.ErrNotExist)
+}
+
+type ErrStdinIdleTimeout struct {
+	Err error
+}
+
+func (e ErrStdinIdleTimeout) Error() string {
+	return "stdin idle timeout: " + e.Err.Error()
+}
+
+func (e ErrStdinIdleTimeout) Is(err error) bool {
+	_, ok := err.(ErrStdinIdleTimeout)
+	return ok
+}
+
+func (e ErrStdinIdleTimeout) Unwrap() error { return e.Err }
+
+type ErrTimeout struct {
+	Err error
+}
+
+func (e ErrTimeout) Error() string {
+	return "timeout: " + e.Err.Error()
+}
+
+func (e ErrTimeout) Is(err error) bool {
+	_, ok := err.(ErrTimeout)
+	return ok
+}
+
+func (e ErrTimeout) Unwrap() error { return e.Err }
+
+type ErrTransform struct {
+	Err error
+}
+
+func (e ErrTransform) Error() string {
+	return "cannot transform standard output: " + e.Err.Error()
+}
+
+func (e ErrTransform) Is(err error) bool {
+	_, ok := err.(ErrTransform)
+	return ok
+}
+
+func (e ErrTransform) Unwrap() error { return e.Err }
+
+// ErrUnexpectedExit is the Response.Err of a child process that exited
+// with an exit code not listed in Request.SuccessCodes.
+type ErrUnexpectedExit struct {
+	Code int
+}
+
+func (e ErrUnexpectedExit) Error() string {
+	return "unexpected exit code: " + strconv.Itoa(e.Code)
+}
+
+func (e ErrUnexpectedExit) Is(err error) bool {
+	_, ok := err.(ErrUnexpectedExit)
+	return ok
+}
+
 type ErrWait struct {
 	Err error
 }
@@ -4,9 +4,19 @@
 package gorun
 
 import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
 	"context"
+	"encoding/base64"
 	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
+	"sync"
+	"syscall"
 	"testing"
 	"time"
 )
@@ -15,7 +25,7 @@ func TestRun(t *testing.T) {
 	t.Run("cannot spawn", func(t *testing.T) {
 		t.Run("path empty string", func(t *testing.T) {
 			_, err := Run(context.Background(), &Request{})
-			ensureError(t, err, ErrSpawn{Err: errors.New("fork/exec : no such file or directory")})
+			ensureError(t, err, ErrSpawn{Err: errors.New("exec: no command")})
 		})
 		t.Run("no such executable", func(t *testing.T) {
 			_, err := Run(context.Background(), &Request{
@@ -30,6 +40,93 @@ func TestRun(t *testing.T) {
 			})
 			ensureError(t, err, ErrSpawn{Err: errors.New("chdir /does-not-exist: no such file or directory")})
 		})
+		t.Run("script without shebang", func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "script")
+			ensureError(t, os.WriteFile(path, []byte("echo hello\n"), 0o755), nil)
+			_, err := Run(context.Background(), &Request{
+				Path: path,
+			})
+			if !strings.Contains(err.Error(), "appears to be a script without a #! line") {
+				t.Errorf("GOT: %v; WANT: mention of missing #! line", err)
+			}
+			if !errors.Is(err, ErrSpawn{}) || !errors.Is(err, syscall.ENOEXEC) {
+				t.Errorf("GOT: %v; WANT: %v", err, syscall.ENOEXEC)
+			}
+		})
+		t.Run("script without shebang found in PATH", func(t *testing.T) {
+			dir := t.TempDir()
+			ensureError(t, os.WriteFile(filepath.Join(dir, "script"), []byte("echo hello\n"), 0o755), nil)
+			t.Setenv("PATH", dir)
+			_, err := Run(context.Background(), &Request{
+				Path: "script",
+			})
+			if want := filepath.Join(dir, "script") + " appears to be a script"; err == nil || !strings.Contains(err.Error(), want) {
+				t.Errorf("GOT: %v; WANT: mention of %q", err, want)
+			}
+		})
+		t.Run("fallback script without shebang", func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "script")
+			ensureError(t, os.WriteFile(path, []byte("echo hello\n"), 0o755), nil)
+			_, err := Run(context.Background(), &Request{
+				Path:      "/no-such-path",
+				Fallbacks: []string{path},
+			})
+			if want := path + " appears to be a script"; err == nil || !strings.Contains(err.Error(), want) {
+				t.Errorf("GOT: %v; WANT: mention of %q", err, want)
+			}
+		})
+		t.Run("binary with unknown format", func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "binary")
+			ensureError(t, os.WriteFile(path, []byte{0x7f, 0x00, 0x01, 0x02}, 0o755), nil)
+			_, err := Run(context.Background(), &Request{
+				Path: path,
+			})
+			if !errors.Is(err, ErrSpawn{}) || !errors.Is(err, syscall.ENOEXEC) {
+				t.Errorf("GOT: %v; WANT: %v", err, syscall.ENOEXEC)
+			}
+			if strings.Contains(err.Error(), "#!") {
+				t.Errorf("GOT: %v; WANT: no mention of #!", err)
+			}
+		})
+	})
+
+	t.Run("is not found", func(t *testing.T) {
+		t.Run("no such executable", func(t *testing.T) {
+			_, err := Run(context.Background(), &Request{
+				Path: "/no-such-path",
+			})
+			if got, want := IsNotFound(err), true; got != want {
+				t.Errorf("GOT: %v; WANT: %v", got, want)
+			}
+		})
+		t.Run("permission denied", func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "not-executable")
+			ensureError(t, os.WriteFile(path, []byte("#!/bin/sh\n"), 0o644), nil)
+			_, err := Run(context.Background(), &Request{
+				Path: path,
+			})
+			if !errors.Is(err, os.ErrPermission) {
+				t.Errorf("GOT: %v; WANT: %v", err, os.ErrPermission)
+			}
+			if got, want := IsNotFound(err), false; got != want {
+				t.Errorf("GOT: %v; WANT: %v", got, want)
+			}
+		})
+		t.Run("invalid dir", func(t *testing.T) {
+			_, err := Run(context.Background(), &Request{
+				Path: "/usr/bin/true",
+				Dir:  "/does-not-exist",
+			})
+			ensureError(t, err, ErrSpawn{Err: errors.New("chdir /does-not-exist: no such file or directory")})
+			if got, want := IsNotFound(err), false; got != want {
+				t.Errorf("GOT: %v; WANT: %v", got, want)
+			}
+		})
+		t.Run("nil", func(t *testing.T) {
+			if got, want := IsNotFound(nil), false; got != want {
+				t.Errorf("GOT: %v; WANT: %v", got, want)
+			}
+		})
 	})
 
 	t.Run("true", func(t *testing.T) {
@@ -83,60 +180,1489 @@ func TestRun(t *testing.T) {
 		}
 		ensureResponsesMatch(t, got, want)
 	})
-	t.Run("timeout", func(t *testing.T) {
-		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
-		defer cancel()
-		got, err := Run(ctx, &Request{
-			Path: "/bin/sleep",
-			Args: []string{"1"},
+	t.Run("no stdin", func(t *testing.T) {
+		started := time.Now()
+		got, err := Run(context.Background(), &Request{
+			Path:    "/bin/cat",
+			Timeout: 5 * time.Second,
+		})
+		ensureError(t, err, nil)
+		if elapsed := time.Since(started); elapsed > time.Second {
+			t.Errorf("GOT: %v; WANT: < %v", elapsed, time.Second)
+		}
+		ensureResponsesMatch(t, got, &Response{Stderr: []byte{}, Stdout: []byte{}})
+	})
+	t.Run("stdin readers", func(t *testing.T) {
+		t.Run("concatenated", func(t *testing.T) {
+			got, err := Run(context.Background(), &Request{
+				Path: "/bin/cat",
+				StdinReaders: []io.Reader{
+					strings.NewReader("header\n"),
+					strings.NewReader("body\n"),
+					strings.NewReader("footer\n"),
+				},
+			})
+			ensureError(t, err, nil)
+			want := &Response{
+				Stderr: []byte{},
+				Stdout: []byte("header\nbody\nfooter\n"),
+			}
+			ensureResponsesMatch(t, got, want)
+		})
+		t.Run("conflicts with stdin", func(t *testing.T) {
+			got, err := Run(context.Background(), &Request{
+				Path:         "/bin/cat",
+				Stdin:        strings.NewReader("stdin"),
+				StdinReaders: []io.Reader{strings.NewReader("readers")},
+			})
+			ensureError(t, err, ErrInvalidRequest{Err: errors.New("cannot set more than one of Stdin, StdinReaders, and StdinReaderAt")})
+			if got != nil {
+				t.Errorf("GOT: %v; WANT: %v", got, nil)
+			}
+		})
+	})
+	t.Run("stdin reader at", func(t *testing.T) {
+		fh, err := os.Create(filepath.Join(t.TempDir(), "input"))
+		ensureError(t, err, nil)
+		defer fh.Close()
+		_, err = fh.WriteString("header\nbody\nfooter\n")
+		ensureError(t, err, nil)
+
+		got, err := Run(context.Background(), &Request{
+			Path:          "/bin/cat",
+			StdinReaderAt: fh,
+			StdinOffset:   int64(len("header\n")),
+			StdinLength:   int64(len("body\n")),
 		})
 		ensureError(t, err, nil)
 		want := &Response{
-			Code:   -1,
-			Err:    ErrSignal{Err: errors.New("signal: killed")},
 			Stderr: []byte{},
-			Stdout: []byte{},
+			Stdout: []byte("body\n"),
 		}
 		ensureResponsesMatch(t, got, want)
-		if !errors.Is(got.Err, want.Err) {
-			t.Errorf("GOT: %T(%v); WANT: %T(%v)", got.Err, got.Err, want.Err, want.Err)
+	})
+	t.Run("stdin bytes", func(t *testing.T) {
+		req := &Request{
+			Path:       "/bin/cat",
+			StdinBytes: []byte("from bytes\n"),
+		}
+		want := &Response{
+			Stderr: []byte{},
+			Stdout: []byte("from bytes\n"),
+		}
+		// Running it again reads the same input again.
+		for i := 0; i < 2; i++ {
+			got, err := Run(context.Background(), req)
+			ensureError(t, err, nil)
+			ensureResponsesMatch(t, got, want)
 		}
 	})
-	t.Run("canceled", func(t *testing.T) {
-		t.Run("before start", func(t *testing.T) {
-			ctx, cancel := context.WithCancel(context.Background())
-			cancel()
-
-			_, err := Run(ctx, &Request{
-				Path: "/bin/sleep",
-				Args: []string{"1"},
+	t.Run("stdin string", func(t *testing.T) {
+		got, err := Run(context.Background(), &Request{
+			Path:        "/bin/cat",
+			StdinString: "from string\n",
+		})
+		ensureError(t, err, nil)
+		ensureResponsesMatch(t, got, &Response{Stderr: []byte{}, Stdout: []byte("from string\n")})
+	})
+	t.Run("stdin precedence", func(t *testing.T) {
+		t.Run("stdin over bytes and string", func(t *testing.T) {
+			got, err := Run(context.Background(), &Request{
+				Path:        "/bin/cat",
+				Stdin:       strings.NewReader("reader"),
+				StdinBytes:  []byte("bytes"),
+				StdinString: "string",
 			})
-
-			ensureError(t, err, ErrSpawn{Err: errors.New("context canceled")})
+			ensureError(t, err, nil)
+			ensureResponsesMatch(t, got, &Response{Stderr: []byte{}, Stdout: []byte("reader")})
 		})
-		t.Run("after start", func(t *testing.T) {
-			ctx, cancel := context.WithCancel(context.Background())
-
-			go func() {
-				time.Sleep(100 * time.Millisecond)
-				cancel()
-			}()
-
-			got, err := Run(ctx, &Request{
-				Path: "/bin/sleep",
-				Args: []string{"1"},
+		t.Run("bytes over string", func(t *testing.T) {
+			got, err := Run(context.Background(), &Request{
+				Path:        "/bin/cat",
+				StdinBytes:  []byte{},
+				StdinString: "string",
+			})
+			ensureError(t, err, nil)
+			ensureResponsesMatch(t, got, &Response{Stderr: []byte{}, Stdout: []byte{}})
+		})
+		t.Run("conflicts with stdin readers", func(t *testing.T) {
+			_, err := Run(context.Background(), &Request{
+				Path:         "/bin/cat",
+				StdinString:  "string",
+				StdinReaders: []io.Reader{strings.NewReader("readers")},
+			})
+			ensureError(t, err, ErrInvalidRequest{Err: errors.New("cannot set more than one of Stdin, StdinReaders, and StdinReaderAt")})
+		})
+	})
+	t.Run("stdin tee", func(t *testing.T) {
+		t.Run("records what the child received", func(t *testing.T) {
+			input := strings.Repeat("0123456789abcdef", 1<<16)
+			var tee bytes.Buffer
+			got, err := Run(context.Background(), &Request{
+				Path: "/bin/cat",
+				StdinReaders: []io.Reader{
+					strings.NewReader(input[:len(input)/2]),
+					strings.NewReader(input[len(input)/2:]),
+				},
+				StdinTee: &tee,
+			})
+			ensureError(t, err, nil)
+			if got, want := len(got.Stdout), len(input); got != want {
+				t.Fatalf("GOT: %v; WANT: %v", got, want)
+			}
+			if !bytes.Equal(tee.Bytes(), got.Stdout) {
+				t.Errorf("GOT: %d bytes; WANT: %d bytes", tee.Len(), len(got.Stdout))
+			}
+		})
+		t.Run("stdin bytes", func(t *testing.T) {
+			var tee bytes.Buffer
+			got, err := Run(context.Background(), &Request{
+				Path:       "/bin/cat",
+				StdinBytes: []byte("from bytes\n"),
+				StdinTee:   &tee,
+			})
+			ensureError(t, err, nil)
+			ensureResponsesMatch(t, got, &Response{Stderr: []byte{}, Stdout: []byte("from bytes\n")})
+			if got, want := tee.String(), "from bytes\n"; got != want {
+				t.Errorf("GOT: %q; WANT: %q", got, want)
+			}
+		})
+		t.Run("without stdin", func(t *testing.T) {
+			var tee bytes.Buffer
+			got, err := Run(context.Background(), &Request{
+				Path:     "/bin/cat",
+				StdinTee: &tee,
+			})
+			ensureError(t, err, nil)
+			ensureResponsesMatch(t, got, &Response{Stderr: []byte{}, Stdout: []byte{}})
+			if got, want := tee.Len(), 0; got != want {
+				t.Errorf("GOT: %v; WANT: %v", got, want)
+			}
+		})
+	})
+	t.Run("exit code file", func(t *testing.T) {
+		t.Run("valid", func(t *testing.T) {
+			dir := t.TempDir()
+			got, err := Run(context.Background(), &Request{
+				Path:         "/bin/sh",
+				Args:         []string{"-c", "echo 42 > code.txt; exit 0"},
+				Dir:          dir,
+				ExitCodeFile: "code.txt",
+			})
+			ensureError(t, err, nil)
+			want := &Response{
+				Code:   42,
+				Stderr: []byte{},
+				Stdout: []byte{},
+			}
+			ensureResponsesMatch(t, got, want)
+		})
+		t.Run("invalid", func(t *testing.T) {
+			dir := t.TempDir()
+			got, err := Run(context.Background(), &Request{
+				Path:         "/bin/sh",
+				Args:         []string{"-c", "echo bogus > code.txt; exit 3"},
+				Dir:          dir,
+				ExitCodeFile: "code.txt",
+			})
+			ensureError(t, err, nil)
+			want := &Response{
+				Code:   3,
+				Stderr: []byte{},
+				Stdout: []byte{},
+			}
+			ensureResponsesMatch(t, got, want)
+		})
+		t.Run("out of range", func(t *testing.T) {
+			for _, value := range []int{300, 256, -1} {
+				got, err := Run(context.Background(), &Request{
+					Path:         "/bin/sh",
+					Args:         []string{"-c", "echo " + strconv.Itoa(value) + " > code.txt; exit 3"},
+					Dir:          t.TempDir(),
+					ExitCodeFile: "code.txt",
+				})
+				ensureError(t, err, nil)
+				want := &Response{
+					Code:   3,
+					Err:    ErrInvalidExitCode{Code: value, Path: "code.txt"},
+					Stderr: []byte{},
+					Stdout: []byte{},
+				}
+				ensureResponsesMatch(t, got, want)
+			}
+		})
+		t.Run("bounds", func(t *testing.T) {
+			for _, code := range []int{0, 255} {
+				got, err := Run(context.Background(), &Request{
+					Path:         "/bin/sh",
+					Args:         []string{"-c", "echo " + strconv.Itoa(code) + " > code.txt; exit 3"},
+					Dir:          t.TempDir(),
+					ExitCodeFile: "code.txt",
+				})
+				ensureError(t, err, nil)
+				ensureResponsesMatch(t, got, &Response{Code: code, Stderr: []byte{}, Stdout: []byte{}})
+			}
+		})
+		t.Run("missing", func(t *testing.T) {
+			got, err := Run(context.Background(), &Request{
+				Path:         "/usr/bin/false",
+				ExitCodeFile: filepath.Join(t.TempDir(), "code.txt"),
+			})
+			ensureError(t, err, nil)
+			want := &Response{
+				Code:   1,
+				Stderr: []byte{},
+				Stdout: []byte{},
+			}
+			ensureResponsesMatch(t, got, want)
+		})
+	})
+	t.Run("env map", func(t *testing.T) {
+		t.Run("alone", func(t *testing.T) {
+			got, err := Run(context.Background(), &Request{
+				Path:   "/usr/bin/env",
+				EnvMap: map[string]string{"B": "two words", "A": "it's"},
+			})
+			ensureError(t, err, nil)
+			ensureResponsesMatch(t, got, &Response{Stderr: []byte{}, Stdout: []byte("A=it's\nB=two words\n")})
+		})
+		t.Run("env alone", func(t *testing.T) {
+			got, err := Run(context.Background(), &Request{
+				Path: "/usr/bin/env",
+				Env:  []string{"B=2", "A=1"},
+			})
+			ensureError(t, err, nil)
+			ensureResponsesMatch(t, got, &Response{Stderr: []byte{}, Stdout: []byte("B=2\nA=1\n")})
+		})
+		t.Run("both", func(t *testing.T) {
+			got, err := Run(context.Background(), &Request{
+				Path:   "/usr/bin/env",
+				Env:    []string{"C=3", "B=env"},
+				EnvMap: map[string]string{"B": "map", "A": "1"},
+			})
+			ensureError(t, err, nil)
+			ensureResponsesMatch(t, got, &Response{Stderr: []byte{}, Stdout: []byte("A=1\nB=env\nC=3\n")})
+		})
+		t.Run("invalid name", func(t *testing.T) {
+			_, err := Run(context.Background(), &Request{
+				Path:   "/usr/bin/env",
+				EnvMap: map[string]string{"A=B": "1"},
 			})
+			ensureError(t, err, ErrInvalidRequest{Err: errors.New(`invalid EnvMap name: "A=B"`)})
+		})
+	})
+	t.Run("inherit env", func(t *testing.T) {
+		t.Setenv("GORUN_TEST_INHERITED", "parent")
+		t.Setenv("GORUN_TEST_OVERRIDDEN", "parent")
 
+		run := func(t *testing.T, req *Request) string {
+			t.Helper()
+			req.Path = "/usr/bin/env"
+			got, err := Run(context.Background(), req)
 			ensureError(t, err, nil)
+			var lines []string
+			for _, line := range got.StdoutLines() {
+				if strings.HasPrefix(line, "GORUN_TEST_") {
+					lines = append(lines, line)
+				}
+			}
+			return strings.Join(lines, " ")
+		}
 
+		t.Run("inherits", func(t *testing.T) {
+			got := run(t, &Request{Env: []string{"GORUN_TEST_ADDED=child"}, InheritEnv: true})
+			for _, want := range []string{"GORUN_TEST_INHERITED=parent", "GORUN_TEST_ADDED=child"} {
+				if !strings.Contains(got, want) {
+					t.Errorf("GOT: %q; WANT: %q", got, want)
+				}
+			}
+		})
+		t.Run("overrides", func(t *testing.T) {
+			got := run(t, &Request{
+				Env:        []string{"GORUN_TEST_OVERRIDDEN=first", "GORUN_TEST_OVERRIDDEN=child"},
+				InheritEnv: true,
+			})
+			if want := "GORUN_TEST_OVERRIDDEN=child"; strings.Count(got, "GORUN_TEST_OVERRIDDEN=") != 1 || !strings.Contains(got, want) {
+				t.Errorf("GOT: %q; WANT: %q", got, want)
+			}
+		})
+		t.Run("replaces by default", func(t *testing.T) {
+			got := run(t, &Request{Env: []string{"GORUN_TEST_ADDED=child"}})
+			if want := "GORUN_TEST_ADDED=child"; got != want {
+				t.Errorf("GOT: %q; WANT: %q", got, want)
+			}
+		})
+	})
+	t.Run("expand env", func(t *testing.T) {
+		t.Run("references", func(t *testing.T) {
+			t.Setenv("GORUN_TEST_INHERITED", "parent")
+			got, err := Run(context.Background(), &Request{
+				Path:      "/bin/sh",
+				Args:      []string{"-c", `echo "$C"`},
+				Env:       []string{"C=${B}-c", "B=$A-b", "A=$GORUN_TEST_INHERITED:a"},
+				ExpandEnv: true,
+			})
+			ensureError(t, err, nil)
 			want := &Response{
-				Code:   -1,
-				Err:    ErrSignal{Err: errors.New("signal: killed")},
 				Stderr: []byte{},
-				Stdout: []byte{},
+				Stdout: []byte("parent:a-b-c\n"),
+			}
+			ensureResponsesMatch(t, got, want)
+		})
+		t.Run("self reference", func(t *testing.T) {
+			t.Setenv("GORUN_TEST_PATH", "/usr/bin")
+			got, err := Run(context.Background(), &Request{
+				Path:      "/bin/sh",
+				Args:      []string{"-c", `echo "$GORUN_TEST_PATH"`},
+				Env:       []string{"GORUN_TEST_PATH=/opt/bin:$GORUN_TEST_PATH"},
+				ExpandEnv: true,
+			})
+			ensureError(t, err, nil)
+			want := &Response{
+				Stderr: []byte{},
+				Stdout: []byte("/opt/bin:/usr/bin\n"),
+			}
+			ensureResponsesMatch(t, got, want)
+		})
+		t.Run("cycle", func(t *testing.T) {
+			_, err := Run(context.Background(), &Request{
+				Path:      "/usr/bin/true",
+				Env:       []string{"A=$B", "B=${C}", "C=$A"},
+				ExpandEnv: true,
+			})
+			ensureError(t, err, ErrInvalidRequest{Err: errors.New("environment variable reference cycle: A -> B -> C -> A")})
+		})
+		t.Run("not expanded by default", func(t *testing.T) {
+			got, err := Run(context.Background(), &Request{
+				Path: "/bin/sh",
+				Args: []string{"-c", `printf '%s\n' "$B"`},
+				Env:  []string{"A=a", "B=$A"},
+			})
+			ensureError(t, err, nil)
+			want := &Response{
+				Stderr: []byte{},
+				Stdout: []byte("$A\n"),
+			}
+			ensureResponsesMatch(t, got, want)
+		})
+	})
+	t.Run("combine output", func(t *testing.T) {
+		t.Run("interleaved", func(t *testing.T) {
+			got, err := Run(context.Background(), &Request{
+				Path:          "/bin/sh",
+				Args:          []string{"-c", "echo out1; echo err1 >&2; echo out2; echo err2 >&2"},
+				CombineOutput: true,
+			})
+			ensureError(t, err, nil)
+			if got, want := string(got.Combined), "out1\nerr1\nout2\nerr2\n"; got != want {
+				t.Errorf("GOT: %q; WANT: %q", got, want)
+			}
+			if got.Stdout != nil || got.Stderr != nil {
+				t.Errorf("GOT: %q, %q; WANT: nil, nil", got.Stdout, got.Stderr)
+			}
+		})
+		t.Run("not combined", func(t *testing.T) {
+			got, err := Run(context.Background(), &Request{
+				Path: "/bin/sh",
+				Args: []string{"-c", "echo out; echo err >&2"},
+			})
+			ensureError(t, err, nil)
+			if got.Combined != nil {
+				t.Errorf("GOT: %q; WANT: %v", got.Combined, nil)
+			}
+		})
+		t.Run("with stdout tee", func(t *testing.T) {
+			_, err := Run(context.Background(), &Request{
+				Path:          "/bin/echo",
+				CombineOutput: true,
+				StdoutTee:     io.Discard,
+			})
+			ensureError(t, err, ErrInvalidRequest{Err: errors.New("cannot set CombineOutput along with fields that handle standard output or standard error separately")})
+		})
+		t.Run("streaming", func(t *testing.T) {
+			_, err := (&Request{Path: "/bin/echo", CombineOutput: true}).RunSink(context.Background(), func([]byte, int) error { return nil })
+			ensureError(t, err, ErrInvalidRequest{Err: errors.New("cannot set CombineOutput when streaming output")})
+		})
+	})
+	t.Run("transform stdout", func(t *testing.T) {
+		t.Run("upper", func(t *testing.T) {
+			got, err := Run(context.Background(), &Request{
+				Path: "/bin/echo",
+				Args: []string{"hello, world"},
+				StdoutTransform: func(b []byte) ([]byte, error) {
+					return bytes.ToUpper(b), nil
+				},
+			})
+			ensureError(t, err, nil)
+			want := &Response{
+				Stderr: []byte{},
+				Stdout: []byte("hello, world\n"),
+			}
+			ensureResponsesMatch(t, got, want)
+			if got, want := string(got.StdoutTransformed), "HELLO, WORLD\n"; got != want {
+				t.Errorf("GOT: %q; WANT: %q", got, want)
+			}
+		})
+		t.Run("error", func(t *testing.T) {
+			got, err := Run(context.Background(), &Request{
+				Path: "/bin/echo",
+				Args: []string{"hello, world"},
+				StdoutTransform: func(b []byte) ([]byte, error) {
+					return nil, someError
+				},
+			})
+			ensureError(t, err, nil)
+			ensureError(t, got.Err, ErrTransform{Err: someError})
+			if got.StdoutTransformed != nil {
+				t.Errorf("GOT: %q; WANT: %v", got.StdoutTransformed, nil)
 			}
+		})
+	})
+	t.Run("decompress stdout", func(t *testing.T) {
+		var compressed bytes.Buffer
+		zw := gzip.NewWriter(&compressed)
+		_, err := zw.Write([]byte("hello, world\n"))
+		ensureError(t, err, nil)
+		ensureError(t, zw.Close(), nil)
 
+		t.Run("gzip", func(t *testing.T) {
+			got, err := Run(context.Background(), &Request{
+				Path:             "/bin/cat",
+				Stdin:            bytes.NewReader(compressed.Bytes()),
+				DecompressStdout: true,
+			})
+			ensureError(t, err, nil)
+			want := &Response{
+				Stderr: []byte{},
+				Stdout: []byte("hello, world\n"),
+			}
+			ensureResponsesMatch(t, got, want)
+		})
+		t.Run("plain", func(t *testing.T) {
+			got, err := Run(context.Background(), &Request{
+				Path:             "/bin/echo",
+				Args:             []string{"hello, world"},
+				DecompressStdout: true,
+			})
+			ensureError(t, err, nil)
+			want := &Response{
+				Stderr: []byte{},
+				Stdout: []byte("hello, world\n"),
+			}
 			ensureResponsesMatch(t, got, want)
 		})
+		t.Run("corrupt", func(t *testing.T) {
+			corrupt := compressed.Bytes()[:12]
+			got, err := Run(context.Background(), &Request{
+				Path:             "/bin/cat",
+				Stdin:            bytes.NewReader(corrupt),
+				DecompressStdout: true,
+			})
+			ensureError(t, err, nil)
+			if !errors.Is(got.Err, ErrDecompress{}) {
+				t.Errorf("GOT: %v; WANT: %v", got.Err, ErrDecompress{})
+			}
+			if !bytes.Equal(got.Stdout, corrupt) {
+				t.Errorf("GOT: %q; WANT: %q", got.Stdout, corrupt)
+			}
+		})
 	})
+	t.Run("decode base64 stdout", func(t *testing.T) {
+		t.Run("valid", func(t *testing.T) {
+			got, err := Run(context.Background(), &Request{
+				Path:               "/usr/bin/printf",
+				Args:               []string{"aGVsbG8s\n IHdvcmxk\r\nCg==\n"},
+				DecodeBase64Stdout: true,
+			})
+			ensureError(t, err, nil)
+			want := &Response{
+				Stderr: []byte{},
+				Stdout: []byte("hello, world\n"),
+			}
+			ensureResponsesMatch(t, got, want)
+		})
+		t.Run("invalid", func(t *testing.T) {
+			got, err := Run(context.Background(), &Request{
+				Path:               "/bin/echo",
+				Args:               []string{"not base64!"},
+				DecodeBase64Stdout: true,
+			})
+			ensureError(t, err, nil)
+			ensureError(t, got.Err, ErrDecodeBase64{Err: errors.New("illegal base64 data at input byte 9")})
+			if got, want := string(got.Stdout), "not base64!\n"; got != want {
+				t.Errorf("GOT: %q; WANT: %q", got, want)
+			}
+		})
+		t.Run("before decompress", func(t *testing.T) {
+			var compressed bytes.Buffer
+			zw := gzip.NewWriter(&compressed)
+			_, err := zw.Write([]byte("hello, world\n"))
+			ensureError(t, err, nil)
+			ensureError(t, zw.Close(), nil)
+
+			got, err := Run(context.Background(), &Request{
+				Path:               "/bin/cat",
+				StdinString:        base64.StdEncoding.EncodeToString(compressed.Bytes()) + "\n",
+				DecodeBase64Stdout: true,
+				DecompressStdout:   true,
+			})
+			ensureError(t, err, nil)
+			want := &Response{
+				Stderr: []byte{},
+				Stdout: []byte("hello, world\n"),
+			}
+			ensureResponsesMatch(t, got, want)
+		})
+		t.Run("conflicts with stdout writer", func(t *testing.T) {
+			_, err := Run(context.Background(), &Request{
+				Path:               "/usr/bin/true",
+				StdoutWriter:       new(bytes.Buffer),
+				DecodeBase64Stdout: true,
+			})
+			ensureError(t, err, ErrInvalidRequest{Err: errors.New("cannot set DecodeBase64Stdout along with StdoutWriter, HashStdout, StdoutGrep, SpillStdoutAfter, or StdoutHeadBytes")})
+		})
+	})
+	t.Run("expect files", func(t *testing.T) {
+		t.Run("some missing", func(t *testing.T) {
+			dir := t.TempDir()
+			absent := filepath.Join(t.TempDir(), "absent.txt")
+			got, err := Run(context.Background(), &Request{
+				Path:        "/bin/sh",
+				Args:        []string{"-c", "mkdir sub && touch a.txt sub/b.txt"},
+				Dir:         dir,
+				ExpectFiles: []string{"a.txt", "c.txt", "sub/b.txt", absent},
+			})
+			ensureError(t, err, nil)
+			want := &Response{
+				Err:    ErrMissingOutputFiles{Paths: []string{"c.txt", absent}},
+				Stderr: []byte{},
+				Stdout: []byte{},
+			}
+			ensureResponsesMatch(t, got, want)
+		})
+		t.Run("all present", func(t *testing.T) {
+			got, err := Run(context.Background(), &Request{
+				Path:        "/bin/sh",
+				Args:        []string{"-c", "touch a.txt"},
+				Dir:         t.TempDir(),
+				ExpectFiles: []string{"a.txt"},
+			})
+			ensureError(t, err, nil)
+			want := &Response{
+				Stderr: []byte{},
+				Stdout: []byte{},
+			}
+			ensureResponsesMatch(t, got, want)
+		})
+		t.Run("not checked after failure", func(t *testing.T) {
+			got, err := Run(context.Background(), &Request{
+				Path:        "/usr/bin/false",
+				Dir:         t.TempDir(),
+				ExpectFiles: []string{"a.txt"},
+			})
+			ensureError(t, err, nil)
+			want := &Response{
+				Code:   1,
+				Stderr: []byte{},
+				Stdout: []byte{},
+			}
+			ensureResponsesMatch(t, got, want)
+		})
+	})
+	t.Run("provided buffers", func(t *testing.T) {
+		stderr := bytes.NewBuffer(make([]byte, 0, 1024))
+		stdout := bytes.NewBuffer(make([]byte, 0, 1024))
+
+		for _, word := range []string{"first", "second"} {
+			stderr.Reset()
+			stdout.Reset()
+
+			got, err := Run(context.Background(), &Request{
+				Path:         "/bin/sh",
+				Args:         []string{"-c", "echo $0; echo $0 >&2", word},
+				StderrBuffer: stderr,
+				StdoutBuffer: stdout,
+			})
+			ensureError(t, err, nil)
+			want := &Response{
+				Stderr: []byte(word + "\n"),
+				Stdout: []byte(word + "\n"),
+			}
+			ensureResponsesMatch(t, got, want)
+
+			if &got.Stderr[0] != &stderr.Bytes()[0] {
+				t.Errorf("GOT: %p; WANT: %p", &got.Stderr[0], &stderr.Bytes()[0])
+			}
+			if &got.Stdout[0] != &stdout.Bytes()[0] {
+				t.Errorf("GOT: %p; WANT: %p", &got.Stdout[0], &stdout.Bytes()[0])
+			}
+			if g, w := stdout.Cap(), 1024; g != w {
+				t.Errorf("GOT: %v; WANT: %v", g, w)
+			}
+		}
+
+		t.Run("appends", func(t *testing.T) {
+			var stdout bytes.Buffer
+			stdout.WriteString("previous\n")
+			got, err := Run(context.Background(), &Request{
+				Path:         "/bin/echo",
+				Args:         []string{"next"},
+				StdoutBuffer: &stdout,
+			})
+			ensureError(t, err, nil)
+			if g, w := string(got.Stdout), "next\n"; g != w {
+				t.Errorf("GOT: %q; WANT: %q", g, w)
+			}
+			if g, w := stdout.String(), "previous\nnext\n"; g != w {
+				t.Errorf("GOT: %q; WANT: %q", g, w)
+			}
+		})
+	})
+	t.Run("require output", func(t *testing.T) {
+		t.Run("silent", func(t *testing.T) {
+			got, err := Run(context.Background(), &Request{
+				Path:          "/usr/bin/true",
+				RequireOutput: true,
+			})
+			ensureError(t, err, nil)
+			want := &Response{
+				Err:    ErrNoOutput{Stream: StreamStdout},
+				Stderr: []byte{},
+				Stdout: []byte{},
+			}
+			ensureResponsesMatch(t, got, want)
+		})
+		t.Run("output", func(t *testing.T) {
+			got, err := Run(context.Background(), &Request{
+				Path:          "/bin/echo",
+				RequireOutput: true,
+			})
+			ensureError(t, err, nil)
+			want := &Response{
+				Stderr: []byte{},
+				Stdout: []byte("\n"),
+			}
+			ensureResponsesMatch(t, got, want)
+		})
+		t.Run("stderr", func(t *testing.T) {
+			got, err := Run(context.Background(), &Request{
+				Path:                "/bin/echo",
+				RequireOutput:       true,
+				RequireOutputStream: StreamStderr,
+			})
+			ensureError(t, err, nil)
+			want := &Response{
+				Err:    ErrNoOutput{Stream: StreamStderr},
+				Stderr: []byte{},
+				Stdout: []byte("\n"),
+			}
+			ensureResponsesMatch(t, got, want)
+		})
+	})
+	t.Run("tee", func(t *testing.T) {
+		stderrReader, stderrWriter, err := os.Pipe()
+		ensureError(t, err, nil)
+		stdoutReader, stdoutWriter, err := os.Pipe()
+		ensureError(t, err, nil)
+
+		var teedStderr, teedStdout []byte
+		var wg sync.WaitGroup
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			teedStderr, _ = io.ReadAll(stderrReader)
+		}()
+		go func() {
+			defer wg.Done()
+			teedStdout, _ = io.ReadAll(stdoutReader)
+		}()
+
+		got, err := Run(context.Background(), &Request{
+			Path:      "/bin/sh",
+			Args:      []string{"-c", "echo out; echo err >&2"},
+			StderrTee: stderrWriter,
+			StdoutTee: stdoutWriter,
+		})
+		ensureError(t, err, nil)
+		_ = stderrWriter.Close()
+		_ = stdoutWriter.Close()
+		wg.Wait()
+
+		want := &Response{
+			Stderr: []byte("err\n"),
+			Stdout: []byte("out\n"),
+		}
+		ensureResponsesMatch(t, got, want)
+		if g, w := string(teedStderr), "err\n"; g != w {
+			t.Errorf("GOT: %q; WANT: %q", g, w)
+		}
+		if g, w := string(teedStdout), "out\n"; g != w {
+			t.Errorf("GOT: %q; WANT: %q", g, w)
+		}
+	})
+	t.Run("tee bufio writer", func(t *testing.T) {
+		var bb bytes.Buffer
+		bw := bufio.NewWriter(&bb)
+
+		got, err := Run(context.Background(), &Request{
+			Path:      "/bin/echo",
+			Args:      []string{"one", "two", "three"},
+			StdoutTee: bw,
+		})
+		ensureError(t, err, nil)
+
+		if g, w := bw.Buffered(), len("one two three\n"); g != w {
+			t.Errorf("GOT: %v; WANT: %v", g, w)
+		}
+		if g, w := bb.Len(), 0; g != w {
+			t.Errorf("GOT: %v; WANT: %v", g, w)
+		}
+		ensureError(t, bw.Flush(), nil)
+		if g, w := bb.String(), "one two three\n"; g != w {
+			t.Errorf("GOT: %q; WANT: %q", g, w)
+		}
+		if g, w := bb.String(), string(got.Stdout); g != w {
+			t.Errorf("GOT: %q; WANT: %q", g, w)
+		}
+	})
+	t.Run("timeout", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+		got, err := Run(ctx, &Request{
+			Path: "/bin/sleep",
+			Args: []string{"1"},
+		})
+		ensureError(t, err, nil)
+		want := &Response{
+			Code:   -1,
+			Err:    ErrTimeout{Err: ErrSignal{Err: errors.New("signal: killed")}},
+			Stderr: []byte{},
+			Stdout: []byte{},
+		}
+		ensureResponsesMatch(t, got, want)
+		if !errors.Is(got.Err, ErrSignal{}) {
+			t.Errorf("GOT: %v; WANT: %v", got.Err, ErrSignal{})
+		}
+		if errors.Is(got.Err, ErrCanceled{}) {
+			t.Errorf("GOT: %v; WANT: not %v", got.Err, ErrCanceled{})
+		}
+	})
+	t.Run("duration", func(t *testing.T) {
+		before := time.Now()
+		got, err := Run(context.Background(), &Request{
+			Path: "/bin/sleep",
+			Args: []string{"0.2"},
+		})
+		ensureError(t, err, nil)
+		if got.Started.Before(before) {
+			t.Errorf("GOT: %v; WANT: >= %v", got.Started, before)
+		}
+		if got.Duration < 200*time.Millisecond {
+			t.Errorf("GOT: %v; WANT: >= %v", got.Duration, 200*time.Millisecond)
+		}
+	})
+	t.Run("latency budget", func(t *testing.T) {
+		t.Run("exceeded", func(t *testing.T) {
+			var bb bytes.Buffer
+			got, err := Run(context.Background(), &Request{
+				Path:          "/bin/sh",
+				Args:          []string{"-c", "sleep 0.1; echo done"},
+				LatencyBudget: time.Millisecond,
+				Events:        &bb,
+			})
+			ensureError(t, err, nil)
+			want := &Response{
+				Stderr: []byte{},
+				Stdout: []byte("done\n"),
+			}
+			ensureResponsesMatch(t, got, want)
+			if !got.BudgetExceeded {
+				t.Errorf("GOT: %v; WANT: %v", got.BudgetExceeded, true)
+			}
+			if !strings.Contains(bb.String(), `"type":"budget exceeded"`) {
+				t.Errorf("GOT: %q; WANT: %q", bb.String(), EventBudgetExceeded)
+			}
+		})
+		t.Run("within", func(t *testing.T) {
+			got, err := Run(context.Background(), &Request{
+				Path:          "/bin/echo",
+				LatencyBudget: time.Minute,
+			})
+			ensureError(t, err, nil)
+			if got.BudgetExceeded {
+				t.Errorf("GOT: %v; WANT: %v", got.BudgetExceeded, false)
+			}
+		})
+	})
+	t.Run("on start", func(t *testing.T) {
+		var pid int
+		got, err := Run(context.Background(), &Request{
+			Path:    "/bin/sh",
+			Args:    []string{"-c", "echo $$"},
+			OnStart: func(p int) { pid = p },
+		})
+		ensureError(t, err, nil)
+		if pid <= 0 {
+			t.Fatalf("GOT: %v; WANT: > 0", pid)
+		}
+		if got, want := string(got.Stdout), strconv.Itoa(pid)+"\n"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+	})
+	t.Run("request timeout", func(t *testing.T) {
+		t.Run("expires", func(t *testing.T) {
+			started := time.Now()
+			got, err := Run(context.Background(), &Request{
+				Path:    "/bin/sleep",
+				Args:    []string{"5"},
+				Timeout: 100 * time.Millisecond,
+			})
+			ensureError(t, err, nil)
+			if elapsed := time.Since(started); elapsed > 2*time.Second {
+				t.Errorf("GOT: %v; WANT: < %v", elapsed, 2*time.Second)
+			}
+			want := &Response{
+				Code:   -1,
+				Err:    ErrTimeout{Err: ErrSignal{Err: errors.New("signal: killed")}},
+				Stderr: []byte{},
+				Stdout: []byte{},
+			}
+			ensureResponsesMatch(t, got, want)
+			if got, want := got.Outcome, OutcomeTimeout; got != want {
+				t.Errorf("GOT: %v; WANT: %v", got, want)
+			}
+		})
+		t.Run("parent earlier", func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer cancel()
+			started := time.Now()
+			got, err := Run(ctx, &Request{
+				Path:    "/bin/sleep",
+				Args:    []string{"5"},
+				Timeout: time.Minute,
+			})
+			ensureError(t, err, nil)
+			if elapsed := time.Since(started); elapsed > 2*time.Second {
+				t.Errorf("GOT: %v; WANT: < %v", elapsed, 2*time.Second)
+			}
+			if got, want := got.Code, -1; got != want {
+				t.Errorf("GOT: %v; WANT: %v", got, want)
+			}
+		})
+		t.Run("parent canceled", func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			time.AfterFunc(100*time.Millisecond, cancel)
+			got, err := Run(ctx, &Request{
+				Path:    "/bin/sleep",
+				Args:    []string{"5"},
+				Timeout: time.Minute,
+			})
+			ensureError(t, err, nil)
+			if got, want := got.Outcome, OutcomeCanceled; got != want {
+				t.Errorf("GOT: %v; WANT: %v", got, want)
+			}
+		})
+		t.Run("parent already done", func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			_, err := Run(ctx, &Request{
+				Path:    "/bin/sleep",
+				Args:    []string{"1"},
+				Timeout: time.Minute,
+			})
+			ensureError(t, err, ErrSpawn{Err: errors.New("context canceled")})
+		})
+		t.Run("not reached", func(t *testing.T) {
+			got, err := Run(context.Background(), &Request{
+				Path:    "/bin/echo",
+				Args:    []string{"hello"},
+				Timeout: time.Minute,
+			})
+			ensureError(t, err, nil)
+			want := &Response{
+				Stderr: []byte{},
+				Stdout: []byte("hello\n"),
+			}
+			ensureResponsesMatch(t, got, want)
+		})
+	})
+	t.Run("start delay", func(t *testing.T) {
+		t.Run("elapses", func(t *testing.T) {
+			delay := 100 * time.Millisecond
+			started := time.Now()
+			got, err := Run(context.Background(), &Request{
+				Path:       "/bin/echo",
+				Args:       []string{"delayed"},
+				StartDelay: delay,
+			})
+			ensureError(t, err, nil)
+			if elapsed := time.Since(started); elapsed < delay {
+				t.Errorf("GOT: %v; WANT: >= %v", elapsed, delay)
+			}
+			want := &Response{
+				Stderr: []byte{},
+				Stdout: []byte("delayed\n"),
+			}
+			ensureResponsesMatch(t, got, want)
+		})
+		t.Run("canceled", func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			go func() {
+				time.Sleep(50 * time.Millisecond)
+				cancel()
+			}()
+
+			dir := t.TempDir()
+			marker := dir + "/started"
+
+			started := time.Now()
+			got, err := Run(ctx, &Request{
+				Path:       "/usr/bin/touch",
+				Args:       []string{marker},
+				StartDelay: time.Second,
+			})
+			ensureError(t, err, ErrCanceled{Err: context.Canceled})
+			if got != nil {
+				t.Errorf("GOT: %v; WANT: %v", got, nil)
+			}
+			if elapsed := time.Since(started); elapsed >= time.Second {
+				t.Errorf("GOT: %v; WANT: < %v", elapsed, time.Second)
+			}
+			if _, err := os.Stat(marker); !os.IsNotExist(err) {
+				t.Errorf("GOT: %v; WANT: %v", err, os.ErrNotExist)
+			}
+		})
+	})
+	t.Run("exit code", func(t *testing.T) {
+		t.Run("zero", func(t *testing.T) {
+			got, err := Run(context.Background(), &Request{Path: "/usr/bin/true"})
+			ensureError(t, err, nil)
+			if g, w := got.ExitCode(), 0; g != w {
+				t.Errorf("GOT: %v; WANT: %v", g, w)
+			}
+		})
+		t.Run("non-zero", func(t *testing.T) {
+			got, err := Run(context.Background(), &Request{
+				Path: "/bin/sh",
+				Args: []string{"-c", "exit 42"},
+			})
+			ensureError(t, err, nil)
+			if g, w := got.ExitCode(), 42; g != w {
+				t.Errorf("GOT: %v; WANT: %v", g, w)
+			}
+		})
+		t.Run("signaled", func(t *testing.T) {
+			got, err := Run(context.Background(), &Request{
+				Path: "/bin/sh",
+				Args: []string{"-c", "kill -TERM $$"},
+			})
+			ensureError(t, err, nil)
+			if g, w := got.ExitCode(), 128+int(syscall.SIGTERM); g != w {
+				t.Errorf("GOT: %v; WANT: %v", g, w)
+			}
+		})
+		t.Run("unknown signal", func(t *testing.T) {
+			resp := &Response{Code: -1, Err: ErrSignal{Err: errors.New("signal: killed")}}
+			if g, w := resp.ExitCode(), 1; g != w {
+				t.Errorf("GOT: %v; WANT: %v", g, w)
+			}
+		})
+	})
+	t.Run("success", func(t *testing.T) {
+		t.Run("zero", func(t *testing.T) {
+			got, err := Run(context.Background(), &Request{Path: "/usr/bin/true"})
+			ensureError(t, err, nil)
+			if g, w := got.Success(), true; g != w {
+				t.Errorf("GOT: %v; WANT: %v", g, w)
+			}
+		})
+		t.Run("non-zero", func(t *testing.T) {
+			got, err := Run(context.Background(), &Request{Path: "/usr/bin/false"})
+			ensureError(t, err, nil)
+			if g, w := got.Success(), false; g != w {
+				t.Errorf("GOT: %v; WANT: %v", g, w)
+			}
+		})
+		t.Run("signaled", func(t *testing.T) {
+			got, err := Run(context.Background(), &Request{
+				Path: "/bin/sh",
+				Args: []string{"-c", "kill -9 $$"},
+			})
+			ensureError(t, err, nil)
+			if g, w := got.Success(), false; g != w {
+				t.Errorf("GOT: %v; WANT: %v", g, w)
+			}
+		})
+		t.Run("zero with error", func(t *testing.T) {
+			resp := &Response{Err: ErrNoOutput{Stream: StreamStdout}}
+			if g, w := resp.Success(), false; g != w {
+				t.Errorf("GOT: %v; WANT: %v", g, w)
+			}
+		})
+	})
+	t.Run("signal", func(t *testing.T) {
+		t.Run("terminated", func(t *testing.T) {
+			got, err := Run(context.Background(), &Request{
+				Path: "/bin/sh",
+				Args: []string{"-c", "kill -TERM $$"},
+			})
+			ensureError(t, err, nil)
+			ensureError(t, got.Err, ErrSignal{Err: errors.New("signal: terminated")})
+			var se ErrSignal
+			if errors.As(got.Err, &se); se.Signal != syscall.SIGTERM {
+				t.Errorf("GOT: %v; WANT: %v", se.Signal, syscall.SIGTERM)
+			}
+		})
+		t.Run("killed on timeout", func(t *testing.T) {
+			got, err := Run(context.Background(), &Request{
+				Path:    "/bin/sleep",
+				Args:    []string{"5"},
+				Timeout: 50 * time.Millisecond,
+			})
+			ensureError(t, err, nil)
+			var se ErrSignal
+			if errors.As(got.Err, &se); se.Signal != syscall.SIGKILL {
+				t.Errorf("GOT: %v; WANT: %v", se.Signal, syscall.SIGKILL)
+			}
+		})
+	})
+	t.Run("success codes", func(t *testing.T) {
+		t.Run("in set", func(t *testing.T) {
+			got, err := Run(context.Background(), &Request{
+				Path:         "/bin/sh",
+				Args:         []string{"-c", "exit 1"},
+				SuccessCodes: []int{0, 1},
+			})
+			ensureError(t, err, nil)
+			ensureResponsesMatch(t, got, &Response{Code: 1, Stderr: []byte{}, Stdout: []byte{}})
+		})
+		t.Run("not in set", func(t *testing.T) {
+			got, err := Run(context.Background(), &Request{
+				Path:         "/bin/sh",
+				Args:         []string{"-c", "exit 2"},
+				SuccessCodes: []int{0, 1},
+			})
+			ensureError(t, err, nil)
+			want := &Response{
+				Code:   2,
+				Err:    ErrUnexpectedExit{Code: 2},
+				Stderr: []byte{},
+				Stdout: []byte{},
+			}
+			ensureResponsesMatch(t, got, want)
+		})
+		t.Run("zero not in set", func(t *testing.T) {
+			got, err := Run(context.Background(), &Request{
+				Path:         "/usr/bin/true",
+				SuccessCodes: []int{3},
+			})
+			ensureError(t, err, nil)
+			ensureError(t, got.Err, ErrUnexpectedExit{Code: 0})
+		})
+		t.Run("signal", func(t *testing.T) {
+			got, err := Run(context.Background(), &Request{
+				Path:         "/bin/sh",
+				Args:         []string{"-c", "kill -9 $$"},
+				SuccessCodes: []int{0},
+			})
+			ensureError(t, err, nil)
+			ensureError(t, got.Err, ErrSignal{Err: errors.New("signal: killed")})
+		})
+		t.Run("checked", func(t *testing.T) {
+			_, err := (&Request{
+				Path:         "/bin/sh",
+				Args:         []string{"-c", "exit 1"},
+				SuccessCodes: []int{0, 1},
+			}).RunVerboseChecked(context.Background())
+			ensureError(t, err, nil)
+
+			notFound := errors.New("not found")
+			_, err = (&Request{
+				Path:           "/bin/sh",
+				Args:           []string{"-c", "exit 2"},
+				SuccessCodes:   []int{0, 1},
+				ExitCodeErrors: map[int]error{2: notFound},
+			}).RunVerboseChecked(context.Background())
+			if !errors.Is(err, ErrExit{}) || !errors.Is(err, notFound) {
+				t.Errorf("GOT: %v; WANT: %v", err, notFound)
+			}
+		})
+	})
+	t.Run("retry on signal", func(t *testing.T) {
+		t.Run("succeeds on retry", func(t *testing.T) {
+			dir := t.TempDir()
+			got, err := Run(context.Background(), &Request{
+				Path:          "/bin/sh",
+				Args:          []string{"-c", "if [ -e attempted ]; then echo recovered; exit 0; fi; touch attempted; kill -9 $$"},
+				Dir:           dir,
+				RetryOnSignal: 2,
+			})
+			ensureError(t, err, nil)
+			want := &Response{
+				Stderr: []byte{},
+				Stdout: []byte("recovered\n"),
+			}
+			ensureResponsesMatch(t, got, want)
+		})
+		t.Run("exhausted", func(t *testing.T) {
+			dir := t.TempDir()
+			got, err := Run(context.Background(), &Request{
+				Path:          "/bin/sh",
+				Args:          []string{"-c", "echo x >> attempts; kill -9 $$"},
+				Dir:           dir,
+				RetryOnSignal: 2,
+			})
+			ensureError(t, err, nil)
+			if !got.Signaled() {
+				t.Errorf("GOT: %v; WANT: %v", got.Signaled(), true)
+			}
+			buf, err := os.ReadFile(filepath.Join(dir, "attempts"))
+			ensureError(t, err, nil)
+			if g, w := string(buf), "x\nx\nx\n"; g != w {
+				t.Errorf("GOT: %q; WANT: %q", g, w)
+			}
+		})
+		t.Run("non-zero exit not retried", func(t *testing.T) {
+			dir := t.TempDir()
+			got, err := Run(context.Background(), &Request{
+				Path:          "/bin/sh",
+				Args:          []string{"-c", "echo x >> attempts; exit 3"},
+				Dir:           dir,
+				RetryOnSignal: 2,
+			})
+			ensureError(t, err, nil)
+			if g, w := got.Code, 3; g != w {
+				t.Errorf("GOT: %v; WANT: %v", g, w)
+			}
+			buf, err := os.ReadFile(filepath.Join(dir, "attempts"))
+			ensureError(t, err, nil)
+			if g, w := string(buf), "x\n"; g != w {
+				t.Errorf("GOT: %q; WANT: %q", g, w)
+			}
+		})
+	})
+	t.Run("retry on empty output", func(t *testing.T) {
+		t.Run("succeeds on retry", func(t *testing.T) {
+			dir := t.TempDir()
+			got, err := Run(context.Background(), &Request{
+				Path:               "/bin/sh",
+				Args:               []string{"-c", "if [ -e attempted ]; then echo recovered; fi; touch attempted"},
+				Dir:                dir,
+				RetryOnEmptyOutput: 2,
+				RequireOutput:      true,
+			})
+			ensureError(t, err, nil)
+			want := &Response{
+				Stderr: []byte{},
+				Stdout: []byte("recovered\n"),
+			}
+			ensureResponsesMatch(t, got, want)
+		})
+		t.Run("exhausted", func(t *testing.T) {
+			dir := t.TempDir()
+			got, err := Run(context.Background(), &Request{
+				Path:               "/bin/sh",
+				Args:               []string{"-c", "echo x >> attempts"},
+				Dir:                dir,
+				RetryOnEmptyOutput: 2,
+				RequireOutput:      true,
+			})
+			ensureError(t, err, nil)
+			ensureError(t, got.Err, ErrNoOutput{Stream: StreamStdout})
+			buf, err := os.ReadFile(filepath.Join(dir, "attempts"))
+			ensureError(t, err, nil)
+			if g, w := string(buf), "x\nx\nx\n"; g != w {
+				t.Errorf("GOT: %q; WANT: %q", g, w)
+			}
+		})
+		t.Run("canceled", func(t *testing.T) {
+			dir := t.TempDir()
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+			got, err := Run(ctx, &Request{
+				Path:               "/bin/sh",
+				Args:               []string{"-c", "echo x >> attempts"},
+				Dir:                dir,
+				RetryOnEmptyOutput: 5,
+			})
+			ensureError(t, err, nil)
+			if g, w := got.Code, 0; g != w {
+				t.Errorf("GOT: %v; WANT: %v", g, w)
+			}
+			buf, err := os.ReadFile(filepath.Join(dir, "attempts"))
+			ensureError(t, err, nil)
+			if g, w := string(buf), "x\n"; g != w {
+				t.Errorf("GOT: %q; WANT: %q", g, w)
+			}
+		})
+		t.Run("non-zero exit not retried", func(t *testing.T) {
+			dir := t.TempDir()
+			got, err := Run(context.Background(), &Request{
+				Path:               "/bin/sh",
+				Args:               []string{"-c", "echo x >> attempts; exit 3"},
+				Dir:                dir,
+				RetryOnEmptyOutput: 2,
+			})
+			ensureError(t, err, nil)
+			if g, w := got.Code, 3; g != w {
+				t.Errorf("GOT: %v; WANT: %v", g, w)
+			}
+			buf, err := os.ReadFile(filepath.Join(dir, "attempts"))
+			ensureError(t, err, nil)
+			if g, w := string(buf), "x\n"; g != w {
+				t.Errorf("GOT: %q; WANT: %q", g, w)
+			}
+		})
+	})
+	t.Run("alarm after", func(t *testing.T) {
+		got, err := Run(context.Background(), &Request{
+			Path:       "/bin/sh",
+			Args:       []string{"-c", "trap 'echo alarm; exit 42' ALRM; while :; do sleep 0.01; done"},
+			AlarmAfter: 50 * time.Millisecond,
+		})
+		ensureError(t, err, nil)
+		want := &Response{
+			Code:   42,
+			Stderr: []byte{},
+			Stdout: []byte("alarm\n"),
+		}
+		ensureResponsesMatch(t, got, want)
+	})
+	t.Run("dump on timeout", func(t *testing.T) {
+		t.Run("go program", func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+			defer cancel()
+			got, err := Run(ctx, &Request{
+				Path:          os.Args[0],
+				Args:          []string{"-test.run=^TestHelperHang$"},
+				Env:           []string{"GORUN_TEST_HELPER_HANG=1"},
+				DumpOnTimeout: true,
+			})
+			ensureError(t, err, nil)
+			if got, want := string(got.Stderr), "SIGQUIT"; !strings.Contains(got, want) {
+				t.Errorf("GOT: %q; WANT: %q", got, want)
+			}
+			if got, want := string(got.Stderr), "goroutine "; !strings.Contains(got, want) {
+				t.Errorf("GOT: %q; WANT: %q", got, want)
+			}
+			// Go programs exit with status 2 after dumping.
+			if got, want := got.Code, 2; got != want {
+				t.Errorf("GOT: %v; WANT: %v", got, want)
+			}
+		})
+		t.Run("ignores quit", func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer cancel()
+			got, err := Run(ctx, &Request{
+				Path:          "/bin/sh",
+				Args:          []string{"-c", "trap 'echo dump >&2' QUIT; while :; do sleep 0.01; done"},
+				DumpOnTimeout: true,
+			})
+			ensureError(t, err, nil)
+			want := &Response{
+				Code:   -1,
+				Err:    ErrTimeout{Err: ErrSignal{Err: errors.New("signal: killed")}},
+				Stderr: []byte("dump\n"),
+				Stdout: []byte{},
+			}
+			ensureResponsesMatch(t, got, want)
+		})
+		t.Run("canceled", func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			time.AfterFunc(100*time.Millisecond, cancel)
+			got, err := Run(ctx, &Request{
+				Path:          "/bin/sh",
+				Args:          []string{"-c", "trap 'echo dump >&2' QUIT; while :; do sleep 0.01; done"},
+				DumpOnTimeout: true,
+			})
+			ensureError(t, err, nil)
+			if len(got.Stderr) > 0 {
+				t.Errorf("GOT: %q; WANT: %q", got.Stderr, "")
+			}
+		})
+	})
+	t.Run("hard timeout", func(t *testing.T) {
+		t.Run("ignores terminate", func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+
+			started := time.Now()
+			got, err := Run(ctx, &Request{
+				Path: "/bin/sh",
+				Args: []string{"-c", "trap '' TERM; while :; do sleep 0.01; done"},
+				Terminator: TerminatorFunc(func(_ context.Context, p *os.Process) error {
+					return p.Signal(syscall.SIGTERM)
+				}),
+				HardTimeout: 200 * time.Millisecond,
+			})
+			ensureError(t, err, nil)
+			if elapsed := time.Since(started); elapsed > time.Second {
+				t.Errorf("GOT: %v; WANT: < %v", elapsed, time.Second)
+			}
+			want := &Response{
+				Code:   -1,
+				Err:    ErrHardTimeout{Err: errors.New("signal: killed")},
+				Stderr: []byte{},
+				Stdout: []byte{},
+			}
+			ensureResponsesMatch(t, got, want)
+			if !errors.Is(got.Err, ErrSignal{}) {
+				t.Errorf("GOT: %v; WANT: %v", got.Err, ErrSignal{})
+			}
+		})
+		t.Run("grandchild holds output", func(t *testing.T) {
+			started := time.Now()
+			got, err := Run(context.Background(), &Request{
+				Path:        "/bin/sh",
+				Args:        []string{"-c", "sleep 5; :"},
+				HardTimeout: 100 * time.Millisecond,
+			})
+			ensureError(t, err, nil)
+			if elapsed := time.Since(started); elapsed > 3*time.Second {
+				t.Errorf("GOT: %v; WANT: < %v", elapsed, 3*time.Second)
+			}
+			if !errors.Is(got.Err, ErrHardTimeout{}) {
+				t.Errorf("GOT: %v; WANT: %v", got.Err, ErrHardTimeout{})
+			}
+		})
+		t.Run("not reached", func(t *testing.T) {
+			got, err := Run(context.Background(), &Request{
+				Path:        "/bin/echo",
+				HardTimeout: time.Second,
+			})
+			ensureError(t, err, nil)
+			want := &Response{
+				Stderr: []byte{},
+				Stdout: []byte("\n"),
+			}
+			ensureResponsesMatch(t, got, want)
+		})
+	})
+	t.Run("stdin idle timeout", func(t *testing.T) {
+		// feed writes each line to the returned reader after pausing,
+		// then closes it.
+		feed := func(pause time.Duration, lines ...string) io.Reader {
+			pr, pw := io.Pipe()
+			t.Cleanup(func() { _ = pr.Close() })
+			go func() {
+				for _, line := range lines {
+					time.Sleep(pause)
+					if _, err := io.WriteString(pw, line); err != nil {
+						return
+					}
+				}
+				_ = pw.Close()
+			}()
+			return pr
+		}
+
+		t.Run("within window", func(t *testing.T) {
+			got, err := Run(context.Background(), &Request{
+				Path:             "/bin/cat",
+				Stdin:            feed(20*time.Millisecond, "one\n", "two\n", "three\n"),
+				StdinIdleTimeout: 200 * time.Millisecond,
+			})
+			ensureError(t, err, nil)
+			want := &Response{
+				Stderr: []byte{},
+				Stdout: []byte("one\ntwo\nthree\n"),
+			}
+			ensureResponsesMatch(t, got, want)
+		})
+		t.Run("exceeds window", func(t *testing.T) {
+			started := time.Now()
+			got, err := Run(context.Background(), &Request{
+				Path:             "/bin/cat",
+				Stdin:            feed(5*time.Second, "one\n", "two\n"),
+				StdinIdleTimeout: 100 * time.Millisecond,
+			})
+			ensureError(t, err, nil)
+			if elapsed := time.Since(started); elapsed > time.Second {
+				t.Errorf("GOT: %v; WANT: < %v", elapsed, time.Second)
+			}
+			want := &Response{
+				Code:   -1,
+				Err:    ErrStdinIdleTimeout{Err: ErrSignal{Err: errors.New("signal: killed")}},
+				Stderr: []byte{},
+				Stdout: []byte{},
+			}
+			ensureResponsesMatch(t, got, want)
+			if got, want := got.Outcome, OutcomeTimeout; got != want {
+				t.Errorf("GOT: %v; WANT: %v", got, want)
+			}
+		})
+		t.Run("slow consumer", func(t *testing.T) {
+			got, err := Run(context.Background(), &Request{
+				Path:             "/bin/sh",
+				Args:             []string{"-c", "sleep 0.3; cat"},
+				Stdin:            strings.NewReader("input\n"),
+				StdinIdleTimeout: 100 * time.Millisecond,
+			})
+			ensureError(t, err, nil)
+			want := &Response{
+				Stderr: []byte{},
+				Stdout: []byte("input\n"),
+			}
+			ensureResponsesMatch(t, got, want)
+		})
+		t.Run("without stdin", func(t *testing.T) {
+			_, err := Run(context.Background(), &Request{
+				Path:             "/usr/bin/true",
+				StdinIdleTimeout: time.Second,
+			})
+			ensureError(t, err, ErrInvalidRequest{Err: errors.New("cannot set StdinIdleTimeout without Stdin, StdinReaders, or StdinReaderAt")})
+		})
+	})
+	t.Run("canceled", func(t *testing.T) {
+		t.Run("before start", func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+
+			_, err := Run(ctx, &Request{
+				Path: "/bin/sleep",
+				Args: []string{"1"},
+			})
+
+			ensureError(t, err, ErrSpawn{Err: errors.New("context canceled")})
+		})
+		t.Run("after start", func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+
+			go func() {
+				time.Sleep(100 * time.Millisecond)
+				cancel()
+			}()
+
+			got, err := Run(ctx, &Request{
+				Path: "/bin/sleep",
+				Args: []string{"1"},
+			})
+
+			ensureError(t, err, nil)
+
+			want := &Response{
+				Code:   -1,
+				Err:    ErrCanceled{Err: ErrSignal{Err: errors.New("signal: killed")}},
+				Stderr: []byte{},
+				Stdout: []byte{},
+			}
+
+			ensureResponsesMatch(t, got, want)
+			if !errors.Is(got.Err, ErrSignal{}) {
+				t.Errorf("GOT: %v; WANT: %v", got.Err, ErrSignal{})
+			}
+			if errors.Is(got.Err, ErrTimeout{}) {
+				t.Errorf("GOT: %v; WANT: not %v", got.Err, ErrTimeout{})
+			}
+		})
+	})
+}
+
+// TestHelperHang is not a real test. It blocks forever when run as a
+// child process by the dump on timeout test.
+func TestHelperHang(t *testing.T) {
+	if os.Getenv("GORUN_TEST_HELPER_HANG") != "1" {
+		t.Skip("only run as a child process")
+	}
+	time.Sleep(time.Hour)
 }
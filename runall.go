@@ -0,0 +1,64 @@
+package gorun
+
+import (
+	"context"
+	"sync"
+)
+
+// Result is the outcome of one of the Requests run by RunAll.
+type Result struct {
+	// Index is the index of the Request in the slice passed to RunAll.
+	Index int
+
+	// Response is the Response returned by running the Request, or nil
+	// when it returned an error.
+	Response *Response
+
+	// Err is the error returned by running the Request.
+	Err error
+}
+
+// RunAll runs each of reqs concurrently, with at most limit child
+// processes running at a time, or with no limit when limit is not
+// positive. It returns a Result for each of reqs, in the order of reqs
+// regardless of the order in which they complete, once every child
+// process has exited. When the context is done, requests that have not
+// yet been spawned are not spawned, and their Result has an ErrCanceled
+// error.
+func RunAll(ctx context.Context, reqs []*Request, limit int) []Result {
+	results := make([]Result, len(reqs))
+
+	var sem chan struct{}
+	if limit > 0 {
+		sem = make(chan struct{}, limit)
+	}
+
+	var wg sync.WaitGroup
+	for i, req := range reqs {
+		results[i].Index = i
+		if sem != nil {
+			select {
+			case <-ctx.Done():
+			case sem <- struct{}{}:
+			}
+		}
+		// Once the context is done, no more slots need be released,
+		// because no more requests are spawned.
+		if err := ctx.Err(); err != nil {
+			results[i].Err = ErrCanceled{Err: err}
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, req *Request) {
+			defer wg.Done()
+			results[i].Response, results[i].Err = req.Run(ctx)
+			if sem != nil {
+				<-sem
+			}
+		}(i, req)
+	}
+	wg.Wait()
+
+	return results
+}
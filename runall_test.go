@@ -0,0 +1,85 @@
+//go:build !windows
+// +build !windows
+
+package gorun
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunAll(t *testing.T) {
+	t.Run("order", func(t *testing.T) {
+		var reqs []*Request
+		for i := 0; i < 5; i++ {
+			// Later requests finish first.
+			reqs = append(reqs, &Request{
+				Path: "/bin/sh",
+				Args: []string{"-c", "sleep 0.0" + strconv.Itoa(5-i) + "; echo " + strconv.Itoa(i)},
+			})
+		}
+		results := RunAll(context.Background(), reqs, 0)
+		if got, want := len(results), len(reqs); got != want {
+			t.Fatalf("GOT: %v; WANT: %v", got, want)
+		}
+		for i, result := range results {
+			ensureError(t, result.Err, nil)
+			if got, want := result.Index, i; got != want {
+				t.Errorf("GOT: %v; WANT: %v", got, want)
+			}
+			if got, want := string(result.Response.Stdout), strconv.Itoa(i)+"\n"; got != want {
+				t.Errorf("GOT: %q; WANT: %q", got, want)
+			}
+		}
+	})
+
+	t.Run("limit", func(t *testing.T) {
+		// Each child process reports how many are running, including
+		// itself, by counting the files in a shared directory.
+		dir := t.TempDir()
+		script := `touch "$0/$$"; ls "$0" | wc -l; sleep 0.05; rm "$0/$$"`
+		var reqs []*Request
+		for i := 0; i < 8; i++ {
+			reqs = append(reqs, &Request{Path: "/bin/sh", Args: []string{"-c", script, dir}})
+		}
+		const limit = 3
+		results := RunAll(context.Background(), reqs, limit)
+		for _, result := range results {
+			ensureError(t, result.Err, nil)
+			running, err := strconv.Atoi(strings.TrimSpace(string(result.Response.Stdout)))
+			ensureError(t, err, nil)
+			if running < 1 || running > limit {
+				t.Errorf("GOT: %v; WANT: between 1 and %v", running, limit)
+			}
+		}
+	})
+
+	t.Run("cancel", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+		reqs := []*Request{
+			{Path: "/bin/sleep", Args: []string{"10"}},
+			{Path: "/bin/echo", Args: []string{"never"}},
+			{Path: "/bin/echo", Args: []string{"never"}},
+		}
+		started := time.Now()
+		results := RunAll(ctx, reqs, 1)
+		if elapsed := time.Since(started); elapsed > 5*time.Second {
+			t.Errorf("GOT: %v; WANT: < %v", elapsed, 5*time.Second)
+		}
+		ensureError(t, results[0].Err, nil)
+		if !errors.Is(results[0].Response.Err, ErrTimeout{}) {
+			t.Errorf("GOT: %v; WANT: %v", results[0].Response.Err, ErrTimeout{})
+		}
+		for _, result := range results[1:] {
+			ensureError(t, result.Err, ErrCanceled{Err: context.DeadlineExceeded})
+			if result.Response != nil {
+				t.Errorf("GOT: %v; WANT: %v", result.Response, nil)
+			}
+		}
+	})
+}
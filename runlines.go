@@ -0,0 +1,131 @@
+package gorun
+
+import (
+	"bytes"
+	"context"
+	"os/exec"
+	"sync"
+)
+
+// RunLines spawns a system command like Run does, but rather than
+// capturing the standard output of the child process in the Response,
+// it sends each line of it, without its newline terminator, to the
+// returned lines channel as it is read. A final line that lacks a
+// newline terminator is also sent. The lines channel is closed once
+// the standard output of the child process ends, after which the
+// Response is sent to the returned response channel, whose Stdout
+// field is nil, and whose Stderr field holds the standard error of the
+// child process.
+//
+// The caller must receive from the lines channel until it is closed,
+// or cancel the context, otherwise the child process blocks writing
+// its standard output. Once the context is done, lines that have not
+// been received are discarded.
+//
+// When this cannot spawn the requested program, it returns nil
+// channels and the error. When the request retries after a signal, the
+// lines of each attempt are sent to the lines channel, and an error
+// spawning a later attempt is reported in Response.Err.
+func (req *Request) RunLines(ctx context.Context) (<-chan string, <-chan *Response, error) {
+	lines, _, responses, err := req.runLines(ctx, false)
+	return lines, responses, err
+}
+
+// RunLinesSeparate spawns a system command like RunLines does, but also
+// sends each line the child process writes to its standard error to
+// the returned stderr channel rather than capturing it in the
+// Response. Both line channels are closed once the child process
+// exits, and the caller must concurrently receive from both of them
+// until they are closed, or cancel the context.
+func (req *Request) RunLinesSeparate(ctx context.Context) (<-chan string, <-chan string, <-chan *Response, error) {
+	return req.runLines(ctx, true)
+}
+
+func (req *Request) runLines(ctx context.Context, separateStderr bool) (<-chan string, <-chan string, <-chan *Response, error) {
+	stdout := &lineSender{ctx: ctx, lines: make(chan string)}
+	var stderr *lineSender
+	opts := runOptions{stdout: stdout}
+	if separateStderr {
+		stderr = &lineSender{ctx: ctx, lines: make(chan string)}
+		opts.stderr = stderr
+	}
+
+	var once sync.Once
+	started := make(chan struct{})
+	opts.start = func(_ context.Context, cmd *exec.Cmd) error {
+		err := cmd.Start()
+		if err == nil {
+			once.Do(func() { close(started) })
+		}
+		return err
+	}
+
+	failed := make(chan error, 1)
+	responses := make(chan *Response, 1)
+	go func() {
+		resp, err := req.runWith(ctx, opts)
+		stdout.close()
+		stderr.close()
+		if err != nil {
+			failed <- err
+			resp = &Response{Err: err}
+		}
+		responses <- resp
+		close(responses)
+	}()
+
+	select {
+	case <-started:
+	case err := <-failed:
+		return nil, nil, nil, err
+	}
+
+	var stderrLines <-chan string
+	if stderr != nil {
+		stderrLines = stderr.lines
+	}
+	return stdout.lines, stderrLines, responses, nil
+}
+
+// lineSender sends each line written to it, without its newline
+// terminator, to its lines channel, until its context is done.
+type lineSender struct {
+	ctx     context.Context
+	lines   chan string
+	partial []byte // the most recent line, when it lacks a newline
+}
+
+func (ls *lineSender) Write(p []byte) (int, error) {
+	n := len(p)
+	for {
+		i := bytes.IndexByte(p, '\n')
+		if i == -1 {
+			ls.partial = append(ls.partial, p...)
+			return n, nil
+		}
+		ls.send(string(append(ls.partial, p[:i]...)))
+		ls.partial = ls.partial[:0]
+		p = p[i+1:]
+	}
+}
+
+// send sends line to the lines channel, unless the context is done.
+func (ls *lineSender) send(line string) {
+	select {
+	case ls.lines <- line:
+	case <-ls.ctx.Done():
+	}
+}
+
+// close sends the final line when it lacks a newline, then closes the
+// lines channel. It is a no-op when invoked on a nil lineSender.
+func (ls *lineSender) close() {
+	if ls == nil {
+		return
+	}
+	if len(ls.partial) > 0 {
+		ls.send(string(ls.partial))
+		ls.partial = nil
+	}
+	close(ls.lines)
+}
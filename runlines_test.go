@@ -0,0 +1,99 @@
+//go:build !windows
+// +build !windows
+
+package gorun
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRunLines(t *testing.T) {
+	t.Run("lines", func(t *testing.T) {
+		lines, responses, err := (&Request{
+			Path: "/bin/sh",
+			Args: []string{"-c", "echo one; echo err >&2; echo two; printf three; exit 3"},
+		}).RunLines(context.Background())
+		ensureError(t, err, nil)
+
+		var got []string
+		for line := range lines {
+			got = append(got, line)
+		}
+		if g, w := strings.Join(got, ","), "one,two,three"; g != w {
+			t.Errorf("GOT: %q; WANT: %q", g, w)
+		}
+
+		resp := <-responses
+		want := &Response{
+			Code:   3,
+			Stderr: []byte("err\n"),
+		}
+		ensureResponsesMatch(t, resp, want)
+		if resp.Stdout != nil {
+			t.Errorf("GOT: %q; WANT: %v", resp.Stdout, nil)
+		}
+	})
+
+	t.Run("separate", func(t *testing.T) {
+		stdout, stderr, responses, err := (&Request{
+			Path: "/bin/sh",
+			Args: []string{"-c", "echo one; echo err1 >&2; echo two; echo err2 >&2"},
+		}).RunLinesSeparate(context.Background())
+		ensureError(t, err, nil)
+
+		var gotStdout, gotStderr []string
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for line := range stderr {
+				gotStderr = append(gotStderr, line)
+			}
+		}()
+		for line := range stdout {
+			gotStdout = append(gotStdout, line)
+		}
+		wg.Wait()
+
+		if g, w := strings.Join(gotStdout, ","), "one,two"; g != w {
+			t.Errorf("GOT: %q; WANT: %q", g, w)
+		}
+		if g, w := strings.Join(gotStderr, ","), "err1,err2"; g != w {
+			t.Errorf("GOT: %q; WANT: %q", g, w)
+		}
+		ensureResponsesMatch(t, <-responses, &Response{})
+	})
+
+	t.Run("cannot spawn", func(t *testing.T) {
+		lines, responses, err := (&Request{
+			Path: "/no-such-path",
+		}).RunLines(context.Background())
+		ensureError(t, err, ErrSpawn{Err: errors.New("fork/exec /no-such-path: no such file or directory")})
+		if lines != nil || responses != nil {
+			t.Errorf("GOT: %v, %v; WANT: nil channels", lines, responses)
+		}
+	})
+
+	t.Run("canceled while not receiving", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+		_, responses, err := (&Request{
+			Path: "/usr/bin/yes",
+		}).RunLines(ctx)
+		ensureError(t, err, nil)
+
+		select {
+		case resp := <-responses:
+			if got, want := resp.Outcome, OutcomeTimeout; got != want {
+				t.Errorf("GOT: %v; WANT: %v", got, want)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("response not delivered after context done")
+		}
+	})
+}
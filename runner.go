@@ -0,0 +1,104 @@
+package gorun
+
+import (
+	"context"
+	"os/exec"
+	"sync"
+)
+
+// Runner is an Executor that bounds how many child processes it runs
+// at once, so a program that runs many commands concurrently cannot
+// exhaust the process table of the host. The zero value of Runner runs
+// every Request it is given without any bound. A Runner is safe for
+// concurrent use by multiple goroutines, but must not be copied after
+// first use.
+type Runner struct {
+	// MaxConcurrentProcesses is the maximum number of child processes
+	// this Runner will run at once. When zero or negative, there is no
+	// bound. Changes made after the Runner is first used have no
+	// effect.
+	MaxConcurrentProcesses int
+
+	// DefaultArgs is a potentially empty list of command line arguments
+	// sent to every child process this Runner spawns, before the
+	// arguments in Request.Args, such as flags that a family of
+	// programs always ought to receive. A Request opts out of them by
+	// setting Request.NoDefaultArgs.
+	DefaultArgs []string
+
+	// ExecFunc is the potentially nil function used to start each
+	// child process, in place of calling cmd.Start. It allows starting
+	// the child process through a sandbox or other wrapper that needs
+	// to rewrite cmd.Path and cmd.Args, or otherwise adjust cmd, before
+	// starting it. It must either start cmd, typically by calling
+	// cmd.Start, or return an error, and must not wait for cmd.
+	ExecFunc func(ctx context.Context, cmd *exec.Cmd) error
+
+	once sync.Once
+	sem  chan struct{}
+}
+
+// Run executes req like Run does, except when this Runner is already
+// running MaxConcurrentProcesses child processes, it blocks until one
+// of them exits before spawning req. When the context is done while
+// blocked, it returns a nil Response and an ErrCanceled error without
+// spawning the child process.
+func (r *Runner) Run(ctx context.Context, req *Request) (*Response, error) {
+	req, release, err := r.acquire(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return req.runWith(ctx, runOptions{start: r.ExecFunc})
+}
+
+// Measure executes req like Request.Measure does, except it is bounded
+// and started like Run. When the context is done while blocked, it
+// returns a zero Metrics and an ErrCanceled error without spawning the
+// child process.
+func (r *Runner) Measure(ctx context.Context, req *Request) (Metrics, error) {
+	req, release, err := r.acquire(ctx, req)
+	if err != nil {
+		return Metrics{}, err
+	}
+	defer release()
+	return req.measure(ctx, r.ExecFunc)
+}
+
+// Benchmark executes req like Request.Benchmark does, except each run
+// is executed by Measure of this Runner.
+func (r *Runner) Benchmark(ctx context.Context, req *Request, warmup, runs int) (BenchResult, error) {
+	return req.benchmark(ctx, warmup, runs, func(ctx context.Context) (Metrics, error) {
+		return r.Measure(ctx, req)
+	})
+}
+
+// acquire blocks until this Runner may spawn another child process,
+// and returns req with DefaultArgs applied, along with the function
+// that must be invoked once the child process exits. When the context
+// is done while blocked, it returns an ErrCanceled error.
+func (r *Runner) acquire(ctx context.Context, req *Request) (*Request, func(), error) {
+	r.once.Do(func() {
+		if r.MaxConcurrentProcesses > 0 {
+			r.sem = make(chan struct{}, r.MaxConcurrentProcesses)
+		}
+	})
+
+	release := func() {}
+	if r.sem != nil {
+		select {
+		case <-ctx.Done():
+			return nil, nil, ErrCanceled{Err: ctx.Err()}
+		case r.sem <- struct{}{}:
+		}
+		release = func() { <-r.sem }
+	}
+
+	if len(r.DefaultArgs) > 0 && !req.NoDefaultArgs {
+		withDefaults := *req
+		withDefaults.Args = append(append(make([]string, 0, len(r.DefaultArgs)+len(req.Args)), r.DefaultArgs...), req.Args...)
+		req = &withDefaults
+	}
+
+	return req, release, nil
+}
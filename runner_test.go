@@ -0,0 +1,145 @@
+//go:build !windows
+// +build !windows
+
+package gorun
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRunner(t *testing.T) {
+	var _ Executor = &Runner{}
+
+	t.Run("max concurrent processes", func(t *testing.T) {
+		const limit = 2
+		const count = 6
+
+		dir := t.TempDir()
+		r := &Runner{MaxConcurrentProcesses: limit}
+
+		var wg sync.WaitGroup
+		responses := make([]*Response, count)
+		errs := make([]error, count)
+
+		started := time.Now()
+		for i := 0; i < count; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				responses[i], errs[i] = r.Run(context.Background(), &Request{
+					Path: "/bin/sh",
+					Args: []string{"-c", "touch $$; ls | wc -l; sleep 0.1; rm $$"},
+					Dir:  dir,
+				})
+			}(i)
+		}
+		wg.Wait()
+
+		if elapsed, want := time.Since(started), (count/limit)*100*time.Millisecond; elapsed < want {
+			t.Errorf("GOT: %v; WANT: >= %v", elapsed, want)
+		}
+		for i := 0; i < count; i++ {
+			ensureError(t, errs[i], nil)
+			running, err := strconv.Atoi(strings.TrimSpace(string(responses[i].Stdout)))
+			ensureError(t, err, nil)
+			if running > limit {
+				t.Errorf("GOT: %v; WANT: <= %v", running, limit)
+			}
+		}
+	})
+
+	t.Run("default args", func(t *testing.T) {
+		r := &Runner{DefaultArgs: []string{"--no-color", "--quiet"}}
+
+		req := &Request{
+			Path: "/bin/echo",
+			Args: []string{"one", "two"},
+		}
+		got, err := r.Run(context.Background(), req)
+		ensureError(t, err, nil)
+		if g, w := string(got.Stdout), "--no-color --quiet one two\n"; g != w {
+			t.Errorf("GOT: %q; WANT: %q", g, w)
+		}
+		if g, w := len(req.Args), 2; g != w {
+			t.Errorf("GOT: %v; WANT: %v", g, w)
+		}
+
+		req.NoDefaultArgs = true
+		got, err = r.Run(context.Background(), req)
+		ensureError(t, err, nil)
+		if g, w := string(got.Stdout), "one two\n"; g != w {
+			t.Errorf("GOT: %q; WANT: %q", g, w)
+		}
+	})
+
+	t.Run("exec func", func(t *testing.T) {
+		var invoked int
+		r := &Runner{
+			ExecFunc: func(ctx context.Context, cmd *exec.Cmd) error {
+				invoked++
+				// Run the program by way of env(1), like a sandbox
+				// wrapper would.
+				cmd.Args = append([]string{"/usr/bin/env", "GORUN=sandboxed"}, cmd.Args...)
+				cmd.Path = cmd.Args[0]
+				return cmd.Start()
+			},
+		}
+
+		got, err := r.Run(context.Background(), &Request{
+			Path: "/bin/sh",
+			Args: []string{"-c", "echo $GORUN"},
+		})
+		ensureError(t, err, nil)
+		if g, w := invoked, 1; g != w {
+			t.Errorf("GOT: %v; WANT: %v", g, w)
+		}
+		want := &Response{
+			Stderr: []byte{},
+			Stdout: []byte("sandboxed\n"),
+		}
+		ensureResponsesMatch(t, got, want)
+	})
+
+	t.Run("exec func error", func(t *testing.T) {
+		r := &Runner{
+			ExecFunc: func(ctx context.Context, cmd *exec.Cmd) error {
+				return errors.New("sandbox unavailable")
+			},
+		}
+		got, err := r.Run(context.Background(), &Request{Path: "/usr/bin/true"})
+		ensureError(t, err, ErrSpawn{Err: errors.New("sandbox unavailable")})
+		if got != nil {
+			t.Errorf("GOT: %v; WANT: %v", got, nil)
+		}
+	})
+
+	t.Run("canceled while blocked", func(t *testing.T) {
+		r := &Runner{MaxConcurrentProcesses: 1}
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			_, _ = r.Run(context.Background(), &Request{
+				Path: "/bin/sleep",
+				Args: []string{"0.2"},
+			})
+		}()
+		time.Sleep(50 * time.Millisecond)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		got, err := r.Run(ctx, &Request{Path: "/usr/bin/true"})
+		ensureError(t, err, ErrCanceled{Err: context.DeadlineExceeded})
+		if got != nil {
+			t.Errorf("GOT: %v; WANT: %v", got, nil)
+		}
+		<-done
+	})
+}
@@ -0,0 +1,25 @@
+//go:build !windows
+// +build !windows
+
+package gorun
+
+import (
+	"os"
+	"runtime"
+	"syscall"
+)
+
+// maxRSS returns the maximum resident set size in bytes of the exited
+// process described by ps, or 0 when it is not available.
+func maxRSS(ps *os.ProcessState) int64 {
+	ru, ok := ps.SysUsage().(*syscall.Rusage)
+	if !ok || ru == nil {
+		return 0
+	}
+	// Darwin reports ru_maxrss in bytes, while other Unix like
+	// operating systems report it in kilobytes.
+	if runtime.GOOS == "darwin" || runtime.GOOS == "ios" {
+		return int64(ru.Maxrss)
+	}
+	return int64(ru.Maxrss) * 1024
+}
@@ -0,0 +1,38 @@
+//go:build !windows
+// +build !windows
+
+package gorun
+
+import (
+	"context"
+	"testing"
+)
+
+func TestResourceUsage(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		args []string
+		code int
+	}{
+		{name: "success", args: []string{"-c", "i=0; while [ $i -lt 100000 ]; do i=$((i+1)); done"}, code: 0},
+		{name: "non-zero", args: []string{"-c", "i=0; while [ $i -lt 100000 ]; do i=$((i+1)); done; exit 3"}, code: 3},
+		{name: "signaled", args: []string{"-c", "i=0; while [ $i -lt 100000 ]; do i=$((i+1)); done; kill -9 $$"}, code: -1},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := Run(context.Background(), &Request{Path: "/bin/sh", Args: tc.args})
+			ensureError(t, err, nil)
+			if got.Code != tc.code {
+				t.Errorf("GOT: %v; WANT: %v", got.Code, tc.code)
+			}
+			if got.UserTime <= 0 {
+				t.Errorf("GOT: %v; WANT: > 0", got.UserTime)
+			}
+			if got.SystemTime < 0 {
+				t.Errorf("GOT: %v; WANT: >= 0", got.SystemTime)
+			}
+			if got.MaxRSS <= 0 {
+				t.Errorf("GOT: %v; WANT: > 0", got.MaxRSS)
+			}
+		})
+	}
+}
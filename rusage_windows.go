@@ -0,0 +1,7 @@
+package gorun
+
+import "os"
+
+// maxRSS returns 0 because Windows does not report the maximum resident
+// set size of an exited process.
+func maxRSS(_ *os.ProcessState) int64 { return 0 }
@@ -0,0 +1,92 @@
+package gorun
+
+import (
+	"os"
+	"os/exec"
+	"sort"
+	"strconv"
+)
+
+// secretFiles are the files through which secrets are passed to a child
+// process.
+type secretFiles struct {
+	files []*os.File // memory backed files passed to the child process
+	temps []string   // paths of temporary files to remove
+}
+
+// release closes and removes the files through which secrets were
+// passed to the child process, once it has exited.
+func (sf *secretFiles) release() {
+	for _, f := range sf.files {
+		_ = f.Close()
+	}
+	for _, path := range sf.temps {
+		_ = os.Remove(path)
+	}
+}
+
+// addSecrets writes each of req.Secrets to a file available to cmd, and
+// adds the path of each file to the environment of cmd. The returned
+// secretFiles must be released after the child process exits, even
+// when an error is returned.
+func (req *Request) addSecrets(cmd *exec.Cmd) (*secretFiles, error) {
+	sf := new(secretFiles)
+	if len(req.Secrets) == 0 {
+		return sf, nil
+	}
+
+	names := make([]string, 0, len(req.Secrets))
+	for name := range req.Secrets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	env := cmd.Environ()
+	for _, name := range names {
+		path, err := sf.add(cmd, req.Secrets[name])
+		if err != nil {
+			return sf, err
+		}
+		env = append(env, name+"_FILE="+path)
+	}
+	cmd.Env = env
+	return sf, nil
+}
+
+// add writes secret to a file available to cmd, and returns the path
+// from which the child process can read it. The file is memory backed
+// and inherited by the child process when the platform supports it,
+// and otherwise is a temporary file readable only by its owner.
+func (sf *secretFiles) add(cmd *exec.Cmd, secret []byte) (string, error) {
+	if f, err := memfd("gorun-secret"); err == nil {
+		sf.files = append(sf.files, f)
+		if _, err = f.Write(secret); err != nil {
+			return "", err
+		}
+		if _, err = f.Seek(0, 0); err != nil {
+			return "", err
+		}
+		cmd.ExtraFiles = append(cmd.ExtraFiles, f)
+		// Files in ExtraFiles follow standard input, output, and error.
+		return "/proc/self/fd/" + strconv.Itoa(2+len(cmd.ExtraFiles)), nil
+	}
+	return sf.addTemp(secret)
+}
+
+// addTemp writes secret to a new temporary file readable only by its
+// owner, and returns its path.
+func (sf *secretFiles) addTemp(secret []byte) (string, error) {
+	f, err := os.CreateTemp("", "gorun-secret-*")
+	if err != nil {
+		return "", err
+	}
+	sf.temps = append(sf.temps, f.Name())
+	_, err = f.Write(secret)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return "", err
+	}
+	return f.Name(), nil
+}
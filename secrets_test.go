@@ -0,0 +1,87 @@
+//go:build !windows
+// +build !windows
+
+package gorun
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestSecrets(t *testing.T) {
+	t.Run("readable by child", func(t *testing.T) {
+		got, err := Run(context.Background(), &Request{
+			Path: "/bin/sh",
+			Args: []string{"-c", `cat "$TOKEN_FILE"; echo; cat "$OTHER_FILE"; echo; echo "$TOKEN_FILE" >&2`},
+			Env:  []string{"A=1"},
+			Secrets: map[string][]byte{
+				"TOKEN": []byte("s3cr3t"),
+				"OTHER": []byte("hunter2"),
+			},
+		})
+		ensureError(t, err, nil)
+		if g, w := string(got.Stdout), "s3cr3t\nhunter2\n"; g != w {
+			t.Errorf("GOT: %q; WANT: %q", g, w)
+		}
+
+		// The secret file, whether memory backed or temporary, must no
+		// longer be reachable after the run.
+		path := strings.TrimSpace(string(got.Stderr))
+		if !strings.HasPrefix(path, "/proc/self/fd/") {
+			if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+				t.Errorf("GOT: %v; WANT: %v", err, os.ErrNotExist)
+			}
+		}
+	})
+
+	t.Run("not in environment", func(t *testing.T) {
+		got, err := Run(context.Background(), &Request{
+			Path:    "/usr/bin/env",
+			Secrets: map[string][]byte{"TOKEN": []byte("s3cr3t")},
+		})
+		ensureError(t, err, nil)
+		if strings.Contains(string(got.Stdout), "s3cr3t") {
+			t.Errorf("GOT: %q; WANT: secret absent", got.Stdout)
+		}
+		if !strings.Contains(string(got.Stdout), "TOKEN_FILE=") {
+			t.Errorf("GOT: %q; WANT: TOKEN_FILE", got.Stdout)
+		}
+		if !strings.Contains(string(got.Stdout), "PATH=") {
+			t.Errorf("GOT: %q; WANT: inherited environment", got.Stdout)
+		}
+	})
+
+	t.Run("temporary file removed", func(t *testing.T) {
+		sf := new(secretFiles)
+		path, err := sf.addTemp([]byte("s3cr3t"))
+		ensureError(t, err, nil)
+
+		fi, err := os.Stat(path)
+		ensureError(t, err, nil)
+		if g, w := fi.Mode().Perm(), os.FileMode(0o600); g != w {
+			t.Errorf("GOT: %v; WANT: %v", g, w)
+		}
+		out, err := exec.Command("/bin/cat", path).Output()
+		ensureError(t, err, nil)
+		if g, w := string(out), "s3cr3t"; g != w {
+			t.Errorf("GOT: %q; WANT: %q", g, w)
+		}
+
+		sf.release()
+		if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("GOT: %v; WANT: %v", err, os.ErrNotExist)
+		}
+	})
+
+	t.Run("invalid name", func(t *testing.T) {
+		_, err := Run(context.Background(), &Request{
+			Path:    "/usr/bin/true",
+			Secrets: map[string][]byte{"A=B": nil},
+		})
+		ensureError(t, err, ErrInvalidRequest{Err: errors.New(`invalid secret name: "A=B"`)})
+	})
+}
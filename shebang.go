@@ -0,0 +1,72 @@
+package gorun
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"syscall"
+	"unicode/utf8"
+)
+
+// explainSpawnError returns err, or when err indicates the program
+// executable file at path, the program Run executed after resolving
+// Path and Fallbacks, has an unrecognized format but appears to be a
+// text script, an error that explains the likely cause.
+func (req *Request) explainSpawnError(path string, err error) error {
+	if !errors.Is(err, syscall.ENOEXEC) {
+		return err
+	}
+	name := path
+	if req.Dir != "" && !filepath.IsAbs(name) {
+		name = filepath.Join(req.Dir, name)
+	}
+	if !looksLikeScript(name) {
+		return err
+	}
+	return missingShebangError{Err: err, Path: path}
+}
+
+// looksLikeScript returns true when the beginning of the named file is
+// valid UTF-8 text without any NUL bytes, and it does not start with
+// "#!".
+func looksLikeScript(name string) bool {
+	fh, err := os.Open(name)
+	if err != nil {
+		return false
+	}
+	defer fh.Close()
+
+	buf := make([]byte, 512)
+	n, err := io.ReadFull(fh, buf)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return false
+	}
+	buf = buf[:n]
+
+	if len(buf) == 0 || bytes.HasPrefix(buf, []byte("#!")) || bytes.IndexByte(buf, 0) >= 0 {
+		return false
+	}
+	if n == cap(buf) {
+		// Avoid checking a multi-byte rune split at the end of the
+		// buffer by only checking complete lines.
+		if i := bytes.LastIndexByte(buf, '\n'); i >= 0 {
+			buf = buf[:i]
+		}
+	}
+	return utf8.Valid(buf)
+}
+
+// missingShebangError explains that a program could not be executed
+// because it appears to be a script that lacks a "#!" line.
+type missingShebangError struct {
+	Err  error
+	Path string
+}
+
+func (e missingShebangError) Error() string {
+	return e.Err.Error() + " (" + e.Path + " appears to be a script without a #! line naming its interpreter: add one, or run its interpreter with the script as an argument)"
+}
+
+func (e missingShebangError) Unwrap() error { return e.Err }
@@ -0,0 +1,70 @@
+//go:build linux && (amd64 || arm64)
+// +build linux
+// +build amd64 arm64
+
+package gorun
+
+import (
+	"context"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"syscall"
+)
+
+// canBlockSignals is true when this platform supports
+// Request.BlockSignals.
+const canBlockSignals = true
+
+// blockSignalsHelper is the name of the program, found in PATH, that
+// blocks signals and then executes the child program, which is built
+// from the cmd/gorun-blocksignals directory of this module.
+const blockSignalsHelper = "gorun-blocksignals"
+
+// blockSignalsStart returns a function that starts cmd like start does,
+// except it executes the program of cmd through blockSignalsHelper, so
+// that it starts with exactly the signals in signals blocked. The
+// returned function reports when the helper cannot execute the program
+// as an error, after reaping the helper.
+func blockSignalsStart(signals []syscall.Signal, start func(context.Context, *exec.Cmd) error) func(context.Context, *exec.Cmd) error {
+	var mask uint64
+	for _, sig := range signals {
+		mask |= 1 << (sig - 1)
+	}
+	return func(ctx context.Context, cmd *exec.Cmd) error {
+		if cmd.Err != nil {
+			return cmd.Err
+		}
+		helper, err := exec.LookPath(blockSignalsHelper)
+		if err != nil {
+			return err
+		}
+
+		// The helper closes its end of the pipe when it executes the
+		// program, or writes the errno of its failure to it.
+		status, w, err := os.Pipe()
+		if err != nil {
+			return err
+		}
+		defer status.Close()
+		program := cmd.Path
+		cmd.ExtraFiles = append(cmd.ExtraFiles, w)
+		fd := 2 + len(cmd.ExtraFiles) // following standard input, output, and error
+		cmd.Args = append([]string{helper, strconv.Itoa(fd), strconv.FormatUint(mask, 16), program}, cmd.Args...)
+		cmd.Path = helper
+
+		err = start(ctx, cmd)
+		_ = w.Close()
+		if err != nil {
+			return err
+		}
+		b, _ := io.ReadAll(status)
+		if len(b) == 0 {
+			return nil
+		}
+		_ = cmd.Wait()
+		errno, _ := strconv.Atoi(string(b))
+		return &os.PathError{Op: "exec", Path: program, Err: syscall.Errno(errno)}
+	}
+}
@@ -0,0 +1,124 @@
+//go:build amd64 || arm64
+// +build amd64 arm64
+
+package gorun
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+// installBlockSignalsHelper builds the helper that BlockSignals requires
+// into a temporary directory at the front of PATH, or skips the test
+// when it cannot.
+func installBlockSignalsHelper(tb testing.TB) {
+	tb.Helper()
+	if !canBlockSignals {
+		tb.Skip("cannot block signals on this platform")
+	}
+	goTool, err := exec.LookPath("go")
+	if err != nil {
+		tb.Skip("cannot build helper without the go command")
+	}
+	dir := tb.TempDir()
+	if out, err := exec.Command(goTool, "build", "-o", dir, "./cmd/"+blockSignalsHelper).CombinedOutput(); err != nil {
+		tb.Fatalf("cannot build helper: %v: %s", err, out)
+	}
+	tb.Setenv("PATH", dir+string(filepath.ListSeparator)+os.Getenv("PATH"))
+}
+
+func TestChildSignalMask(t *testing.T) {
+	t.Run("default", func(t *testing.T) {
+		// The Go runtime restores the mask this process started with
+		// between fork and exec, rather than using the mask of
+		// whichever thread happened to spawn the child process.
+		resp, err := Run(context.Background(), &Request{
+			Path: "/bin/grep",
+			Args: []string{"SigBlk", "/proc/self/status"},
+		})
+		ensureError(t, err, nil)
+
+		got := strings.TrimSpace(string(resp.Stdout))
+		if want := "SigBlk:\t0000000000000000"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+	})
+
+	t.Run("invalid signal", func(t *testing.T) {
+		_, err := Run(context.Background(), &Request{
+			Path:         "/usr/bin/true",
+			BlockSignals: []syscall.Signal{65},
+		})
+		ensureError(t, err, ErrInvalidRequest{Err: errors.New("cannot block signal 65")})
+	})
+
+	t.Run("missing helper", func(t *testing.T) {
+		if !canBlockSignals {
+			t.Skip("cannot block signals on this platform")
+		}
+		t.Setenv("PATH", t.TempDir())
+		_, err := Run(context.Background(), &Request{
+			Path:         "/usr/bin/true",
+			BlockSignals: []syscall.Signal{syscall.SIGUSR1},
+		})
+		ensureError(t, err, ErrSpawn{Err: errors.New(`exec: "gorun-blocksignals": executable file not found in $PATH`)})
+	})
+
+	installBlockSignalsHelper(t)
+
+	t.Run("blocked", func(t *testing.T) {
+		resp, err := Run(context.Background(), &Request{
+			Path:         "/bin/grep",
+			Args:         []string{"SigBlk", "/proc/self/status"},
+			BlockSignals: []syscall.Signal{syscall.SIGUSR1, syscall.SIGTERM},
+		})
+		ensureError(t, err, nil)
+
+		got := strings.TrimSpace(string(resp.Stdout))
+		// Bit n-1 represents signal n: SIGUSR1 is 10 and SIGTERM is 15.
+		if want := "SigBlk:\t0000000000004200"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+	})
+
+	t.Run("arguments", func(t *testing.T) {
+		resp, err := Run(context.Background(), &Request{
+			Path:         "sh",
+			Args:         []string{"-c", `echo "$0" "$@"`, "zero", "one", "two words"},
+			BlockSignals: []syscall.Signal{syscall.SIGUSR1},
+		})
+		ensureError(t, err, nil)
+		if g, w := string(resp.Stdout), "zero one two words\n"; g != w {
+			t.Errorf("GOT: %q; WANT: %q", g, w)
+		}
+	})
+
+	t.Run("with MaxChildProcesses", func(t *testing.T) {
+		resp, err := Run(context.Background(), &Request{
+			Path:              "/bin/grep",
+			Args:              []string{"SigBlk", "/proc/self/status"},
+			BlockSignals:      []syscall.Signal{syscall.SIGUSR1},
+			MaxChildProcesses: 3,
+		})
+		ensureError(t, err, nil)
+
+		got := strings.TrimSpace(string(resp.Stdout))
+		if want := "SigBlk:\t0000000000000200"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+	})
+
+	t.Run("missing program", func(t *testing.T) {
+		_, err := Run(context.Background(), &Request{
+			Path:         "/does/not/exist",
+			BlockSignals: []syscall.Signal{syscall.SIGUSR1},
+		})
+		ensureError(t, err, ErrSpawn{Err: errors.New("exec /does/not/exist: no such file or directory")})
+	})
+}
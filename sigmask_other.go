@@ -0,0 +1,18 @@
+//go:build !linux || !(amd64 || arm64)
+// +build !linux !amd64,!arm64
+
+package gorun
+
+import (
+	"context"
+	"os/exec"
+	"syscall"
+)
+
+// canBlockSignals is true when this platform supports
+// Request.BlockSignals.
+const canBlockSignals = false
+
+func blockSignalsStart(_ []syscall.Signal, start func(context.Context, *exec.Cmd) error) func(context.Context, *exec.Cmd) error {
+	return start
+}
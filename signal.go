@@ -0,0 +1,72 @@
+//go:build !windows
+// +build !windows
+
+package gorun
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"syscall"
+	"time"
+)
+
+// signalNumber returns the number of the signal that terminated the
+// child process whose wait error is wrapped by err.
+func signalNumber(err error) (int, bool) {
+	var ee *exec.ExitError
+	if !errors.As(err, &ee) {
+		return 0, false
+	}
+	ws, ok := ee.Sys().(syscall.WaitStatus)
+	if !ok || !ws.Signaled() {
+		return 0, false
+	}
+	return int(ws.Signal()), true
+}
+
+// canSendAlarm is true when this platform supports Request.AlarmAfter.
+const canSendAlarm = true
+
+// sendAlarm sends SIGALRM to p.
+func sendAlarm(p *os.Process) error {
+	return p.Signal(syscall.SIGALRM)
+}
+
+// canUseGracePeriod is true when this platform supports
+// Request.GracePeriod.
+const canUseGracePeriod = true
+
+// sendTerminate sends SIGTERM to p.
+func sendTerminate(p *os.Process) error {
+	return p.Signal(syscall.SIGTERM)
+}
+
+// sendInterrupt sends SIGINT to p.
+func sendInterrupt(p *os.Process) error {
+	return p.Signal(syscall.SIGINT)
+}
+
+// canDumpOnTimeout is true when this platform supports
+// Request.DumpOnTimeout.
+const canDumpOnTimeout = true
+
+// dumpGracePeriod is how long to wait for a child process to exit after
+// sending it SIGQUIT before terminating it.
+const dumpGracePeriod = time.Second
+
+// dumpBeforeTerminate sends SIGQUIT to p, then waits for it to exit,
+// but no longer than dumpGracePeriod.
+func dumpBeforeTerminate(p *os.Process) {
+	if p.Signal(syscall.SIGQUIT) != nil {
+		return
+	}
+	deadline := time.Now().Add(dumpGracePeriod)
+	for time.Now().Before(deadline) {
+		// Signaling a process fails once it has been waited on.
+		if p.Signal(syscall.Signal(0)) != nil {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
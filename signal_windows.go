@@ -0,0 +1,26 @@
+package gorun
+
+import "os"
+
+// signalNumber returns false because Windows processes do not terminate
+// due to signals.
+func signalNumber(_ error) (int, bool) { return 0, false }
+
+// canSendAlarm is true when this platform supports Request.AlarmAfter.
+const canSendAlarm = false
+
+func sendAlarm(_ *os.Process) error { return nil }
+
+// canUseGracePeriod is true when this platform supports
+// Request.GracePeriod.
+const canUseGracePeriod = false
+
+func sendTerminate(_ *os.Process) error { return nil }
+
+func sendInterrupt(_ *os.Process) error { return nil }
+
+// canDumpOnTimeout is true when this platform supports
+// Request.DumpOnTimeout.
+const canDumpOnTimeout = false
+
+func dumpBeforeTerminate(_ *os.Process) {}
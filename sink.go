@@ -0,0 +1,72 @@
+package gorun
+
+import (
+	"context"
+	"io"
+	"sync"
+)
+
+// RunSink executes a system command like Run does, but rather than
+// capturing the output of the child process in the Response, it invokes
+// sink with each chunk of output as it is read, along with either
+// StreamStdout or StreamStderr to identify which stream it was read
+// from. Invocations of sink are serialized, so sink need not be safe for
+// concurrent use, and while sink is running, no more output is read
+// from the child process, which eventually blocks when it writes more
+// than its pipe can hold. The chunk is only valid until sink returns.
+//
+// When sink returns an error, the child process is sent a kill signal,
+// sink is not invoked again, the request is not retried, regardless of
+// Retry, RetryOnSignal, and RetryOnEmptyOutput, and the returned
+// Response has an ErrSinkAborted error wrapping that error in its Err
+// field.
+//
+// The Stdout and Stderr fields of the returned Response are nil.
+func (req *Request) RunSink(ctx context.Context, sink func(chunk []byte, stream int) error) (*Response, error) {
+	return req.runWith(ctx, runOptions{sink: &sinkWriter{sink: sink}})
+}
+
+// sinkWriter passes each chunk written to any of its streams to sink,
+// until sink returns an error.
+type sinkWriter struct {
+	lock sync.Mutex
+	sink func([]byte, int) error
+	kill func()
+	err  error
+}
+
+// stream returns an io.Writer that passes each chunk written to it to
+// the sink as having been read from the specified stream.
+func (sw *sinkWriter) stream(stream int) io.Writer {
+	return sinkStream{sw: sw, stream: stream}
+}
+
+// abortErr returns the error returned by the sink, or nil when the sink
+// did not return an error or sw is nil.
+func (sw *sinkWriter) abortErr() error {
+	if sw == nil {
+		return nil
+	}
+	sw.lock.Lock()
+	defer sw.lock.Unlock()
+	return sw.err
+}
+
+type sinkStream struct {
+	sw     *sinkWriter
+	stream int
+}
+
+func (ss sinkStream) Write(p []byte) (int, error) {
+	ss.sw.lock.Lock()
+	defer ss.sw.lock.Unlock()
+	if ss.sw.err == nil {
+		if err := ss.sw.sink(p, ss.stream); err != nil {
+			ss.sw.err = err
+			ss.sw.kill()
+		}
+	}
+	// Keep draining the output after the sink fails, so the child
+	// process does not block writing before it is terminated.
+	return len(p), nil
+}
@@ -0,0 +1,105 @@
+//go:build !windows
+// +build !windows
+
+package gorun
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRunSink(t *testing.T) {
+	t.Run("all output", func(t *testing.T) {
+		var stderr, stdout []byte
+		resp, err := (&Request{
+			Path: "/bin/sh",
+			Args: []string{"-c", "echo out; echo err >&2; exit 2"},
+		}).RunSink(context.Background(), func(chunk []byte, stream int) error {
+			switch stream {
+			case StreamStdout:
+				stdout = append(stdout, chunk...)
+			case StreamStderr:
+				stderr = append(stderr, chunk...)
+			}
+			return nil
+		})
+		ensureError(t, err, nil)
+		ensureResponsesMatch(t, resp, &Response{Code: 2})
+		if resp.Stdout != nil || resp.Stderr != nil {
+			t.Errorf("GOT: %q, %q; WANT: nil", resp.Stdout, resp.Stderr)
+		}
+		if got, want := string(stdout), "out\n"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+		if got, want := string(stderr), "err\n"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+	})
+
+	t.Run("abort", func(t *testing.T) {
+		errEnough := errors.New("enough")
+		var total int
+		var calls int
+
+		started := time.Now()
+		resp, err := (&Request{
+			Path: "/bin/sh",
+			Args: []string{"-c", "while :; do echo 0123456789; sleep 0.01; done"},
+		}).RunSink(context.Background(), func(chunk []byte, stream int) error {
+			calls++
+			total += len(chunk)
+			if total >= 50 {
+				return errEnough
+			}
+			return nil
+		})
+		ensureError(t, err, nil)
+
+		if elapsed := time.Since(started); elapsed > 2*time.Second {
+			t.Errorf("GOT: %v; WANT: < %v", elapsed, 2*time.Second)
+		}
+		if !errors.Is(resp.Err, ErrSinkAborted{}) || !errors.Is(resp.Err, errEnough) {
+			t.Errorf("GOT: %v; WANT: %v", resp.Err, ErrSinkAborted{Err: errEnough})
+		}
+		if got, want := resp.Code, -1; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		calledAtAbort := calls
+		time.Sleep(50 * time.Millisecond)
+		if calls != calledAtAbort {
+			t.Errorf("GOT: %v; WANT: %v", calls, calledAtAbort)
+		}
+	})
+
+	t.Run("abort not retried", func(t *testing.T) {
+		errEnough := errors.New("enough")
+		var calls, spawned int
+
+		resp, err := (&Request{
+			Path:    "/bin/sh",
+			Args:    []string{"-c", "echo attempt; exec sleep 5"},
+			OnStart: func(int) { spawned++ },
+			Retry: &Retry{
+				MaxAttempts: 3,
+				Backoff:     time.Millisecond,
+				RetryOn:     func(*Response, error) bool { return true },
+			},
+		}).RunSink(context.Background(), func(chunk []byte, stream int) error {
+			calls++
+			return errEnough
+		})
+		ensureError(t, err, nil)
+
+		if !errors.Is(resp.Err, ErrSinkAborted{}) || !errors.Is(resp.Err, errEnough) {
+			t.Errorf("GOT: %v; WANT: %v", resp.Err, ErrSinkAborted{Err: errEnough})
+		}
+		if got, want := calls, 1; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		if got, want := spawned, 1; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+}
@@ -0,0 +1,104 @@
+package gorun
+
+import (
+	"bytes"
+	"io"
+	"os"
+)
+
+// spillWriter writes to buf until more than threshold bytes have been
+// written to it since offset, then moves them to a new temporary file,
+// to which it writes everything thereafter.
+type spillWriter struct {
+	buf       *bytes.Buffer
+	offset    int
+	threshold int64
+	f         *os.File
+}
+
+func (sw *spillWriter) Write(p []byte) (int, error) {
+	if sw.f == nil {
+		if int64(sw.buf.Len()-sw.offset+len(p)) <= sw.threshold {
+			return sw.buf.Write(p)
+		}
+		f, err := os.CreateTemp("", "gorun-stdout-*")
+		if err != nil {
+			return 0, err
+		}
+		sw.f = f
+		if _, err = f.Write(sw.buf.Bytes()[sw.offset:]); err != nil {
+			return 0, err
+		}
+		sw.buf.Truncate(sw.offset)
+	}
+	return sw.f.Write(p)
+}
+
+// close closes the spill file, returning its path, or the empty string
+// when nothing was spilled. It returns the empty string when invoked on
+// a nil spillWriter.
+func (sw *spillWriter) close() (string, error) {
+	if sw == nil || sw.f == nil {
+		return "", nil
+	}
+	return sw.f.Name(), sw.f.Close()
+}
+
+// TailStdout returns at most the final n lines the child process wrote
+// to its standard output, without their newline terminators, in the
+// order they were written. When its standard output was spilled to
+// StdoutFile, only the end of that file is read, so the tail of a large
+// output is available without loading the whole of it. When fewer than
+// n lines were written, all of them are returned.
+func (r *Response) TailStdout(n int) ([]string, error) {
+	if n <= 0 {
+		return nil, nil
+	}
+	if r.StdoutFile == "" {
+		return lastLines(splitLines(r.Stdout), n), nil
+	}
+	f, err := os.Open(r.StdoutFile)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	return tailLines(f, fi.Size(), n)
+}
+
+// tailBlockSize is the number of bytes tailLines reads at a time.
+const tailBlockSize = 4096
+
+// tailLines returns at most the final n lines of the size bytes of r,
+// reading backwards from its end only as much as is required.
+func tailLines(r io.ReaderAt, size int64, n int) ([]string, error) {
+	var tail []byte
+	var newlines int
+	// More than n newlines guarantees the final n lines are complete,
+	// whether or not the final line has a newline terminator.
+	for end := size; end > 0 && newlines <= n; {
+		start := end - tailBlockSize
+		if start < 0 {
+			start = 0
+		}
+		block := make([]byte, end-start)
+		if _, err := r.ReadAt(block, start); err != nil && err != io.EOF {
+			return nil, err
+		}
+		newlines += bytes.Count(block, []byte{'\n'})
+		tail = append(block, tail...)
+		end = start
+	}
+	return lastLines(splitLines(tail), n), nil
+}
+
+// lastLines returns at most the final n of lines.
+func lastLines(lines []string, n int) []string {
+	if len(lines) > n {
+		return lines[len(lines)-n:]
+	}
+	return lines
+}
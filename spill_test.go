@@ -0,0 +1,92 @@
+//go:build !windows
+// +build !windows
+
+package gorun
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestSpillStdout(t *testing.T) {
+	t.Run("spilled", func(t *testing.T) {
+		got, err := Run(context.Background(), &Request{
+			Path:             "/usr/bin/seq",
+			Args:             []string{"100000"},
+			SpillStdoutAfter: 1024,
+		})
+		ensureError(t, err, nil)
+		if got.StdoutFile == "" {
+			t.Fatalf("GOT: %q; WANT: spill file", got.StdoutFile)
+		}
+		defer os.Remove(got.StdoutFile)
+		if got.Stdout != nil {
+			t.Errorf("GOT: %d bytes; WANT: %v", len(got.Stdout), nil)
+		}
+
+		contents, err := os.ReadFile(got.StdoutFile)
+		ensureError(t, err, nil)
+		var want bytes.Buffer
+		for i := 1; i <= 100000; i++ {
+			want.WriteString(strconv.Itoa(i) + "\n")
+		}
+		if !bytes.Equal(contents, want.Bytes()) {
+			t.Errorf("GOT: %d bytes; WANT: %d bytes", len(contents), want.Len())
+		}
+
+		lines, err := got.TailStdout(3)
+		ensureError(t, err, nil)
+		if g, w := strings.Join(lines, ","), "99998,99999,100000"; g != w {
+			t.Errorf("GOT: %q; WANT: %q", g, w)
+		}
+
+		// Spans several of the blocks read from the end of the file.
+		lines, err = got.TailStdout(2000)
+		ensureError(t, err, nil)
+		if g, w := len(lines), 2000; g != w {
+			t.Fatalf("GOT: %v; WANT: %v", g, w)
+		}
+		if g, w := lines[0], "98001"; g != w {
+			t.Errorf("GOT: %q; WANT: %q", g, w)
+		}
+	})
+
+	t.Run("fewer lines than requested", func(t *testing.T) {
+		got, err := Run(context.Background(), &Request{
+			Path:             "/bin/sh",
+			Args:             []string{"-c", "printf 'one\\ntwo\\nthree'"},
+			SpillStdoutAfter: 4,
+		})
+		ensureError(t, err, nil)
+		defer os.Remove(got.StdoutFile)
+
+		lines, err := got.TailStdout(10)
+		ensureError(t, err, nil)
+		if g, w := strings.Join(lines, ","), "one,two,three"; g != w {
+			t.Errorf("GOT: %q; WANT: %q", g, w)
+		}
+	})
+
+	t.Run("not spilled", func(t *testing.T) {
+		got, err := Run(context.Background(), &Request{
+			Path:             "/usr/bin/seq",
+			Args:             []string{"5"},
+			SpillStdoutAfter: 1024,
+		})
+		ensureError(t, err, nil)
+		if got.StdoutFile != "" {
+			t.Errorf("GOT: %q; WANT: %q", got.StdoutFile, "")
+		}
+		ensureResponsesMatch(t, got, &Response{Stderr: []byte{}, Stdout: []byte("1\n2\n3\n4\n5\n")})
+
+		lines, err := got.TailStdout(2)
+		ensureError(t, err, nil)
+		if g, w := strings.Join(lines, ","), "4,5"; g != w {
+			t.Errorf("GOT: %q; WANT: %q", g, w)
+		}
+	})
+}
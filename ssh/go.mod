@@ -0,0 +1,10 @@
+module github.com/karrick/gorun/ssh
+
+go 1.21
+
+require github.com/karrick/gorun v0.0.0-20261015115613-ded287056610
+
+require (
+	golang.org/x/crypto v0.30.0
+	golang.org/x/sys v0.28.0 // indirect
+)
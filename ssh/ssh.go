@@ -0,0 +1,193 @@
+// Package ssh provides a gorun.Executor that runs requests on a remote
+// host over an SSH connection.
+package ssh
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"syscall"
+
+	"github.com/karrick/gorun"
+	"golang.org/x/crypto/ssh"
+)
+
+// Executor is a gorun.Executor that runs each Request in its own
+// session on the remote host of an established SSH connection.
+//
+// Because SSH servers execute commands using the login shell of the
+// remote user, Executor sends a quoted command line that changes to
+// Request.Dir when it is set, and when Request.Env or Request.EnvMap is
+// not nil, uses env(1) to replace the remote environment with their
+// entries, or to apply them to the remote environment when
+// Request.InheritEnv is true, matching how Run treats them on the local
+// host.
+type Executor struct {
+	// Client is the established SSH connection to the remote host.
+	Client *ssh.Client
+}
+
+// Run executes req on the remote host.
+//
+// 1. When it cannot open a session or start the command, it returns a
+// nil Response and a gorun.ErrSpawn error.
+//
+// 2. When the remote command terminated due to receiving a signal, it
+// returns a Response with a -1 Code, and a gorun.ErrSignal in Err.
+//
+// NOTE: If context.Context expires, or Request.Timeout elapses when it
+// is positive, Run sends a kill signal to the remote command and closes
+// the session, and the Response will have Code and Err set in
+// accordance with this case, except the gorun.ErrSignal is wrapped in a
+// gorun.ErrTimeout error when the deadline was exceeded, or a
+// gorun.ErrCanceled error when the context was canceled.
+//
+// 3. When the connection fails before the remote command reports its
+// exit status, it returns a nil Response and a gorun.ErrWait error.
+//
+// 4. Otherwise it returns a Response with Code set to the exit code of
+// the remote command, and Err set to nil.
+func (e *Executor) Run(ctx context.Context, req *gorun.Request) (*gorun.Response, error) {
+	var stderr, stdout bytes.Buffer
+
+	if err := ctx.Err(); err != nil {
+		return nil, gorun.ErrSpawn{Err: err}
+	}
+
+	if req.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, req.Timeout)
+		defer cancel()
+	}
+
+	session, err := e.Client.NewSession()
+	if err != nil {
+		return nil, gorun.ErrSpawn{Err: err}
+	}
+	defer session.Close()
+
+	session.Stdin = req.StdinReader()
+	if session.Stdin != nil && req.StdinTee != nil {
+		session.Stdin = io.TeeReader(session.Stdin, req.StdinTee)
+	}
+	session.Stderr = tee(&stderr, req.StderrTee)
+	session.Stdout = tee(&stdout, req.StdoutTee)
+
+	if err = session.Start(commandLine(req)); err != nil {
+		return nil, gorun.ErrSpawn{Err: err}
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			_ = session.Signal(ssh.SIGKILL)
+			_ = session.Close()
+		case <-done:
+		}
+	}()
+
+	resp := &gorun.Response{}
+
+	switch err = session.Wait(); e := err.(type) {
+	case nil:
+	case *ssh.ExitError:
+		if e.Signal() != "" {
+			resp.Code = -1
+			resp.Err = gorun.ErrSignal{Err: err, Signal: signals[e.Signal()]}
+		} else {
+			resp.Code = e.ExitStatus()
+		}
+	default:
+		if ctx.Err() != nil && (errors.Is(err, io.EOF) || isMissingExit(err)) {
+			// Session was closed in response to the context being
+			// done before the remote host reported the signal.
+			resp.Code = -1
+			resp.Err = gorun.ErrSignal{Err: errors.New("signal: killed"), Signal: syscall.SIGKILL}
+			break
+		}
+		return nil, gorun.ErrWait{Err: err}
+	}
+	resp.Err = contextError(ctx, resp.Err)
+
+	resp.Stderr = stderr.Bytes()
+	resp.Stdout = stdout.Bytes()
+	return resp, nil
+}
+
+// contextError wraps err in an ErrTimeout or ErrCanceled error when it
+// is an ErrSignal error and ctx is done, like gorun.Run does, because
+// the remote command was then most likely killed in response.
+func contextError(ctx context.Context, err error) error {
+	if _, ok := err.(gorun.ErrSignal); ok {
+		switch ctx.Err() {
+		case context.DeadlineExceeded:
+			return gorun.ErrTimeout{Err: err}
+		case context.Canceled:
+			return gorun.ErrCanceled{Err: err}
+		}
+	}
+	return err
+}
+
+func isMissingExit(err error) bool {
+	var eme *ssh.ExitMissingError
+	return errors.As(err, &eme)
+}
+
+// commandLine returns the command line the remote shell ought to
+// execute to honor the Dir, Env, Path, and Args of req.
+func commandLine(req *gorun.Request) string {
+	var words []string
+	if req.Dir != "" {
+		words = append(words, "cd", quote(req.Dir), "&&")
+	}
+	words = append(words, "exec")
+	if env := req.EnvList(); env != nil {
+		words = append(words, "env")
+		if !req.InheritEnv {
+			words = append(words, "-i")
+		}
+		for _, kv := range env {
+			words = append(words, quote(kv))
+		}
+	}
+	words = append(words, quote(req.Path))
+	for _, arg := range req.Args {
+		words = append(words, quote(arg))
+	}
+	return strings.Join(words, " ")
+}
+
+// signals maps the names of signals in SSH exit-signal messages, as
+// specified by RFC 4254, to their signals on this host.
+var signals = map[string]syscall.Signal{
+	"ABRT": syscall.SIGABRT,
+	"ALRM": syscall.SIGALRM,
+	"FPE":  syscall.SIGFPE,
+	"HUP":  syscall.SIGHUP,
+	"ILL":  syscall.SIGILL,
+	"INT":  syscall.SIGINT,
+	"KILL": syscall.SIGKILL,
+	"PIPE": syscall.SIGPIPE,
+	"QUIT": syscall.SIGQUIT,
+	"SEGV": syscall.SIGSEGV,
+	"TERM": syscall.SIGTERM,
+}
+
+// quote returns s quoted for a POSIX shell.
+func quote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// tee returns an io.Writer that writes to both w and t, or just w when
+// t is nil.
+func tee(w, t io.Writer) io.Writer {
+	if t == nil {
+		return w
+	}
+	return io.MultiWriter(w, t)
+}
@@ -0,0 +1,270 @@
+//go:build !windows
+// +build !windows
+
+package ssh
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"net"
+	"os/exec"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/karrick/gorun"
+	"golang.org/x/crypto/ssh"
+)
+
+// newTestClient starts an in-process SSH server that executes the
+// commands it receives with the local shell, and returns a client
+// connected to it.
+func newTestClient(tb testing.TB) *ssh.Client {
+	tb.Helper()
+
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	signer, err := ssh.NewSignerFromKey(key)
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	config := &ssh.ServerConfig{NoClientAuth: true}
+	config.AddHostKey(signer)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		tb.Fatal(err)
+	}
+	tb.Cleanup(func() { _ = listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go serveConn(conn, config)
+		}
+	}()
+
+	client, err := ssh.Dial("tcp", listener.Addr().String(), &ssh.ClientConfig{
+		User:            "test",
+		HostKeyCallback: ssh.FixedHostKey(signer.PublicKey()),
+	})
+	if err != nil {
+		tb.Fatal(err)
+	}
+	tb.Cleanup(func() { _ = client.Close() })
+	return client
+}
+
+func serveConn(conn net.Conn, config *ssh.ServerConfig) {
+	_, chans, reqs, err := ssh.NewServerConn(conn, config)
+	if err != nil {
+		return
+	}
+	go ssh.DiscardRequests(reqs)
+	for nc := range chans {
+		if nc.ChannelType() != "session" {
+			_ = nc.Reject(ssh.UnknownChannelType, "unsupported channel type")
+			continue
+		}
+		ch, requests, err := nc.Accept()
+		if err != nil {
+			continue
+		}
+		go serveSession(ch, requests)
+	}
+}
+
+func serveSession(ch ssh.Channel, requests <-chan *ssh.Request) {
+	defer ch.Close()
+
+	var cmd *exec.Cmd
+	exited := make(chan error, 1)
+
+	for {
+		select {
+		case req, ok := <-requests:
+			if !ok {
+				return
+			}
+			switch req.Type {
+			case "exec":
+				var payload struct{ Command string }
+				if err := ssh.Unmarshal(req.Payload, &payload); err != nil || cmd != nil {
+					_ = req.Reply(false, nil)
+					continue
+				}
+				cmd = exec.Command("/bin/sh", "-c", payload.Command)
+				cmd.Stdin = ch
+				cmd.Stdout = ch
+				cmd.Stderr = ch.Stderr()
+				if err := cmd.Start(); err != nil {
+					_ = req.Reply(false, nil)
+					return
+				}
+				_ = req.Reply(true, nil)
+				go func() { exited <- cmd.Wait() }()
+			case "signal":
+				if cmd != nil && cmd.Process != nil {
+					_ = cmd.Process.Kill()
+				}
+			default:
+				_ = req.Reply(false, nil)
+			}
+		case err := <-exited:
+			_ = ch.CloseWrite()
+			var ee *exec.ExitError
+			if errors.As(err, &ee) {
+				if ws, ok := ee.Sys().(syscall.WaitStatus); ok && ws.Signaled() {
+					_, _ = ch.SendRequest("exit-signal", false, ssh.Marshal(struct {
+						Signal     string
+						CoreDumped bool
+						Error      string
+						Lang       string
+					}{Signal: "KILL"}))
+					return
+				}
+			}
+			code := uint32(0)
+			if ee != nil {
+				code = uint32(ee.ExitCode())
+			}
+			_, _ = ch.SendRequest("exit-status", false, ssh.Marshal(struct{ Status uint32 }{code}))
+			return
+		}
+	}
+}
+
+func ensureError(tb testing.TB, got, want error) {
+	tb.Helper()
+	if got == nil {
+		if want != nil {
+			tb.Fatalf("GOT: %v; WANT: %T(%q)", got, want, want.Error())
+		}
+	} else if want == nil {
+		tb.Fatalf("GOT: %T(%q); WANT: %v", got, got.Error(), want)
+	} else if !errors.Is(got, want) {
+		tb.Fatalf("GOT: %T(%q); WANT: %T(%q)", got, got.Error(), want, want.Error())
+	}
+}
+
+func TestExecutor(t *testing.T) {
+	var executor gorun.Executor = &Executor{Client: newTestClient(t)}
+
+	t.Run("echo", func(t *testing.T) {
+		resp, err := executor.Run(context.Background(), &gorun.Request{
+			Path: "/bin/echo",
+			Args: []string{"one", "two words", "it's"},
+		})
+		ensureError(t, err, nil)
+		if got, want := string(resp.Stdout), "one two words it's\n"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+		if got, want := resp.Code, 0; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+
+	t.Run("inherit env", func(t *testing.T) {
+		// The test server runs commands in the environment of this
+		// process.
+		t.Setenv("GORUN_PARENT", "parent")
+		for _, tc := range []struct {
+			inherit bool
+			want    string
+		}{
+			{inherit: false, want: "asdf \n"},
+			{inherit: true, want: "asdf parent\n"},
+		} {
+			resp, err := executor.Run(context.Background(), &gorun.Request{
+				Path:       "/bin/sh",
+				Args:       []string{"-c", `echo "$GORUN $GORUN_PARENT"`},
+				Env:        []string{"GORUN=asdf"},
+				InheritEnv: tc.inherit,
+			})
+			ensureError(t, err, nil)
+			if got := string(resp.Stdout); got != tc.want {
+				t.Errorf("GOT: %q; WANT: %q", got, tc.want)
+			}
+		}
+	})
+
+	t.Run("stdin env dir and exit code", func(t *testing.T) {
+		dir := t.TempDir()
+		resp, err := executor.Run(context.Background(), &gorun.Request{
+			Path:  "/bin/sh",
+			Args:  []string{"-c", `echo "$GORUN $(pwd)" >&2; cat -; exit 13`},
+			Dir:   dir,
+			Env:   []string{"GORUN=asdf"},
+			Stdin: strings.NewReader("from stdin"),
+		})
+		ensureError(t, err, nil)
+		if got, want := resp.Code, 13; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		if got, want := string(resp.Stderr), "asdf "+dir+"\n"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+		if got, want := string(resp.Stdout), "from stdin"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+		resp, err := executor.Run(ctx, &gorun.Request{
+			Path: "/bin/sleep",
+			Args: []string{"5"},
+		})
+		ensureError(t, err, nil)
+		if got, want := resp.Code, -1; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		ensureError(t, resp.Err, gorun.ErrTimeout{})
+		var se gorun.ErrSignal
+		if errors.As(resp.Err, &se); se.Signal != syscall.SIGKILL {
+			t.Errorf("GOT: %v; WANT: %v", se.Signal, syscall.SIGKILL)
+		}
+	})
+
+	t.Run("request timeout", func(t *testing.T) {
+		started := time.Now()
+		resp, err := executor.Run(context.Background(), &gorun.Request{
+			Path:    "/bin/sleep",
+			Args:    []string{"5"},
+			Timeout: 100 * time.Millisecond,
+		})
+		ensureError(t, err, nil)
+		if elapsed := time.Since(started); elapsed > 2*time.Second {
+			t.Errorf("GOT: %v; WANT: < %v", elapsed, 2*time.Second)
+		}
+		if got, want := resp.Code, -1; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		ensureError(t, resp.Err, gorun.ErrTimeout{})
+	})
+
+	t.Run("canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(100*time.Millisecond, cancel)
+		resp, err := executor.Run(ctx, &gorun.Request{
+			Path: "/bin/sleep",
+			Args: []string{"5"},
+		})
+		ensureError(t, err, nil)
+		if got, want := resp.Code, -1; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		ensureError(t, resp.Err, gorun.ErrCanceled{})
+		ensureError(t, resp.Err, gorun.ErrSignal{})
+	})
+}
@@ -0,0 +1,80 @@
+package gorun
+
+import (
+	"errors"
+	"io"
+	"sync"
+	"time"
+)
+
+// errStdinIdle is returned by idleReader once its timeout expires.
+var errStdinIdle = errors.New("stdin idle timeout")
+
+// idleReader invokes expire when a Read from r blocks for longer than
+// timeout, which happens when the caller stops providing input. Time
+// spent between reads, while the child process is not consuming its
+// input, does not count toward the timeout.
+//
+// Because a blocked Read of r cannot be interrupted, each Read of r is
+// performed in its own goroutine, so the Read of idleReader can return
+// once the timeout expires, allowing the child process to be reaped.
+type idleReader struct {
+	r       io.Reader
+	timeout time.Duration
+	expire  func()
+
+	once    sync.Once
+	expired chan struct{}
+}
+
+type readResult struct {
+	buf []byte
+	err error
+}
+
+func newIdleReader(r io.Reader, timeout time.Duration, expire func()) *idleReader {
+	return &idleReader{r: r, timeout: timeout, expire: expire, expired: make(chan struct{})}
+}
+
+func (ir *idleReader) Read(p []byte) (int, error) {
+	select {
+	case <-ir.expired:
+		return 0, errStdinIdle
+	default:
+	}
+
+	results := make(chan readResult, 1)
+	go func() {
+		buf := make([]byte, len(p))
+		n, err := ir.r.Read(buf)
+		results <- readResult{buf: buf[:n], err: err}
+	}()
+
+	timer := time.NewTimer(ir.timeout)
+	defer timer.Stop()
+
+	select {
+	case res := <-results:
+		return copy(p, res.buf), res.err
+	case <-timer.C:
+		ir.once.Do(func() {
+			close(ir.expired)
+			ir.expire()
+		})
+		return 0, errStdinIdle
+	}
+}
+
+// timedOut returns true when the timeout expired. It returns false when
+// invoked on a nil idleReader.
+func (ir *idleReader) timedOut() bool {
+	if ir == nil {
+		return false
+	}
+	select {
+	case <-ir.expired:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,64 @@
+package gorun
+
+import "strings"
+
+// String returns the command line of req in a form that may be pasted
+// into a POSIX shell: a cd to Dir when it is not empty, followed by an
+// env command with the environment assignments of EnvList when it is not
+// nil, followed by Path and Args. Env is invoked with -i unless
+// InheritEnv is true, so the child process receives only those
+// assignments, as it would from Run. Words that contain white space or
+// characters special to the shell are single quoted, as is Path when it
+// contains "=", so the shell does not take it for an assignment.
+// References in the assignments are not expanded, even when ExpandEnv
+// is true.
+func (req *Request) String() string {
+	var words []string
+	if req.Dir != "" {
+		words = append(words, "cd", shellQuote(req.Dir), "&&")
+	}
+	if env := req.EnvList(); env != nil {
+		words = append(words, "env")
+		if !req.InheritEnv {
+			words = append(words, "-i")
+		}
+		for _, kv := range env {
+			words = append(words, shellQuote(kv))
+		}
+	}
+	words = append(words, shellQuoteCommand(req.Path))
+	for _, arg := range req.Args {
+		words = append(words, shellQuote(arg))
+	}
+	return strings.Join(words, " ")
+}
+
+// shellQuote returns s, single quoted when it is empty or contains any
+// character other than those a POSIX shell never treats specially.
+func shellQuote(s string) string {
+	if s != "" && strings.Trim(s, shellSafe) == "" {
+		return s
+	}
+	return singleQuote(s)
+}
+
+// shellQuoteCommand returns s quoted like shellQuote does, except it is
+// also single quoted when it contains "=", because a POSIX shell takes
+// an unquoted word of that form in the command position for a variable
+// assignment rather than the name of the program to execute.
+func shellQuoteCommand(s string) string {
+	if strings.Contains(s, "=") {
+		return singleQuote(s)
+	}
+	return shellQuote(s)
+}
+
+// singleQuote returns s enclosed in single quotes, with each single
+// quote within it escaped.
+func singleQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// shellSafe is the set of characters that need not be quoted in a word
+// of a POSIX shell command line.
+const shellSafe = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789%+,-./:=@_"
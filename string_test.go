@@ -0,0 +1,105 @@
+//go:build !windows
+// +build !windows
+
+package gorun
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRequestString(t *testing.T) {
+	tests := []struct {
+		name string
+		req  *Request
+		want string
+	}{
+		{
+			name: "no args",
+			req:  &Request{Path: "/usr/bin/true"},
+			want: "/usr/bin/true",
+		},
+		{
+			name: "plain args",
+			req:  &Request{Path: "/bin/echo", Args: []string{"-n", "a.b/c", "key=value"}},
+			want: "/bin/echo -n a.b/c key=value",
+		},
+		{
+			name: "spaces",
+			req:  &Request{Path: "/bin/echo", Args: []string{"hello world", "\ttab"}},
+			want: "/bin/echo 'hello world' '\ttab'",
+		},
+		{
+			name: "quotes",
+			req:  &Request{Path: "/bin/echo", Args: []string{"it's", `say "hi"`, "'"}},
+			want: `/bin/echo 'it'\''s' 'say "hi"' ''\'''`,
+		},
+		{
+			name: "special characters",
+			req:  &Request{Path: "/bin/echo", Args: []string{"$HOME", "a;b", "*", "~"}},
+			want: "/bin/echo '$HOME' 'a;b' '*' '~'",
+		},
+		{
+			name: "empty arg",
+			req:  &Request{Path: "/bin/echo", Args: []string{""}},
+			want: "/bin/echo ''",
+		},
+		{
+			name: "path with equals sign",
+			req:  &Request{Path: "./a=b", Args: []string{"c=d"}},
+			want: "'./a=b' c=d",
+		},
+		{
+			name: "dir",
+			req:  &Request{Path: "/bin/ls", Dir: "/tmp/my dir"},
+			want: "cd '/tmp/my dir' && /bin/ls",
+		},
+		{
+			name: "env",
+			req:  &Request{Path: "/usr/bin/env", Env: []string{"A=1", "B=two words"}},
+			want: "env -i A=1 'B=two words' /usr/bin/env",
+		},
+		{
+			name: "inherit env",
+			req:  &Request{Path: "/usr/bin/env", EnvMap: map[string]string{"A": "1"}, InheritEnv: true},
+			want: "env A=1 /usr/bin/env",
+		},
+		{
+			name: "empty env",
+			req:  &Request{Path: "/usr/bin/env", Env: []string{}},
+			want: "env -i /usr/bin/env",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.req.String(); got != tt.want {
+				t.Errorf("GOT: %q; WANT: %q", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("round trip", func(t *testing.T) {
+		args := []string{"hello world", "it's", `"quoted"`, "", "$HOME", "a\nb"}
+		req := &Request{Path: "/usr/bin/printf", Args: append([]string{"%s|"}, args...)}
+		want, err := Run(context.Background(), req)
+		ensureError(t, err, nil)
+		got, err := Run(context.Background(), &Request{Path: "/bin/sh", Args: []string{"-c", req.String()}})
+		ensureError(t, err, nil)
+		ensureResponsesMatch(t, got, want)
+	})
+
+	t.Run("path with equals sign round trip", func(t *testing.T) {
+		dir := t.TempDir()
+		ensureError(t, os.WriteFile(filepath.Join(dir, "a=b"), []byte("#!/bin/sh\necho ran\n"), 0o755), nil)
+		t.Setenv("PATH", dir+":"+os.Getenv("PATH"))
+		req := &Request{Path: "a=b"}
+		got, err := Run(context.Background(), &Request{Path: "/bin/sh", Args: []string{"-c", req.String()}})
+		ensureError(t, err, nil)
+		if got, want := string(got.Stdout), "ran\n"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+	})
+}
@@ -0,0 +1,44 @@
+//go:build !windows
+// +build !windows
+
+package gorun
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestSwapStdoutStderr(t *testing.T) {
+	t.Run("buffers", func(t *testing.T) {
+		got, err := Run(context.Background(), &Request{
+			Path:             "/bin/sh",
+			Args:             []string{"-c", "echo out; echo err >&2"},
+			SwapStdoutStderr: true,
+		})
+		ensureError(t, err, nil)
+		ensureResponsesMatch(t, got, &Response{Stderr: []byte("out\n"), Stdout: []byte("err\n")})
+	})
+
+	t.Run("writers and limits", func(t *testing.T) {
+		var stdout bytes.Buffer
+		got, err := Run(context.Background(), &Request{
+			Path:             "/bin/sh",
+			Args:             []string{"-c", "echo out; echo err >&2; echo more >&2"},
+			StdoutWriter:     &stdout,
+			MaxStdoutBytes:   4,
+			SwapStdoutStderr: true,
+		})
+		ensureError(t, err, nil)
+		if got, want := stdout.String(), "err\n"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+		if got, want := string(got.Stderr), "out\n"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+		if !errors.Is(got.Err, ErrOutputTooLarge{}) {
+			t.Errorf("GOT: %v; WANT: %v", got.Err, ErrOutputTooLarge{})
+		}
+	})
+}
@@ -0,0 +1,54 @@
+package gorun
+
+import (
+	"os"
+	"strings"
+)
+
+// TableOptions control how StdoutTable splits the lines of standard
+// output into fields.
+type TableOptions struct {
+	// Delimiter separates the fields of each line. When empty, fields
+	// are separated by runs of white space, and leading and trailing
+	// white space is ignored, as in the output of programs like ps and
+	// df. Otherwise each occurrence of Delimiter separates two fields,
+	// so adjacent delimiters delimit an empty field.
+	Delimiter string
+
+	// SkipHeader, when true, omits the first line, which holds the
+	// column headings rather than data.
+	SkipHeader bool
+}
+
+// StdoutTable returns the lines the child process wrote to its standard
+// output, each split into fields as specified by opts. Empty lines are
+// omitted. Rows need not have the same number of fields as each other;
+// it is up to the caller to decide how to handle a row with missing or
+// extra fields. When its standard output was spilled to StdoutFile, that
+// file is read, and any error reading it is returned.
+func (r *Response) StdoutTable(opts TableOptions) ([][]string, error) {
+	stdout := r.Stdout
+	if r.StdoutFile != "" {
+		var err error
+		if stdout, err = os.ReadFile(r.StdoutFile); err != nil {
+			return nil, err
+		}
+	}
+	lines := splitLines(stdout)
+	if opts.SkipHeader && len(lines) > 0 {
+		lines = lines[1:]
+	}
+	var rows [][]string
+	for _, line := range lines {
+		line = strings.TrimSuffix(line, "\r")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if opts.Delimiter == "" {
+			rows = append(rows, strings.Fields(line))
+		} else {
+			rows = append(rows, strings.Split(line, opts.Delimiter))
+		}
+	}
+	return rows, nil
+}
@@ -0,0 +1,47 @@
+//go:build !windows
+// +build !windows
+
+package gorun
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestStdoutTable(t *testing.T) {
+	t.Run("white space", func(t *testing.T) {
+		got, err := Run(context.Background(), &Request{
+			Path: "/usr/bin/printf",
+			Args: []string{"PID   TTY   CMD\n  1   ?     init\n\n 42   pts/0 sh -c\n  7\n"},
+		})
+		ensureError(t, err, nil)
+		rows, err := got.StdoutTable(TableOptions{SkipHeader: true})
+		ensureError(t, err, nil)
+		want := [][]string{
+			{"1", "?", "init"},
+			{"42", "pts/0", "sh", "-c"},
+			{"7"},
+		}
+		if !reflect.DeepEqual(rows, want) {
+			t.Errorf("GOT: %q; WANT: %q", rows, want)
+		}
+	})
+	t.Run("delimiter", func(t *testing.T) {
+		got, err := Run(context.Background(), &Request{
+			Path: "/usr/bin/printf",
+			Args: []string{"name:uid:shell\nroot:0:/bin/sh\nnobody::"},
+		})
+		ensureError(t, err, nil)
+		rows, err := got.StdoutTable(TableOptions{Delimiter: ":"})
+		ensureError(t, err, nil)
+		want := [][]string{
+			{"name", "uid", "shell"},
+			{"root", "0", "/bin/sh"},
+			{"nobody", "", ""},
+		}
+		if !reflect.DeepEqual(rows, want) {
+			t.Errorf("GOT: %q; WANT: %q", rows, want)
+		}
+	})
+}
@@ -0,0 +1,28 @@
+package gorun
+
+import (
+	"context"
+	"os"
+)
+
+// Terminator is the interface implemented by values that know how to
+// terminate a child process when the context used to run it is done
+// before the child process exits. Implementations may signal the
+// process, its process group, or some other resource that contains it,
+// but must ensure the child process eventually exits, because Run
+// waits for it to do so.
+//
+// The provided context is not done, but carries the values of the
+// context used to run the child process.
+type Terminator interface {
+	Terminate(ctx context.Context, p *os.Process) error
+}
+
+// TerminatorFunc is an adapter to allow the use of an ordinary
+// function as a Terminator.
+type TerminatorFunc func(ctx context.Context, p *os.Process) error
+
+// Terminate calls f(ctx, p).
+func (f TerminatorFunc) Terminate(ctx context.Context, p *os.Process) error {
+	return f(ctx, p)
+}
@@ -0,0 +1,50 @@
+//go:build !windows
+// +build !windows
+
+package gorun
+
+import (
+	"context"
+	"errors"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestTerminator(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	var invoked int
+	var pid int
+
+	got, err := Run(ctx, &Request{
+		Path: "/bin/sleep",
+		Args: []string{"1"},
+		Terminator: TerminatorFunc(func(ctx context.Context, p *os.Process) error {
+			if err := ctx.Err(); err != nil {
+				t.Errorf("GOT: %v; WANT: %v", err, nil)
+			}
+			invoked++
+			pid = p.Pid
+			return p.Signal(syscall.SIGTERM)
+		}),
+	})
+	ensureError(t, err, nil)
+
+	if got, want := invoked, 1; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+	if pid <= 0 {
+		t.Errorf("GOT: %v; WANT: > 0", pid)
+	}
+
+	want := &Response{
+		Code:   -1,
+		Err:    ErrSignal{Err: errors.New("signal: terminated")},
+		Stderr: []byte{},
+		Stdout: []byte{},
+	}
+	ensureResponsesMatch(t, got, want)
+}
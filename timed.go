@@ -0,0 +1,66 @@
+package gorun
+
+import (
+	"io"
+	"sync"
+	"time"
+)
+
+// Stream identifiers used to tag output from a child process.
+const (
+	// StreamStdout identifies the standard output of a child process.
+	StreamStdout = 1
+
+	// StreamStderr identifies the standard error of a child process.
+	StreamStderr = 2
+)
+
+// TimedChunk represents a chunk of output read from a child process.
+type TimedChunk struct {
+	// At is the time the chunk was read from the child process.
+	At time.Time
+
+	// Data is the chunk of output.
+	Data []byte
+
+	// Stream is either StreamStdout or StreamStderr, to identify
+	// which stream the chunk was read from.
+	Stream int
+}
+
+// timedRecorder records the chunks written to any of its streams in
+// the order they were written.
+type timedRecorder struct {
+	lock   sync.Mutex
+	chunks []TimedChunk
+}
+
+// stream returns an io.Writer that records each chunk written to it as
+// having been read from the specified stream, or nil when tr is nil.
+func (tr *timedRecorder) stream(stream int) io.Writer {
+	if tr == nil {
+		return nil
+	}
+	return timedWriter{tr: tr, stream: stream}
+}
+
+type timedWriter struct {
+	tr     *timedRecorder
+	stream int
+}
+
+func (tw timedWriter) Write(p []byte) (int, error) {
+	// The caller may reuse p after Write returns.
+	data := make([]byte, len(p))
+	copy(data, p)
+
+	tw.tr.lock.Lock()
+	tw.tr.chunks = append(tw.tr.chunks, TimedChunk{
+		At:     time.Now(),
+		Data:   data,
+		Stream: tw.stream,
+	})
+	tw.tr.lock.Unlock()
+
+	return len(p), nil
+}
@@ -0,0 +1,50 @@
+//go:build !windows
+// +build !windows
+
+package gorun
+
+import (
+	"context"
+	"testing"
+)
+
+func TestTimedOutput(t *testing.T) {
+	t.Run("disabled", func(t *testing.T) {
+		got, err := Run(context.Background(), &Request{
+			Path: "/bin/echo",
+		})
+		ensureError(t, err, nil)
+		if got.TimedOutput != nil {
+			t.Errorf("GOT: %v; WANT: %v", got.TimedOutput, nil)
+		}
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		got, err := Run(context.Background(), &Request{
+			Path:        "/bin/sh",
+			Args:        []string{"-c", "echo one; sleep 0.05; echo two >&2; sleep 0.05; echo three"},
+			TimedOutput: true,
+		})
+		ensureError(t, err, nil)
+
+		want := []TimedChunk{
+			{Stream: StreamStdout, Data: []byte("one\n")},
+			{Stream: StreamStderr, Data: []byte("two\n")},
+			{Stream: StreamStdout, Data: []byte("three\n")},
+		}
+		if g, w := len(got.TimedOutput), len(want); g != w {
+			t.Fatalf("GOT: %v; WANT: %v", g, w)
+		}
+		for i, chunk := range got.TimedOutput {
+			if g, w := chunk.Stream, want[i].Stream; g != w {
+				t.Errorf("chunk %d: GOT: %v; WANT: %v", i, g, w)
+			}
+			if g, w := string(chunk.Data), string(want[i].Data); g != w {
+				t.Errorf("chunk %d: GOT: %q; WANT: %q", i, g, w)
+			}
+			if i > 0 && !chunk.At.After(got.TimedOutput[i-1].At) {
+				t.Errorf("chunk %d: GOT: %v; WANT: after %v", i, chunk.At, got.TimedOutput[i-1].At)
+			}
+		}
+	})
+}
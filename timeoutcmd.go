@@ -0,0 +1,43 @@
+package gorun
+
+import (
+	"context"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// timeoutKillAfter is how long the timeout program waits after sending
+// SIGTERM to the command before sending it SIGKILL.
+const timeoutKillAfter = "1"
+
+// timeoutCommand returns the path and arguments that run path with args
+// under the timeout program, limited to the time remaining until the
+// deadline of ctx, and true. When ctx has no deadline, or the timeout
+// program is not available, it returns path and args unmodified, and
+// false.
+func timeoutCommand(ctx context.Context, path string, args []string) (string, []string, bool) {
+	deadline, ok := ctx.Deadline()
+	if !ok || runtime.GOOS == "windows" {
+		return path, args, false
+	}
+	timeout, err := exec.LookPath("timeout")
+	if err != nil {
+		return path, args, false
+	}
+	remaining := time.Until(deadline)
+	if remaining < time.Millisecond {
+		remaining = time.Millisecond
+	}
+	targs := []string{"-k", timeoutKillAfter, strconv.FormatFloat(remaining.Seconds(), 'f', 3, 64), path}
+	return timeout, append(targs, args...), true
+}
+
+// terminateTimeoutCommand sends SIGTERM to the timeout program, which
+// forwards it to the command it runs, rather than killing the timeout
+// program and leaving the command running.
+func terminateTimeoutCommand(cmd *exec.Cmd) func() error {
+	return func() error { return cmd.Process.Signal(syscall.SIGTERM) }
+}
@@ -0,0 +1,55 @@
+//go:build !windows
+// +build !windows
+
+package gorun
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestUseTimeoutCommand(t *testing.T) {
+	if _, err := exec.LookPath("timeout"); err != nil {
+		t.Skip("timeout program not available")
+	}
+	if _, err := os.Stat("/proc/self/comm"); err != nil {
+		t.Skip("cannot identify parent process without /proc")
+	}
+
+	t.Run("kills long command", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+		defer cancel()
+		started := time.Now()
+		got, err := Run(ctx, &Request{
+			Path:              "/bin/sh",
+			Args:              []string{"-c", "cat /proc/$PPID/comm; exec sleep 5"},
+			UseTimeoutCommand: true,
+		})
+		ensureError(t, err, nil)
+		if elapsed := time.Since(started); elapsed > 2*time.Second {
+			t.Errorf("GOT: %v; WANT: < %v", elapsed, 2*time.Second)
+		}
+		if got, want := strings.TrimSpace(string(got.Stdout)), "timeout"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+		if got.Code == 0 {
+			t.Errorf("GOT: %v; WANT: non-zero", got.Code)
+		}
+	})
+
+	t.Run("without deadline", func(t *testing.T) {
+		got, err := Run(context.Background(), &Request{
+			Path:              "/bin/sh",
+			Args:              []string{"-c", "cat /proc/$PPID/comm"},
+			UseTimeoutCommand: true,
+		})
+		ensureError(t, err, nil)
+		if got := strings.TrimSpace(string(got.Stdout)); got == "timeout" {
+			t.Errorf("GOT: %q; WANT: not run under timeout", got)
+		}
+	})
+}
@@ -0,0 +1,55 @@
+//go:build !windows
+// +build !windows
+
+package gorun
+
+import (
+	"bytes"
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	t.Run("valid", func(t *testing.T) {
+		err := (&Request{
+			Path: "/bin/echo",
+			Dir:  t.TempDir(),
+			Env:  []string{"A=1", "B="},
+		}).Validate()
+		ensureError(t, err, nil)
+	})
+
+	t.Run("empty path", func(t *testing.T) {
+		err := (&Request{}).Validate()
+		ensureError(t, err, ErrInvalidRequest{Err: errors.New("cannot leave Path empty")})
+	})
+
+	t.Run("missing dir", func(t *testing.T) {
+		dir := filepath.Join(t.TempDir(), "missing")
+		err := (&Request{Path: "/bin/echo", Dir: dir}).Validate()
+		if !errors.Is(err, ErrInvalidRequest{}) || !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("GOT: %v; WANT: %v", err, fs.ErrNotExist)
+		}
+	})
+
+	t.Run("dir not a directory", func(t *testing.T) {
+		err := (&Request{Path: "/bin/echo", Dir: "/bin/echo"}).Validate()
+		ensureError(t, err, ErrInvalidRequest{Err: errors.New(`Dir is not a directory: "/bin/echo"`)})
+	})
+
+	t.Run("malformed env", func(t *testing.T) {
+		err := (&Request{Path: "/bin/echo", Env: []string{"A=1", "NOEQUALS"}}).Validate()
+		ensureError(t, err, ErrInvalidRequest{Err: errors.New(`invalid Env entry: "NOEQUALS"`)})
+	})
+
+	t.Run("checks Run performs", func(t *testing.T) {
+		err := (&Request{
+			Path:         "/bin/echo",
+			StdoutWriter: new(bytes.Buffer),
+			StdoutBuffer: new(bytes.Buffer),
+		}).Validate()
+		ensureError(t, err, ErrInvalidRequest{Err: errors.New("cannot set StdoutWriter along with StdoutBuffer, StdoutGrep, DecompressStdout, or HashStdout")})
+	})
+}
@@ -0,0 +1,74 @@
+//go:build !windows
+// +build !windows
+
+package gorun
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestOutputWriters(t *testing.T) {
+	t.Run("stdout writer with buffered stderr", func(t *testing.T) {
+		var stdout bytes.Buffer
+		got, err := Run(context.Background(), &Request{
+			Path:         "/bin/sh",
+			Args:         []string{"-c", "echo out; echo err >&2"},
+			StdoutWriter: &stdout,
+		})
+		ensureError(t, err, nil)
+		if got.Stdout != nil {
+			t.Errorf("GOT: %q; WANT: %v", got.Stdout, nil)
+		}
+		if got, want := string(got.Stderr), "err\n"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+		if got, want := stdout.String(), "out\n"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+	})
+	t.Run("stderr writer with buffered stdout", func(t *testing.T) {
+		var stderr bytes.Buffer
+		got, err := Run(context.Background(), &Request{
+			Path:         "/bin/sh",
+			Args:         []string{"-c", "echo out; echo err >&2"},
+			StderrWriter: &stderr,
+		})
+		ensureError(t, err, nil)
+		if got.Stderr != nil {
+			t.Errorf("GOT: %q; WANT: %v", got.Stderr, nil)
+		}
+		if got, want := string(got.Stdout), "out\n"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+		if got, want := stderr.String(), "err\n"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+	})
+	t.Run("both writers", func(t *testing.T) {
+		var stderr, stdout bytes.Buffer
+		got, err := Run(context.Background(), &Request{
+			Path:         "/bin/sh",
+			Args:         []string{"-c", "echo out; echo err >&2"},
+			StderrWriter: &stderr,
+			StdoutWriter: &stdout,
+		})
+		ensureError(t, err, nil)
+		if got.Stderr != nil || got.Stdout != nil {
+			t.Errorf("GOT: %q, %q; WANT: nil, nil", got.Stdout, got.Stderr)
+		}
+		if got, want := stdout.String()+stderr.String(), "out\nerr\n"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+	})
+	t.Run("stderr writer conflicts with buffer", func(t *testing.T) {
+		_, err := Run(context.Background(), &Request{
+			Path:         "/usr/bin/true",
+			StderrWriter: new(bytes.Buffer),
+			StderrBuffer: new(bytes.Buffer),
+		})
+		ensureError(t, err, ErrInvalidRequest{Err: errors.New("cannot set StderrWriter along with StderrBuffer or StderrHeadBytes")})
+	})
+}